@@ -2,6 +2,7 @@ package main
 
 import (
 	"os"
+	"strconv"
 
 	"github.com/james-nesbitt/coach/conf"
 	"github.com/james-nesbitt/coach/log"
@@ -60,7 +61,7 @@ func main() {
 
 	if !(operationName == "init" || operationName == "init-generate" || operationName == "help" || project.IsValid(logger.MakeChild("Sanity Check"))) {
 		logger.Error("Coach project configuration is not processable.  Execution halted. [" + operationName + "]")
-		return
+		os.Exit(1)
 	}
 
 	logger.Debug(log.VERBOSITY_DEBUG, "Starting CLI Processing", nil)
@@ -87,9 +88,27 @@ func main() {
 	/**
 	 * convert the target string list from the arguments into a target set
 	 */
-	targets := nodes.Targets(logger.MakeChild("targets"), mainTargets)
+	targetsOptions := libs.TargetsOptions{
+		WithDependencies: project.TargetDependencies || globalFlags["with-deps"] != "",
+		WithDependents:   project.TargetDependents || globalFlags["with-dependents"] != "",
+	}
+	targets := nodes.Targets(logger.MakeChild("targets"), mainTargets, targetsOptions)
 	logger.Debug(log.VERBOSITY_DEBUG, "Sorted Targets", mainTargets, targets)
 
+	// node dependency cycles have no processing order, so nothing should be run
+	if _, hasCycle := targets.Cycle(); hasCycle && !(operationName == "init" || operationName == "init-generate" || operationName == "help") {
+		logger.Error("Coach node dependencies are not processable.  Execution halted. [" + operationName + "]")
+		os.Exit(1)
+	}
+
+	if globalFlags["parallel"] != "" {
+		if parallel, err := strconv.Atoi(globalFlags["parallel"]); err == nil && parallel > 0 {
+			targets.SetParallel(parallel)
+		} else {
+			logger.Warning("Invalid --parallel worker count [" + globalFlags["parallel"] + "], so nodes will be processed one at a time")
+		}
+	}
+
 	/**
 	 * Maybe we can come up with a better default operation?
 	 *
@@ -130,7 +149,15 @@ func main() {
 	operations := operation.MakeOperation(logger.MakeChild("operations"), project, operationName, operationFlags, targets)
 	logger.Debug(log.VERBOSITY_DEBUG, "OPERATION:", operationName, operationFlags, operations)
 
-	operations.Run(logger.MakeChild("operation"))
+	if globalFlags["output"] != "" && !operations.SetOutput(globalFlags["output"]) {
+		os.Exit(1)
+	}
+
+	exitCode := operations.Run(logger.MakeChild("operation"))
 
 	logger.Debug(log.VERBOSITY_DEBUG, "Finished CLI Processing", nil)
+
+	if exitCode != 0 {
+		os.Exit(exitCode)
+	}
 }
@@ -10,12 +10,16 @@ import (
 const (
 	COACH_PROJECT_FLAG_DEFAULT_UsePathsAsTokens        = true
 	COACH_PROJECT_FLAG_DEFAULT_UseEnvVariablesAsTokens = false
+	COACH_PROJECT_FLAG_DEFAULT_TargetDependencies      = false
+	COACH_PROJECT_FLAG_DEFAULT_TargetDependents        = false
 )
 
 func MakeProjectFlags() Flags {
 	return Flags{
 		UsePathsAsTokens:        COACH_PROJECT_FLAG_DEFAULT_UsePathsAsTokens,
 		UseEnvVariablesAsTokens: COACH_PROJECT_FLAG_DEFAULT_UseEnvVariablesAsTokens,
+		TargetDependencies:      COACH_PROJECT_FLAG_DEFAULT_TargetDependencies,
+		TargetDependents:        COACH_PROJECT_FLAG_DEFAULT_TargetDependents,
 	}
 }
 
@@ -23,6 +27,8 @@ func MakeProjectFlags() Flags {
 type Flags struct {
 	UsePathsAsTokens        bool // Should all of the paths be available as tokens
 	UseEnvVariablesAsTokens bool // Should the running user ENV variables be available as tokens
+	TargetDependencies      bool // Should targets include the nodes that they depend on
+	TargetDependents        bool // Should targets include the nodes that depend on them
 }
 
 func (project *Project) ProcessFlags(logger log.Log) {
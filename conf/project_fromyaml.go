@@ -100,6 +100,10 @@ func (conf *conf_Yaml) configureProject(logger log.Log, project *Project) bool {
 			project.UsePathsAsTokens = conf.SettingStringToFlag(value)
 		case "UseEnvVariablesAsTokens":
 			project.UseEnvVariablesAsTokens = conf.SettingStringToFlag(value)
+		case "TargetDependencies":
+			project.TargetDependencies = conf.SettingStringToFlag(value)
+		case "TargetDependents":
+			project.TargetDependents = conf.SettingStringToFlag(value)
 		}
 	}
 
@@ -43,6 +43,23 @@ func parseGlobalFlags(flags []string) (operationName string, targetIdentifiers [
 		case "--staaap":
 			globalFlags["verbosity"] = "staaap"
 
+		case "--parallel": // how many nodes can be processed at the same time
+			if index+1 < len(flags) {
+				index++
+				globalFlags["parallel"] = flags[index]
+			}
+
+		case "--output": // text, json or yaml output for reporting operations
+			if index+1 < len(flags) {
+				index++
+				globalFlags["output"] = flags[index]
+			}
+
+		case "--with-deps": // add the nodes that the targets depend on
+			globalFlags["with-deps"] = "yes"
+		case "--with-dependents": // add the nodes that depend on the targets
+			globalFlags["with-dependents"] = "yes"
+
 		case "--all": // this is default anyway
 			targetIdentifiers = append(targetIdentifiers, "$all")
 
@@ -8,6 +8,10 @@ func (help *Help) getCoreHelpYaml() []byte {
 
   SEE ALSO:
   - cli:targets : $/> coach help cli:targets
+  - cli:parallel : $/> coach help cli:parallel
+  - cli:output : $/> coach help cli:output
+  - cli:format : $/> coach help cli:format
+  - cli:summary : $/> coach help cli:summary
   - operations : $/> coach help operations
 
 "cli:targets": |
@@ -36,7 +40,119 @@ func (help *Help) getCoreHelpYaml() []byte {
     $/> coach %volume:single commit
     commit the "single" instance of all nodes of type "volume"
 
-settings: |
+  Targets are processed in dependency order, using the node Requires, Links and VolumesFrom settings.  If the
+  dependencies form a cycle, then coach reports the cycle (e.g. www -> fpm -> www), and halts before running the
+  operation.  A Requires entry that doesn't name a node is ignored, with a warning.
+
+  Teardown operations (stop, remove, clean and destroy) process targets in reverse dependency order, so that a
+  node is stopped or removed before the nodes that it depends on.  The restart operation stops nodes in reverse
+  order, and then starts them in dependency order.
+
+  Only the targeted nodes are processed, unless one of these global flags is used:
+
+  --with-deps : also target the nodes that the targets depend on, and their dependencies
+  --with-dependents : also target the nodes that depend on the targets, and their dependents
+
+    $/> coach --with-deps @www up
+    bring up the "www" node, and any nodes that it links to, mounts volumes from, or requires
+
+    $/> coach --with-dependents @db stop
+    stop the "db" node, and any nodes that need it
+
+  The added nodes are targeted without instance filters, like @{node} targets.  A project can always add them,
+  using the conf.yml settings:
+
+    Settings:
+      TargetDependencies: yes
+      TargetDependents: yes
+
+"cli:parallel": |
+
+  By default, coach processes target nodes one at a time, in dependency order.  The --parallel global flag lets
+  operations process target nodes that don't depend on each other at the same time, using up to {workers} workers:
+
+    $/> coach --parallel 4 pull
+
+  The nodes are processed one dependency level at a time, so a node is never processed before the nodes that it
+  depends on are finished (or after them, for teardown operations).  The output for each node is held until the node is finished, so that output from
+  different nodes doesn't get mixed together.
+
+  Interactive operations such as run, and reporting operations such as info and status, always process nodes one
+  at a time.
+
+  With or without --parallel, when a node fails in a way that its dependents can't recover from, the rest of its
+  dependency level is still processed, but the nodes in later levels are not run.
+
+"cli:summary": |
+
+  When operations such as up, start, stop or clean change nodes and instances, coach writes a summary table after the
+  operations are finished.  Each node and instance row lists the actions that were attempted, and which of them
+  succeeded, were skipped, failed, or were not run:
+
+    |=  Node  Instance  Attempted      Succeeded  Skipped                  Failed  Not run
+    |-  db    single    create, start  create     start (already running)  --      --
+    |-  www   single    create, start  create     --                       start   --
+    |-  app   --        process        --         --                       --      process (an earlier target failed)
+
+  An action is skipped when nothing needed to be done, such as creating a container for an instance that already
+  has one.  A node is not run when the operation stopped before reaching it.  The error for each failed action is
+  listed after the table.
+
+  EXIT CODES:
+  - 0 : all of the actions succeeded, or were skipped
+  - 1 : an action failed, or the operation or project configuration could not be used
+  - the exit code of the command, for the run and exec operations
+
+  If a node dependency is not ready, or a node instance can't be created or started, then the nodes that are
+  processed after it are not run.
+
+"cli:output": |
+
+  The info, status and inspect operations can write a structured document instead of text, for scripts and CI
+  checks, using the --output global flag:
+
+    $/> coach --output json status
+    $/> coach --output yaml @db info
+
+  The documents list each target node, with its type, images (or named volume), and each node instance with its
+  container id, state, ports and default/filtered flags.  Inspect always writes a document, which is JSON unless
+  --output yaml is used.
+
+"cli:format": |
+
+  The info and status operations can write a line for each target node instance, using a go template
+  ( https://golang.org/pkg/text/template/ ) passed with the --format flag:
+
+    $/> coach status --format '{{.Node}} {{.Instance}} {{.Status}}'
+    $/> coach @www info --format '{{.Node}}:{{.Instance}} {{if .Running}}up{{else}}down{{end}} {{join .Ports ","}}'
+
+  Nodes without any target instances, such as build nodes, get a single line with empty instance fields.  The
+  template can use the following fields:
+
+  Node fields:
+    .Node : the node name
+    .Type : the node type (build, pull, volume, service, command)
+    .HasImage : does the node have an image (true/false)
+    .Containers : how many of the target instances have a container
+    .RunningCount : how many of the target instances are running
+    .Report : the full node report, as written by --output json, e.g. {{range .Report.Images}}{{.ID}}{{end}}
+
+  Instance fields:
+    .Instance : the instance name
+    .Container : the instance container name
+    .Default : is the instance used by default (true/false)
+    .Filtered : is the instance included by the targets (true/false)
+    .HasContainer : does the instance have a container (true/false)
+    .Running : is the instance container running (true/false)
+    .ID : the container id
+    .Image : the container image
+    .State : the container state, e.g. running, exited
+    .Status : the container status, e.g. "Up 2 hours"
+    .Created : when the container was created
+    .Ports : a list of the container ports, e.g. 0.0.0.0:8080->80/tcp
+
+  Template functions: join {list} {separator}, json {value}, lower {string}, upper {string}
+
 
   Settings are primarily managed through a set of YAML files, that can be found in the project .coach folder.  In 
   the case of some settings files, copies can also exist in the users home folder at ~/.coach, in order to get
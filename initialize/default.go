@@ -120,7 +120,8 @@ PERSONAL_APP_KEY: APP_KEY_VALUE
 #   - build: only used for building images, let's you build a base 
 #        image for other nodes
 #   - volume: non-running containers meant to hold files that are 
-#        shared with other nodes
+#        shared with other nodes, or a docker named volume if the node
+#        has a Volume: setting
 #   - service: a node with containers that get started and stopped
 #   - command: a node that uses disposable containers to run commands
 #        inside an environment
@@ -170,6 +171,14 @@ PERSONAL_APP_KEY: APP_KEY_VALUE
 #           Instances: first second third
 #           Instances: temporary
 #           Instances: scaled 3 9
+#   - Client: which client (from the .coach/clients.yml) the node should
+#        use, by client name or client type.  By default the first docker
+#        client is used, which lets one project run some nodes on a remote
+#        docker host, and others on the local socket.
+#        e.g.:
+#           Client: remote
+#   - Requires: a list of other nodes that this node depends on, which
+#        orders operations, in the same way that Links and VolumesFrom do.
 #
 # Docker remote API Configurations:
 #
@@ -184,6 +193,41 @@ PERSONAL_APP_KEY: APP_KEY_VALUE
 #   - Host : the dockerclient config element used to define the relationship
 #        between the container and it's host.
 #        https://github.com/fsouza/go-dockerclient/blob/master/container.go#L472
+#   - BuildArgs : a map of build ARG values passed to the image build,
+#        which can use tokens like any other setting.
+#   - Dockerfile : an alternate Dockerfile, relative to the Build: path.
+#   - NoCache / Pull : build without any cache, or always pull the parent
+#        image (also available as --no-cache and --pull build flags).
+#   - Target : the stage to build, from a multi-stage Dockerfile.
+#   - Labels : a map of labels to add to the built image.
+#   - Networks : a list of extra docker networks for the node instances to
+#        join.  All instances join the project network ({project}_default)
+#        with their node name (and node_instance name) as DNS aliases, so
+#        Links are no longer needed.  A Host NetworkMode disables this.
+#   - Volume : (volume nodes only) keep the node files in a docker named
+#        volume ({project}_{node}) instead of a container.  Use Volume: {}
+#        for the default driver, or set a Driver:, driver Options: and
+#        Labels:.  Other nodes use the volume by node name in their Binds:
+#        e.g.:
+#           Binds:
+#             - "data:/var/lib/mysql"
+#   - Registry : the registry to pull the node image from.  By default this
+#        is taken from the image name, or the docker hub if the image name
+#        has no registry host in it.
+#   - Auth : registry credentials (Username, Password, Email, IdentityToken)
+#        for the node.  By default credentials are taken from the docker
+#        config file (~/.docker/config.json) including credential helpers,
+#        so you should only need this to override a docker login.
+#   - Healthcheck : how to tell that a node instance is ready, so that up and
+#        start wait for it before starting the nodes that depend on it.  Any
+#        of Port: (a TCP port that accepts connections), Command: (a command
+#        exec that exits 0), Log: (a regex to find in the container logs)
+#        and Docker: true (the image HEALTHCHECK status is healthy) can be
+#        used, with a Timeout: and Interval: in seconds (default 60 and 1)
+#        e.g.:
+#           Healthcheck:
+#             Port: 3306
+#             Timeout: 120
 #
 ###
 
@@ -0,0 +1,70 @@
+package libs
+
+import (
+	"sync"
+)
+
+// A cache of actions that have already been run, such as image pulls that are shared between
+// nodes.  The cache is safe for concurrent use, as nodes may be processed in parallel.
+type ActionCache struct {
+	lock    sync.Mutex
+	actions map[string]*cachedAction
+}
+
+type cachedAction struct {
+	done   chan struct{}
+	result error
+}
+
+// Constructor for an empty action cache
+func MakeActionCache() *ActionCache {
+	return &ActionCache{actions: map[string]*cachedAction{}}
+}
+
+// Has an action been run (or is it running)
+func (cache *ActionCache) Has(key string) bool {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	_, ok := cache.actions[key]
+	return ok
+}
+
+// Wait for the result of an action that has been run (or is running)
+func (cache *ActionCache) Wait(key string) (result error, ok bool) {
+	cache.lock.Lock()
+	cached, ok := cache.actions[key]
+	cache.lock.Unlock()
+
+	if !ok {
+		return nil, false
+	}
+	<-cached.done
+	return cached.result, true
+}
+
+// Mark an action as having been run
+func (cache *ActionCache) Set(key string, result error) {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	done := make(chan struct{})
+	close(done)
+	cache.actions[key] = &cachedAction{done: done, result: result}
+}
+
+// Run an action only once for a key.  If the action has already been run, or is being
+// run by another node, then wait for it, and return its result with ran == false.
+func (cache *ActionCache) Once(key string, action func() error) (result error, ran bool) {
+	cache.lock.Lock()
+	if cached, ok := cache.actions[key]; ok {
+		cache.lock.Unlock()
+		<-cached.done
+		return cached.result, false
+	}
+	cached := &cachedAction{done: make(chan struct{})}
+	cache.actions[key] = cached
+	cache.lock.Unlock()
+
+	cached.result = action()
+	close(cached.done)
+	return cached.result, true
+}
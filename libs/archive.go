@@ -0,0 +1,198 @@
+package libs
+
+/**
+ * @file Tar archive helpers, used to copy files between the host and instance
+ * containers.
+ *
+ * Docker copies files in and out of containers as tar archives, whichever
+ * client is used.  Coach treats a copy destination as the full path of the
+ * copy, so the root entry of an archive is always renamed to the base name
+ * of the destination.
+ */
+
+import (
+	"archive/tar"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Stream a tar archive of a host file or directory into an upload function
+func uploadFromHostPath(source string, name string, upload func(io.Reader) error) error {
+	if _, err := os.Lstat(source); err != nil {
+		return err
+	}
+
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(archiveHostPath(source, name, writer))
+	}()
+
+	err := upload(reader)
+	reader.CloseWithError(err) // stop archiving if the upload stopped early
+	return err
+}
+
+// Extract a tar archive, written by a download function, into a host path
+func downloadToHostPath(destination string, download func(io.Writer) error) error {
+	reader, writer := io.Pipe()
+	extracted := make(chan error, 1)
+	go func() {
+		err := extractHostPath(reader, destination)
+		if err == nil {
+			// read any archive padding, so that the download can finish
+			io.Copy(ioutil.Discard, reader)
+		}
+		reader.CloseWithError(err)
+		extracted <- err
+	}()
+
+	err := download(writer)
+	writer.CloseWithError(err)
+
+	// a failed download also fails the extraction, which has the more useful error
+	if extractErr := <-extracted; extractErr != nil {
+		return extractErr
+	}
+	return err
+}
+
+// Write a tar archive of a host file or directory, with the root entry renamed
+func archiveHostPath(source string, name string, writer io.Writer) error {
+	archive := tar.NewWriter(writer)
+
+	err := filepath.Walk(source, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relative, err := filepath.Rel(source, file)
+		if err != nil {
+			return err
+		}
+
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(file); err != nil {
+				return err
+			}
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = path.Join(name, filepath.ToSlash(relative))
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		if err := archive.WriteHeader(header); err != nil {
+			return err
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		contents, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer contents.Close()
+		_, err = io.Copy(archive, contents)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
+// Extract a tar archive of a single file or directory, with the root entry renamed to the destination
+func extractHostPath(reader io.Reader, destination string) error {
+	archive := tar.NewReader(reader)
+	destination = filepath.Clean(destination)
+
+	extracted := false
+	links := map[string]bool{} // symlinks created by the extraction, which no other entry may be written through
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		// replace the archive root with the destination, refusing any entry that escapes it
+		name := path.Clean(strings.TrimPrefix(header.Name, "/"))
+		relative := ""
+		if index := strings.Index(name, "/"); index >= 0 {
+			relative = name[index+1:]
+		}
+		if name == ".." || strings.HasPrefix(name, "../") || relative == ".." || strings.HasPrefix(relative, "../") {
+			return errors.New("archive entry is outside of the copied path: " + header.Name)
+		}
+		target := filepath.Join(destination, filepath.FromSlash(relative))
+		mode := os.FileMode(header.Mode).Perm()
+		for parent := target; pathInside(destination, parent); parent = filepath.Dir(parent) {
+			if links[parent] {
+				return errors.New("archive entry is inside of an extracted symlink: " + header.Name)
+			}
+			if parent == destination {
+				break
+			}
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, mode|0700); err != nil {
+				return err
+			}
+		case tar.TypeReg, tar.TypeRegA:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			file, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(file, archive)
+			file.Close()
+			if err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			// links may only point inside of the copied path.  A copied root symlink has nothing inside of it
+			if filepath.IsAbs(header.Linkname) || path.IsAbs(header.Linkname) {
+				return errors.New("archive symlink has an absolute target: " + header.Name + " -> " + header.Linkname)
+			} else if target != destination && !pathInside(destination, filepath.Join(filepath.Dir(target), filepath.FromSlash(header.Linkname))) {
+				return errors.New("archive symlink points outside of the copied path: " + header.Name + " -> " + header.Linkname)
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			os.Remove(target)
+			if err := os.Symlink(header.Linkname, target); err != nil {
+				return err
+			}
+			links[target] = true
+		default:
+			// devices, fifos and hard links are not copied
+			continue
+		}
+		extracted = true
+	}
+
+	if !extracted {
+		return errors.New("nothing was copied")
+	}
+	return nil
+}
+
+// Is a path inside of a directory, or the directory itself
+func pathInside(directory string, file string) bool {
+	relative, err := filepath.Rel(directory, file)
+	return err == nil && relative != ".." && !strings.HasPrefix(relative, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,167 @@
+package libs
+
+import (
+	"archive/tar"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// A single crafted archive entry, which is a symlink if link is set
+type testArchiveEntry struct {
+	name     string
+	link     string
+	contents string
+}
+
+// Write a tar archive from a list of entries
+func makeTestArchive(t *testing.T, entries []testArchiveEntry) *bytes.Buffer {
+	buffer := &bytes.Buffer{}
+	archive := tar.NewWriter(buffer)
+	for _, entry := range entries {
+		header := &tar.Header{Name: entry.name, Mode: 0644, Typeflag: tar.TypeReg, Size: int64(len(entry.contents))}
+		if entry.link != "" {
+			header = &tar.Header{Name: entry.name, Mode: 0777, Typeflag: tar.TypeSymlink, Linkname: entry.link}
+		} else if strings.HasSuffix(entry.name, "/") {
+			header = &tar.Header{Name: entry.name, Mode: 0755, Typeflag: tar.TypeDir}
+		}
+		if err := archive.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := archive.Write([]byte(entry.contents)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buffer
+}
+
+func TestExtractHostPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []testArchiveEntry
+		fail    string            // part of the expected error, if the extraction should fail
+		files   map[string]string // files that should be readable from the destination afterwards
+	}{
+		{
+			name: "directory with an internal symlink",
+			entries: []testArchiveEntry{
+				{name: "root/"},
+				{name: "root/file", contents: "contents"},
+				{name: "root/sub/"},
+				{name: "root/sub/link", link: "../file"},
+			},
+			files: map[string]string{"file": "contents", "sub/link": "contents"},
+		},
+		{
+			name:    "single root symlink",
+			entries: []testArchiveEntry{{name: "root", link: "file"}},
+		},
+		{
+			name:    "escaping entry",
+			entries: []testArchiveEntry{{name: "root/"}, {name: "root/../../outside/file", contents: "escaped"}},
+			fail:    "outside of the copied path",
+		},
+		{
+			name:    "absolute symlink",
+			entries: []testArchiveEntry{{name: "root/"}, {name: "root/link", link: "/etc/passwd"}},
+			fail:    "absolute target",
+		},
+		{
+			name:    "escaping symlink",
+			entries: []testArchiveEntry{{name: "root/"}, {name: "root/sub/link", link: "../../outside"}},
+			fail:    "points outside of the copied path",
+		},
+		{
+			name: "file written through an extracted symlink",
+			entries: []testArchiveEntry{
+				{name: "root/"},
+				{name: "root/sub/"},
+				{name: "root/link", link: "sub"},
+				{name: "root/link/file", contents: "through"},
+			},
+			fail: "inside of an extracted symlink",
+		},
+		{
+			name: "file written through a root symlink",
+			entries: []testArchiveEntry{
+				{name: "root", link: "../outside"},
+				{name: "root/file", contents: "escaped"},
+			},
+			fail: "inside of an extracted symlink",
+		},
+		{
+			name:    "single file",
+			entries: []testArchiveEntry{{name: "root", contents: "contents"}},
+		},
+		{
+			name:    "empty archive",
+			entries: []testArchiveEntry{},
+			fail:    "nothing was copied",
+		},
+	}
+
+	for _, test := range tests {
+		parent, err := ioutil.TempDir("", "coach-archive")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(parent)
+
+		outside := filepath.Join(parent, "outside")
+		if err := os.Mkdir(outside, 0755); err != nil {
+			t.Fatal(err)
+		}
+		destination := filepath.Join(parent, "destination")
+
+		err = extractHostPath(makeTestArchive(t, test.entries), destination)
+		if test.fail == "" && err != nil {
+			t.Errorf("%s: extraction failed => %s", test.name, err)
+		} else if test.fail != "" && (err == nil || !strings.Contains(err.Error(), test.fail)) {
+			t.Errorf("%s: expected an error containing [%s], got %v", test.name, test.fail, err)
+		}
+
+		for file, contents := range test.files {
+			if read, err := ioutil.ReadFile(filepath.Join(destination, filepath.FromSlash(file))); err != nil || string(read) != contents {
+				t.Errorf("%s: expected %s to contain [%s], got [%s] %v", test.name, file, contents, read, err)
+			}
+		}
+		if written, _ := ioutil.ReadDir(outside); len(written) > 0 {
+			t.Errorf("%s: extraction wrote outside of the destination: %s", test.name, written[0].Name())
+		}
+	}
+}
+
+func TestArchiveHostPathRoundTrip(t *testing.T) {
+	parent, err := ioutil.TempDir("", "coach-archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(parent)
+
+	source := filepath.Join(parent, "source")
+	if err := os.MkdirAll(filepath.Join(source, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(source, "sub", "file"), []byte("contents"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// the archive root is renamed, and then replaced by the destination when extracting
+	buffer := &bytes.Buffer{}
+	if err := archiveHostPath(source, "renamed", buffer); err != nil {
+		t.Fatal("Archive failed:", err)
+	}
+	destination := filepath.Join(parent, "destination")
+	if err := extractHostPath(buffer, destination); err != nil {
+		t.Fatal("Extract failed:", err)
+	}
+	if read, err := ioutil.ReadFile(filepath.Join(destination, "sub", "file")); err != nil || string(read) != "contents" {
+		t.Errorf("Expected the copied file to contain [contents], got [%s] %v", read, err)
+	}
+}
@@ -1,6 +1,8 @@
 package libs
 
 import (
+	"io"
+
 	"github.com/james-nesbitt/coach/conf"
 	"github.com/james-nesbitt/coach/log"
 )
@@ -27,16 +29,38 @@ type Client interface {
  * client actions for a Node, without further configuration
  * The NodeClient is also used to generate InstanceClients
  * when needed.
+ *
+ * Actions return nil on success, or a *ClientError (see errors.go)
+ * with a kind that callers can check.
  */
 type NodeClient interface {
 	Can(action string) bool
 	HasImage() bool // Has this Node got an built or pulled image?
 
 	NodeInfo(logger log.Log)
+	Report() NodeReport // a machine readable report of the node image or volume
+
+	Build(logger log.Log, force bool, options BuildOptions) error
+	Destroy(logger log.Log, force bool) error
+	Pull(logger log.Log, force bool) error
+	Push(logger log.Log, repository string, tag string) error
+
+	RemoveNetworks(logger log.Log) error // remove any project networks that are no longer in use
 
-	Build(logger log.Log, force bool) bool
-	Destroy(logger log.Log, force bool) bool
-	Pull(logger log.Log, force bool) bool
+	Events(logger log.Log, options EventsOptions, events chan<- ProjectEvent) error // stream the events for the project resources of the options nodes
+	EventsStream() interface{}                                                      // the backend that streams events, which is shared by nodes that can use one stream
+
+	HasVolume() bool // Has this (named volume) Node got a volume?
+	CreateVolume(logger log.Log) error
+	RemoveVolume(logger log.Log, force bool) error
+}
+
+/**
+ * Run time build options, which are added to any node build settings
+ */
+type BuildOptions struct {
+	NoCache bool // build without using any cached image layers
+	Pull    bool // always try to pull a newer version of the parent image
 }
 
 /**
@@ -52,21 +76,76 @@ type InstancesClient interface {
 /*
  * IntancsClient gives a configured client ready to handle
  * client actions for an Instance, without further configuration
+ *
+ * Like NodeClient actions, these return a *ClientError on failure.
  */
 type InstanceClient interface {
 	Can(action string) bool
 	HasContainer() bool // Does this instance have a matching container
 	IsRunning() bool    // Is this instance container running
+	IsReady() bool      // Does this instance pass its health checks
+
+	WaitReady(logger log.Log) error // Wait for this instance to pass its health checks
+
+	Report() InstanceReport                          // a machine readable report of the instance container
+	Inspect(logger log.Log) (InstanceInspect, error) // the resolved container configuration, and the container inspect document
 
-	Attach(logger log.Log) bool
-	Create(logger log.Log, overrideCmd []string, force bool) bool
-	Remove(logger log.Log, force bool) bool
-	Start(logger log.Log, force bool) bool
-	Stop(logger log.Log, force bool, timeout uint) bool
-	Pause(logger log.Log) bool
-	Unpause(logger log.Log) bool
+	Attach(logger log.Log, options AttachOptions) error
+	Create(logger log.Log, overrideCmd []string, force bool) error
+	Remove(logger log.Log, force bool) error
+	Start(logger log.Log, force bool) error
+	Stop(logger log.Log, force bool, timeout uint) error
+	Pause(logger log.Log) error
+	Unpause(logger log.Log) error
 
-	Commit(logger log.Log, tag string, message string) bool
+	Commit(logger log.Log, tag string, message string) error
 
-	Run(logger log.Log, persistant bool, overrideCmd []string) bool
+	Logs(logger log.Log, output io.Writer, options LogsOptions) error
+	Exec(logger log.Log, cmd []string, options ExecOptions) (exitCode int, err error) // run a command in the running container
+
+	Stats(logger log.Log) (InstanceStats, error) // sample the resource usage of the running container
+
+	Upload(logger log.Log, source string, destination string) error   // copy a host file or directory into the container
+	Download(logger log.Log, source string, destination string) error // copy a container file or directory to the host
+
+	Run(logger log.Log, overrideCmd []string, options RunOptions) (exitCode int, err error) // run a command in a new container, and wait for it to exit
+}
+
+/**
+ * Run time log options, used to read instance container logs
+ */
+type LogsOptions struct {
+	Follow     bool   // keep streaming new log output
+	Tail       string // how many lines to show from the end of the logs ("all" or a number)
+	Since      string // only show logs since a timestamp, or a relative duration like "10m"
+	Timestamps bool   // show a timestamp on each line
+}
+
+/**
+ * Run time exec options, used to run commands inside running instance containers
+ */
+type ExecOptions struct {
+	User       string   // user to run the command as
+	WorkingDir string   // path inside the container to run the command in
+	Env        []string // extra KEY=VALUE environment variables for the command
+	Tty        bool     // allocate a TTY for the command
+	Stdin      bool     // attach stdin to the command
+}
+
+/**
+ * Run time run options, used to run commands in disposable instance containers
+ */
+type RunOptions struct {
+	Persistant bool // keep the container after the command exits
+	Tty        bool // allow a TTY, if the node container uses one (false when stdin is not a terminal)
+	Stdin      bool // attach stdin to the container, so that input can be piped in
+}
+
+/**
+ * Run time attach options, used to attach the terminal to instance containers
+ */
+type AttachOptions struct {
+	DetachKeys string // key sequence to detach from the container, e.g. "ctrl-p,ctrl-q"
+	Stdin      bool   // attach stdin to the container
+	Logs       bool   // replay the container logs before attaching
 }
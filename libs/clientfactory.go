@@ -68,13 +68,39 @@ func (clientFactories *ClientFactories) from_Default(logger log.Log, project *co
 type ClientFactories struct {
 	log                    log.Log
 	orderedClientFactories []ClientFactory
+	clientFactoryNames     []string // names for the factories, in the same order
 }
 
-func (clientFactories *ClientFactories) AddClientFactory(ID string, client ClientFactory) {
+// Add a named factory to the end of the factory list
+func (clientFactories *ClientFactories) AddClientFactory(name string, client ClientFactory) {
 	clientFactories.orderedClientFactories = append(clientFactories.orderedClientFactories, client)
+	clientFactories.clientFactoryNames = append(clientFactories.clientFactoryNames, name)
+}
+
+// Retrieve a factory using the name that it was added with
+func (clientFactories *ClientFactories) ClientFactory(name string) (ClientFactory, bool) {
+	for index, factoryName := range clientFactories.clientFactoryNames {
+		if factoryName == name {
+			return clientFactories.orderedClientFactories[index], true
+		}
+	}
+	return nil, false
+}
+
+// return a string slice of factory names
+func (clientFactories *ClientFactories) ClientFactoryNames() []string {
+	return clientFactories.clientFactoryNames
 }
 
 func (clientFactories *ClientFactories) MatchClientFactory(requirements FactoryMatchRequirements) (ClientFactory, bool) {
+	// an exact name match is preferred over any factory that matches by type/class/id
+	if requirements.Name != "" {
+		if clientFactory, ok := clientFactories.ClientFactory(requirements.Name); ok {
+			clientFactories.log.Debug(log.VERBOSITY_DEBUG, "Matched client factory by name: "+requirements.Name, nil)
+			return clientFactory, true
+		}
+	}
+
 	for _, clientFactory := range clientFactories.orderedClientFactories {
 		if clientFactory.Match(requirements) {
 			clientFactories.log.Debug(log.VERBOSITY_DEBUG, "Matched client factory: "+clientFactory.Id(), nil)
@@ -93,6 +119,7 @@ func (clientFactories *ClientFactories) HasFactories() bool {
 }
 
 type FactoryMatchRequirements struct {
+	Name  string // The name that the factory was given, usually the clients.yml key
 	Type  string // Type of containerization system such as docker / rkt
 	Class string // Specific Client class
 	ID    string // A specific Client ID (which would have been included in requirements)
@@ -6,6 +6,7 @@ package libs
 
 import (
 	"io/ioutil"
+	"sort"
 	"strings"
 
 	"encoding/json"
@@ -59,7 +60,15 @@ func (clientFactories *ClientFactories) from_ClientFactoriesYamlBytes(logger log
 	}
 	logger.Debug(log.VERBOSITY_DEBUG_STAAAP, "YAML source:", yaml_clients)
 
-	for name, client_struct := range yaml_clients {
+	// sort the client names, so that factory order (and so default matching) is predictable
+	names := []string{}
+	for name := range yaml_clients {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		client_struct := yaml_clients[name]
 		clientType := ""
 		client_json, _ := json.Marshal(client_struct)
 		logger.Debug(log.VERBOSITY_DEBUG_STAAAP, "Single client JSON:", string(client_json))
@@ -92,7 +101,49 @@ func (clientFactories *ClientFactories) from_ClientFactoriesYamlBytes(logger log
 
 			// Add this factory to the factory list
 			logger.Debug(log.VERBOSITY_DEBUG_LOTS, "Client Factory Created [Client_DockerFSouzaFactory]", factory)
-			clientFactories.AddClientFactory(clientType, ClientFactory(&factory))
+			clientFactories.AddClientFactory(name, ClientFactory(&factory))
+
+		case "dockercli":
+
+			clientFactorySettings := &DockerCli_ClientFactorySettings{}
+			err := json.Unmarshal(client_json, clientFactorySettings)
+
+			if err != nil {
+				logger.Warning("Factory definition failed to configure client factory :" + err.Error())
+				logger.Debug(log.VERBOSITY_DEBUG, "Factory configuration json: ", string(client_json), clientFactorySettings)
+				continue
+			}
+
+			factory := DockerCli_ClientFactory{}
+			if !factory.Init(logger.MakeChild(clientType), project, ClientFactorySettings(clientFactorySettings)) {
+				logger.Error("Failed to initialize Docker CLI factory from client factory configuration")
+				continue
+			}
+
+			// Add this factory to the factory list
+			logger.Debug(log.VERBOSITY_DEBUG_LOTS, "Client Factory Created [Client_DockerCliFactory]", factory)
+			clientFactories.AddClientFactory(name, ClientFactory(&factory))
+
+		case "fake":
+
+			clientFactorySettings := &Fake_ClientFactorySettings{}
+			err := json.Unmarshal(client_json, clientFactorySettings)
+
+			if err != nil {
+				logger.Warning("Factory definition failed to configure client factory :" + err.Error())
+				logger.Debug(log.VERBOSITY_DEBUG, "Factory configuration json: ", string(client_json), clientFactorySettings)
+				continue
+			}
+
+			factory := Fake_ClientFactory{}
+			if !factory.Init(logger.MakeChild(clientType), project, ClientFactorySettings(clientFactorySettings)) {
+				logger.Error("Failed to initialize Fake factory from client factory configuration")
+				continue
+			}
+
+			// Add this factory to the factory list
+			logger.Debug(log.VERBOSITY_DEBUG_LOTS, "Client Factory Created [Client_FakeFactory]", factory)
+			clientFactories.AddClientFactory(name, ClientFactory(&factory))
 
 		case "":
 			logger.Warning("Client registration failure, client has a bad value for 'Type'")
@@ -0,0 +1,103 @@
+package libs
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/james-nesbitt/coach/log"
+)
+
+func TestNodeClientSelection(t *testing.T) {
+	logger := log.MakeCliLog("test", ioutil.Discard, log.VERBOSITY_MESSAGE)
+	project := makeTestProject(t, logger, map[string]string{
+		"conf.yml": "Project: clientproject\n",
+		"clients.yml": `
+primary:
+  Type: fake
+  Images:
+    - "library/nginx:latest"
+secondary:
+  Type: fake
+  Images:
+    - "library/nginx:latest"
+`,
+		"nodes.yml": `
+byname:
+  Type: service
+  Client: secondary
+  Docker:
+    Config:
+      Image: library/nginx
+bytype:
+  Type: service
+  Client: fake
+  Docker:
+    Config:
+      Image: library/nginx
+`,
+	})
+
+	factories := MakeClientFactories(logger, project)
+	if names := factories.ClientFactoryNames(); len(names) != 2 || names[0] != "primary" || names[1] != "secondary" {
+		t.Fatalf("Unexpected client factory names: %q", names)
+	}
+	nodes := MakeNodes(logger, project, factories)
+	nodes.Prepare(logger)
+
+	tests := []struct {
+		node    string
+		factory string
+	}{
+		// a factory name is preferred over a type match
+		{node: "byname", factory: "secondary"},
+		{node: "bytype", factory: "primary"},
+	}
+
+	for _, test := range tests {
+		node, ok := nodes.Node(test.node)
+		if !ok {
+			t.Errorf("%s: node was not created", test.node)
+			continue
+		}
+		instance, ok := node.Instances().Instance(INSTANCE_SINGLE_ID)
+		if !ok {
+			t.Errorf("%s: node has no single instance", test.node)
+			continue
+		}
+		if err := instance.Client().Create(logger, []string{}, false); err != nil {
+			t.Errorf("%s: create failed: %s", test.node, err)
+			continue
+		}
+
+		factory, _ := factories.ClientFactory(test.factory)
+		if _, ok := factory.(*Fake_ClientFactory).Backend().Container("clientproject_" + test.node); !ok {
+			t.Errorf("%s: container was not created using the %s client", test.node, test.factory)
+		}
+	}
+}
+
+func TestFakeClientFactoryMatch(t *testing.T) {
+	logger := log.MakeCliLog("test", ioutil.Discard, log.VERBOSITY_MESSAGE)
+	factory := &Fake_ClientFactory{}
+	if !factory.Init(logger, makeTestProject(t, logger, map[string]string{"conf.yml": "Project: matchproject\n"}), ClientFactorySettings(&Fake_ClientFactorySettings{})) {
+		t.Fatal("The fake client factory failed to initialize")
+	}
+
+	tests := []struct {
+		requirements FactoryMatchRequirements
+		match        bool
+	}{
+		{requirements: FactoryMatchRequirements{Type: "fake"}, match: true},
+		{requirements: FactoryMatchRequirements{Class: "Fake_ClientFactory"}, match: true},
+		{requirements: FactoryMatchRequirements{ID: factory.Id()}, match: true},
+		// real nodes are never routed to the fake
+		{requirements: FactoryMatchRequirements{Type: "docker"}, match: false},
+		{requirements: FactoryMatchRequirements{}, match: false},
+	}
+
+	for _, test := range tests {
+		if match := factory.Match(test.requirements); match != test.match {
+			t.Errorf("%+v: match %v, expected %v", test.requirements, match, test.match)
+		}
+	}
+}
@@ -0,0 +1,283 @@
+package libs
+
+/**
+ * @file Registry authentication for docker image pulls and pushes
+ *
+ * Credentials are resolved in the same way that the docker CLI does it:
+ * the registry is derived from the image name, and then matched against
+ * the "auths" and "credHelpers" entries of the docker config file
+ * (~/.docker/config.json, or $DOCKER_CONFIG/config.json), falling back
+ * to the "credsStore" helper, and finally to the legacy ~/.dockercfg.
+ *
+ * A node can also override the registry and auth in its Docker settings,
+ * which takes precedence over anything found in the docker config.
+ */
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+
+	docker "github.com/fsouza/go-dockerclient"
+
+	"github.com/james-nesbitt/coach/conf"
+	"github.com/james-nesbitt/coach/log"
+)
+
+// the registry used when an image name does not include a registry host
+const DOCKER_HUB_REGISTRY = "https://index.docker.io/v1/"
+
+// Per node registry authentication settings
+type FSouza_AuthSettings struct {
+	Username      string `json:"Username,omitempty" yaml:"Username,omitempty"`
+	Password      string `json:"Password,omitempty" yaml:"Password,omitempty"`
+	Email         string `json:"Email,omitempty" yaml:"Email,omitempty"`
+	IdentityToken string `json:"IdentityToken,omitempty" yaml:"IdentityToken,omitempty"`
+}
+
+// Are there any credentials in these settings
+func (settings FSouza_AuthSettings) IsEmpty() bool {
+	return settings.Username == "" && settings.Password == "" && settings.IdentityToken == ""
+}
+
+// Convert the settings to an FSouza auth configuration
+func (settings FSouza_AuthSettings) AuthConfiguration(registry string) docker.AuthConfiguration {
+	return docker.AuthConfiguration{
+		Username:      settings.Username,
+		Password:      settings.Password,
+		Email:         settings.Email,
+		IdentityToken: settings.IdentityToken,
+		ServerAddress: registry,
+	}
+}
+
+/**
+ * Image and registry names
+ */
+
+// Split an image name into the image and tag, ignoring any port on a registry host
+func dockerSplitImageTag(imageName string) (image, tag string) {
+	image = imageName
+	tag = ""
+
+	if index := strings.LastIndex(imageName, ":"); index > strings.LastIndex(imageName, "/") {
+		image = imageName[:index]
+		tag = imageName[index+1:]
+	}
+	return
+}
+
+// Determine which registry an image should be pulled from, using the image name
+func DockerRegistryFromImage(image string) string {
+	if index := strings.Index(image, "/"); index > 0 {
+		host := image[:index]
+		if strings.ContainsAny(host, ".:") || host == "localhost" {
+			return host
+		}
+	}
+	return DOCKER_HUB_REGISTRY
+}
+
+// Reduce a registry address to a host, so that config keys can be compared
+func dockerRegistryHost(registry string) string {
+	host := strings.TrimPrefix(strings.TrimPrefix(registry, "https://"), "http://")
+	if index := strings.Index(host, "/"); index >= 0 {
+		host = host[:index]
+	}
+	host = strings.ToLower(host)
+
+	switch host {
+	case "docker.io", "index.docker.io", "registry-1.docker.io", "registry.hub.docker.com":
+		return "index.docker.io"
+	}
+	return host
+}
+
+/**
+ * Docker config file
+ */
+
+// A single auth entry in the docker config file
+type DockerConfig_Auth struct {
+	Auth          string `json:"auth,omitempty"`
+	Username      string `json:"username,omitempty"`
+	Password      string `json:"password,omitempty"`
+	Email         string `json:"email,omitempty"`
+	IdentityToken string `json:"identitytoken,omitempty"`
+}
+
+// The parts of the docker config file that are used for registry auth
+type DockerConfig struct {
+	Auths       map[string]DockerConfig_Auth `json:"auths,omitempty"`
+	CredsStore  string                       `json:"credsStore,omitempty"`
+	CredHelpers map[string]string            `json:"credHelpers,omitempty"`
+}
+
+// Load the docker config for a project, looking in the usual docker places
+func LoadDockerConfig(logger log.Log, project *conf.Project) (*DockerConfig, bool) {
+	homePath := os.Getenv("HOME")
+	if project != nil {
+		if userHome, ok := project.Paths.Path("user-home"); ok {
+			homePath = userHome
+		}
+	}
+
+	configDir := os.Getenv("DOCKER_CONFIG")
+	if configDir == "" {
+		configDir = path.Join(homePath, ".docker")
+	}
+
+	if config, err := ReadDockerConfigFile(path.Join(configDir, "config.json")); err == nil {
+		return config, true
+	} else if !os.IsNotExist(err) {
+		logger.Warning("Could not read docker config file [" + path.Join(configDir, "config.json") + "] : " + err.Error())
+	}
+
+	if config, err := ReadDockerCfgFile(path.Join(homePath, ".dockercfg")); err == nil {
+		return config, true
+	} else if !os.IsNotExist(err) {
+		logger.Warning("Could not read legacy docker config file [" + path.Join(homePath, ".dockercfg") + "] : " + err.Error())
+	}
+
+	return &DockerConfig{}, false
+}
+
+// Read a current (config.json) docker config file
+func ReadDockerConfigFile(filePath string) (*DockerConfig, error) {
+	source, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &DockerConfig{}
+	if err := json.Unmarshal(source, config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// Read a legacy (.dockercfg) docker config file, which is just an auths map
+func ReadDockerCfgFile(filePath string) (*DockerConfig, error) {
+	source, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &DockerConfig{}
+	if err := json.Unmarshal(source, &config.Auths); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// Find the auth configuration for a registry, returns false if no credentials were found
+func (config *DockerConfig) AuthConfiguration(logger log.Log, registry string) (docker.AuthConfiguration, bool) {
+	host := dockerRegistryHost(registry)
+
+	if helper, ok := config.CredHelpers[host]; ok && helper != "" {
+		return config.helperAuth(logger, helper, registry)
+	}
+	for key, helper := range config.CredHelpers {
+		if helper != "" && dockerRegistryHost(key) == host {
+			return config.helperAuth(logger, helper, key)
+		}
+	}
+
+	for key, entry := range config.Auths {
+		if dockerRegistryHost(key) != host {
+			continue
+		}
+		auth, err := entry.AuthConfiguration(key)
+		if err != nil {
+			logger.Warning("Could not decode docker config credentials for registry [" + key + "] : " + err.Error())
+			continue
+		}
+		if auth.Username != "" || auth.IdentityToken != "" {
+			return auth, true
+		}
+	}
+
+	if config.CredsStore != "" {
+		return config.helperAuth(logger, config.CredsStore, registry)
+	}
+
+	return docker.AuthConfiguration{}, false
+}
+
+// Convert a config file entry to an FSouza auth configuration
+func (entry DockerConfig_Auth) AuthConfiguration(registry string) (docker.AuthConfiguration, error) {
+	auth := docker.AuthConfiguration{
+		Username:      entry.Username,
+		Password:      entry.Password,
+		Email:         entry.Email,
+		IdentityToken: entry.IdentityToken,
+		ServerAddress: registry,
+	}
+
+	if entry.Auth != "" {
+		decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+		if err != nil {
+			return auth, err
+		}
+		split := strings.SplitN(string(decoded), ":", 2)
+		if len(split) != 2 {
+			return auth, errors.New("auth entry is not in the username:password format")
+		}
+		auth.Username = split[0]
+		auth.Password = split[1]
+	}
+
+	return auth, nil
+}
+
+/**
+ * Credential helpers
+ */
+
+// the response from a docker-credential-* helper "get" call
+type dockerCredentialHelperResponse struct {
+	ServerURL string
+	Username  string
+	Secret    string
+}
+
+// Ask a docker credential helper for credentials for a registry
+func (config *DockerConfig) helperAuth(logger log.Log, helper string, registry string) (docker.AuthConfiguration, bool) {
+	binary := "docker-credential-" + helper
+
+	cmd := exec.Command(binary, "get")
+	cmd.Stdin = strings.NewReader(registry)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		message := strings.TrimSpace(stdout.String() + stderr.String())
+		if message == "" {
+			message = err.Error()
+		}
+		logger.Debug(log.VERBOSITY_DEBUG, "Docker credential helper ["+binary+"] returned no credentials for registry ["+registry+"] : "+message)
+		return docker.AuthConfiguration{}, false
+	}
+
+	response := dockerCredentialHelperResponse{}
+	if err := json.Unmarshal(stdout.Bytes(), &response); err != nil {
+		logger.Warning("Docker credential helper [" + binary + "] returned an unreadable response : " + err.Error())
+		return docker.AuthConfiguration{}, false
+	}
+
+	auth := docker.AuthConfiguration{ServerAddress: registry}
+	if response.Username == "<token>" {
+		auth.IdentityToken = response.Secret
+	} else {
+		auth.Username = response.Username
+		auth.Password = response.Secret
+	}
+	return auth, true
+}
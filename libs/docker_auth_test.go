@@ -0,0 +1,119 @@
+package libs
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"path"
+	"strings"
+	"testing"
+
+	docker "github.com/fsouza/go-dockerclient"
+
+	"github.com/james-nesbitt/coach/log"
+)
+
+// A docker config with an auth entry for docker hub and a private registry, a credential helper, and a credential store
+const testDockerConfig = `{
+	"auths": {
+		"https://index.docker.io/v1/": {"auth": "%HUB_AUTH%"},
+		"registry.example.com:5000": {"username": "reguser", "password": "regpass"}
+	},
+	"credHelpers": {
+		"helped.example.com": "helper"
+	},
+	"credsStore": "store"
+}`
+
+// Credential helpers that answer for any registry, one with a password, and one with an identity token
+const (
+	testCredentialHelperScript = `#!/bin/sh
+read registry
+echo "{\"ServerURL\": \"$registry\", \"Username\": \"helperuser\", \"Secret\": \"helperpass\"}"
+`
+	testCredentialStoreScript = `#!/bin/sh
+read registry
+echo "{\"ServerURL\": \"$registry\", \"Username\": \"<token>\", \"Secret\": \"storetoken\"}"
+`
+)
+
+func TestDockerSplitImageTag(t *testing.T) {
+	tests := []struct {
+		name, image, tag, registry string
+	}{
+		{name: "nginx", image: "nginx", tag: "", registry: DOCKER_HUB_REGISTRY},
+		{name: "library/nginx:1.19", image: "library/nginx", tag: "1.19", registry: DOCKER_HUB_REGISTRY},
+		{name: "localhost/app:dev", image: "localhost/app", tag: "dev", registry: "localhost"},
+		{name: "registry.example.com:5000/team/app", image: "registry.example.com:5000/team/app", tag: "", registry: "registry.example.com:5000"},
+		{name: "registry.example.com:5000/team/app:2.0", image: "registry.example.com:5000/team/app", tag: "2.0", registry: "registry.example.com:5000"},
+	}
+
+	for _, test := range tests {
+		if image, tag := dockerSplitImageTag(test.name); image != test.image || tag != test.tag {
+			t.Errorf("%s: split into [%s] [%s], expected [%s] [%s]", test.name, image, tag, test.image, test.tag)
+		}
+		if registry := DockerRegistryFromImage(test.name); registry != test.registry {
+			t.Errorf("%s: registry [%s], expected [%s]", test.name, registry, test.registry)
+		}
+	}
+}
+
+func TestRegistryAuth(t *testing.T) {
+	logger := log.MakeCliLog("test", ioutil.Discard, log.VERBOSITY_MESSAGE)
+
+	configPath := installTestBinary(t, "docker-credential-helper", testCredentialHelperScript)
+	installTestBinary(t, "docker-credential-store", testCredentialStoreScript)
+	t.Setenv("DOCKER_CONFIG", configPath)
+
+	hubAuth := base64.StdEncoding.EncodeToString([]byte("hubuser:hubpass"))
+	config := strings.Replace(testDockerConfig, "%HUB_AUTH%", hubAuth, 1)
+	if err := ioutil.WriteFile(path.Join(configPath, "config.json"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		image    string
+		settings FSouza_ClientSettings
+		auth     docker.AuthConfiguration
+	}{
+		{
+			name:  "docker hub default registry, from auths",
+			image: "library/nginx:1.19",
+			auth:  docker.AuthConfiguration{Username: "hubuser", Password: "hubpass", ServerAddress: DOCKER_HUB_REGISTRY},
+		},
+		{
+			name:  "registry with a port, from auths",
+			image: "registry.example.com:5000/team/app:2.0",
+			auth:  docker.AuthConfiguration{Username: "reguser", Password: "regpass", ServerAddress: "registry.example.com:5000"},
+		},
+		{
+			name:  "credHelpers",
+			image: "helped.example.com/app",
+			auth:  docker.AuthConfiguration{Username: "helperuser", Password: "helperpass", ServerAddress: "helped.example.com"},
+		},
+		{
+			name:  "credsStore, with an identity token",
+			image: "other.example.com/app:latest",
+			auth:  docker.AuthConfiguration{IdentityToken: "storetoken", ServerAddress: "other.example.com"},
+		},
+		{
+			name:     "node registry override",
+			image:    "library/nginx",
+			settings: FSouza_ClientSettings{Registry: "registry.example.com:5000"},
+			auth:     docker.AuthConfiguration{Username: "reguser", Password: "regpass", ServerAddress: "registry.example.com:5000"},
+		},
+		{
+			name:     "node auth override",
+			image:    "registry.example.com:5000/team/app",
+			settings: FSouza_ClientSettings{Auth: FSouza_AuthSettings{Username: "nodeuser", Password: "nodepass"}},
+			auth:     docker.AuthConfiguration{Username: "nodeuser", Password: "nodepass", ServerAddress: "registry.example.com:5000"},
+		},
+	}
+
+	for _, test := range tests {
+		client := &FSouza_NodeClient{FSouza_Client: &FSouza_Client{}, settings: test.settings}
+		if auth := client.RegistryAuth(logger, test.image); auth != test.auth {
+			t.Errorf("%s: auth %+v, expected %+v", test.name, auth, test.auth)
+		}
+	}
+}
@@ -0,0 +1,1832 @@
+package libs
+
+/**
+ * @file Coach Client and ClientFactory, based on running the docker
+ * command line binary, instead of using the remote API directly.
+ *
+ * Running the docker binary means that any docker contexts, credential
+ * helpers and docker config that a user has set up are honoured, and that
+ * there are no API version mismatches between coach and the docker daemon.
+ *
+ * The client reads the same node Docker: settings as the FSouza client, and
+ * translates them into docker cli flags.
+ *
+ * COMPONENTS:
+ *
+ * DockerCli_Wrapper : a wrapper for the docker binary, which caches image and
+ *   container lists, as each call to the binary is comparatively slow.
+ *
+ * ClientFactory & ClientFactorySettings : The Coach Client factory, and settings
+ * 		that create Client objects from
+ */
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	docker "github.com/fsouza/go-dockerclient"
+
+	"github.com/james-nesbitt/coach/conf"
+	"github.com/james-nesbitt/coach/log"
+)
+
+const (
+	DOCKERCLI_DEFAULT_BINARY = "docker"
+)
+
+/**
+ * Coach: ClientFactory
+ */
+type DockerCli_ClientFactorySettings struct {
+	Binary   string `json:"Binary,omitempty" yaml:"Binary,omitempty"`     // path to the docker binary (default: docker from the PATH)
+	Host     string `json:"Host,omitempty" yaml:"Host,omitempty"`         // docker --host
+	Context  string `json:"Context,omitempty" yaml:"Context,omitempty"`   // docker --context
+	Config   string `json:"Config,omitempty" yaml:"Config,omitempty"`     // docker --config
+	CertPath string `json:"CertPath,omitempty" yaml:"CertPath,omitempty"` // path containing ca.pem, cert.pem and key.pem for TLS
+}
+
+func (settings *DockerCli_ClientFactorySettings) Settings() interface{} {
+	return settings
+}
+
+type DockerCli_ClientFactory struct {
+	settings DockerCli_ClientFactorySettings
+	log      log.Log
+	conf     *conf.Project
+	client   *DockerCli_Wrapper
+}
+
+// Provide a unique string identifier for this client-factory/type
+func (clientFactory *DockerCli_ClientFactory) Id() string {
+	return "Docker:Cli"
+}
+
+// Return a boolean for if this client factory matches the requirements
+// @note nodes have to ask for the CLI client, as it doesn't match docker requirements
+func (clientFactory *DockerCli_ClientFactory) Match(requirements FactoryMatchRequirements) bool {
+	clientFactory.log.Debug(log.VERBOSITY_DEBUG_STAAAP, "Match test for Docker CLI client factory:", requirements.Type)
+	return requirements.Type == "dockercli" || requirements.ID == clientFactory.Id() || requirements.Class == "DockerCli_ClientFactory"
+}
+
+func (clientFactory *DockerCli_ClientFactory) Init(logger log.Log, project *conf.Project, settings ClientFactorySettings) bool {
+	clientFactory.log = logger
+	clientFactory.conf = project
+
+	// make sure that the settings that were given, where the proper "DockerCli_ClientFactory" type
+	typedSettings := settings.Settings()
+	switch asserted := typedSettings.(type) {
+	case *DockerCli_ClientFactorySettings:
+		clientFactory.settings = *asserted
+	default:
+		logger.Error("Invalid settings type passed to Docker CLI Factory")
+		logger.Debug(log.VERBOSITY_DEBUG, "Settings passed:", asserted)
+		return false
+	}
+
+	// if we haven't made a docker binary wrapper, then do it now
+	if clientFactory.client == nil {
+		wrapper := &DockerCli_Wrapper{}
+		if !wrapper.Init(logger.MakeChild("dockercli"), clientFactory.settings) {
+			logger.Error("Failed to create a docker CLI wrapper from client factory configuration")
+			return false
+		}
+		clientFactory.client = wrapper
+	}
+	return true
+}
+
+// Get an actual client object from the Factory, from settings
+func (clientFactory *DockerCli_ClientFactory) MakeClient(logger log.Log, settings ClientSettings) (Client, bool) {
+	client := &DockerCli_Client{backend: clientFactory.client}
+	return Client(client), client.Init(logger, clientFactory.conf, settings)
+}
+
+/**
+ * Coach: Client
+ */
+
+// Docker CLI Coach Client object, which uses the node Docker: settings
+type DockerCli_Client struct {
+	settings FSouza_ClientSettings
+	log      log.Log
+	conf     *conf.Project
+	backend  *DockerCli_Wrapper
+
+	id     string
+	nodeId string
+}
+
+type DockerCli_NodeClient struct {
+	*DockerCli_Client
+	settings FSouza_ClientSettings
+	node     Node
+}
+
+type DockerCli_InstancesClient struct {
+	*DockerCli_Client
+	settings  FSouza_ClientSettings
+	instances Instances
+}
+
+type DockerCli_InstanceClient struct {
+	*DockerCli_Client
+	settings FSouza_ClientSettings
+	instance Instance
+}
+
+func (client *DockerCli_Client) Init(logger log.Log, project *conf.Project, settings ClientSettings) bool {
+	client.log = logger
+	client.conf = project
+
+	// the docker cli client accepts the same node settings as the FSouza client
+	settingsTyped := settings.Settings()
+	switch asserted := settingsTyped.(type) {
+	case *FSouza_ClientSettings:
+		client.settings = *asserted
+		client.settings.Init(logger, project)
+		return true
+	default:
+		logger.Error("Invalid settings type passed to Docker CLI Client")
+		return false
+	}
+}
+func (client *DockerCli_Client) Prepare(logger log.Log, nodes *Nodes, node Node) bool {
+	client.id = node.MachineName()
+	client.nodeId = node.Id()
+
+	// the settings object has it's own Prepare
+	client.settings.Prepare(logger, nodes)
+
+	return true
+}
+
+func (client *DockerCli_Client) NodeClient(node Node) NodeClient {
+	return NodeClient(&DockerCli_NodeClient{
+		DockerCli_Client: client,
+		node:             node,
+		settings:         client.settings.nodeSettings(node),
+	})
+}
+func (client *DockerCli_Client) InstancesClient(instances Instances) InstancesClient {
+	return InstancesClient(&DockerCli_InstancesClient{
+		DockerCli_Client: client,
+		instances:        instances,
+		settings:         client.settings.instancesSettings(instances),
+	})
+}
+func (client *DockerCli_Client) InstanceClient(instance Instance) InstanceClient {
+	return InstanceClient(&DockerCli_InstanceClient{
+		DockerCli_Client: client,
+		instance:         instance,
+		settings:         client.settings.instanceSettings(instance),
+	})
+}
+
+// The coach labels used to stamp and match client resources, for the node or one of its instances
+func (client *DockerCli_Client) labels(instanceId string) map[string]string {
+	return CoachLabels(client.conf, client.nodeId, instanceId)
+}
+func (client *DockerCli_Client) matchLabels(instanceId string) map[string]string {
+	return CoachMatchLabels(client.conf, client.nodeId, instanceId)
+}
+
+func (client *DockerCli_Client) Can(action string) bool {
+	switch action {
+	case "build":
+		return client.settings.BuildPath != ""
+	case "pull":
+		return client.settings.BuildPath == "" && client.settings.Config.Image != ""
+	case "volume":
+		return client.settings.Volume != nil
+	default:
+		return true
+	}
+}
+
+func (client *DockerCli_Client) DependsOn(target string) bool {
+	_, ok := client.settings.dependencies.Dependency(target)
+	return ok
+}
+
+// Image and tag for the node, following the same rules as the FSouza client
+func (client *DockerCli_Client) GetImageName() (image, tag string) {
+	image = strings.ToLower(client.id)
+	tag = "latest"
+
+	if client.settings.Config.Image == "" {
+		return
+	}
+	image, tag = dockerSplitImageTag(client.settings.Config.Image)
+	if tag == "" {
+		tag = "latest"
+	}
+	return
+}
+
+/**
+ * Docker CLI Wrapper
+ */
+
+// An image, as reported by $/> docker images
+type DockerCli_Image struct {
+	ID      string
+	RepoTag string
+	Created string
+}
+
+// A container, as reported by $/> docker ps
+type DockerCli_Container struct {
+	ID      string
+	Names   []string
+	Image   string
+	Status  string
+	Created string
+	State   string
+	Ports   []string
+	Labels  map[string]string
+}
+
+func (container *DockerCli_Container) IsRunning() bool {
+	return strings.HasPrefix(container.Status, "Up")
+}
+
+type DockerCli_Wrapper struct {
+	log        log.Log
+	binary     string
+	globalArgs []string
+	host       string
+
+	cacheLock        sync.RWMutex // nodes may be processed concurrently
+	cachedImages     []DockerCli_Image
+	cachedContainers []DockerCli_Container
+
+	networkLock sync.Mutex // networks are checked and created by one node at a time
+}
+
+// Init constructor for the docker binary wrapper
+func (wrapper *DockerCli_Wrapper) Init(logger log.Log, settings DockerCli_ClientFactorySettings) bool {
+	wrapper.log = logger
+
+	binary := settings.Binary
+	if binary == "" {
+		binary = DOCKERCLI_DEFAULT_BINARY
+	}
+	if found, err := exec.LookPath(binary); err == nil {
+		wrapper.binary = found
+	} else {
+		logger.Error("Could not find the docker binary [" + binary + "] => " + err.Error())
+		return false
+	}
+
+	wrapper.globalArgs = []string{}
+	if settings.Config != "" {
+		wrapper.globalArgs = append(wrapper.globalArgs, "--config", settings.Config)
+	}
+	if settings.Context != "" {
+		wrapper.globalArgs = append(wrapper.globalArgs, "--context", settings.Context)
+	}
+	if settings.Host != "" {
+		wrapper.globalArgs = append(wrapper.globalArgs, "--host", settings.Host)
+		wrapper.host = settings.Host
+	} else {
+		wrapper.host = os.Getenv("DOCKER_HOST")
+	}
+	if settings.CertPath != "" {
+		wrapper.globalArgs = append(wrapper.globalArgs,
+			"--tlsverify",
+			"--tlscacert", path.Join(settings.CertPath, "ca.pem"),
+			"--tlscert", path.Join(settings.CertPath, "cert.pem"),
+			"--tlskey", path.Join(settings.CertPath, "key.pem"),
+		)
+	}
+
+	logger.Debug(log.VERBOSITY_DEBUG_WOAH, "Docker CLI wrapper created:", wrapper.binary, wrapper.globalArgs)
+	return true
+}
+
+// Make a docker command, with the global arguments
+func (wrapper *DockerCli_Wrapper) Command(args ...string) *exec.Cmd {
+	return exec.Command(wrapper.binary, append(append([]string{}, wrapper.globalArgs...), args...)...)
+}
+
+// Run a docker command, sending all output to a logger.  Stderr is also kept, so
+// that a failed command can be converted into a client error of the right kind.
+func (wrapper *DockerCli_Wrapper) Run(logger log.Log, args ...string) error {
+	stderr := &bytes.Buffer{}
+	cmd := wrapper.Command(args...)
+	cmd.Stdout = logger
+	cmd.Stderr = io.MultiWriter(logger, stderr)
+
+	logger.Debug(log.VERBOSITY_DEBUG_LOTS, "Running docker command:", cmd.Args)
+	if err := cmd.Run(); err != nil {
+		return &dockerCliCommandError{err: err, stderr: stderr.String()}
+	}
+	return nil
+}
+
+// A failed docker command, with the messages that it wrote to stderr
+type dockerCliCommandError struct {
+	err    error
+	stderr string
+}
+
+func (commandError *dockerCliCommandError) Error() string {
+	if message := strings.TrimSpace(commandError.stderr); message != "" {
+		return commandError.err.Error() + " => " + message
+	}
+	return commandError.err.Error()
+}
+func (commandError *dockerCliCommandError) Unwrap() error {
+	return commandError.err
+}
+
+// Run a docker command, connecting it to the user terminal
+func (wrapper *DockerCli_Wrapper) Interactive(args ...string) error {
+	cmd := wrapper.Command(args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	wrapper.log.Debug(log.VERBOSITY_DEBUG_LOTS, "Running interactive docker command:", cmd.Args)
+	return cmd.Run()
+}
+
+// Run a docker command, and return its output as lines
+func (wrapper *DockerCli_Wrapper) Lines(args ...string) ([]string, error) {
+	output, err := wrapper.Command(args...).Output()
+	lines := []string{}
+	for _, line := range strings.Split(string(output), "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, err
+}
+
+// Reload all of the images and/or containers from the docker binary
+func (wrapper *DockerCli_Wrapper) Refresh(refreshImages bool, refreshContainers bool) error {
+	var err error
+
+	if refreshImages {
+		var lines []string
+		if lines, err = wrapper.Lines("images", "--no-trunc", "--format", "{{.ID}}\t{{.Repository}}:{{.Tag}}\t{{.CreatedSince}}"); err == nil {
+			images := []DockerCli_Image{}
+			for _, line := range lines {
+				fields := strings.SplitN(line, "\t", 3)
+				if len(fields) < 3 {
+					continue
+				}
+				images = append(images, DockerCli_Image{ID: fields[0], RepoTag: fields[1], Created: fields[2]})
+			}
+
+			wrapper.cacheLock.Lock()
+			wrapper.cachedImages = images
+			wrapper.cacheLock.Unlock()
+		}
+	}
+	if refreshContainers {
+		// only the coach labels are read, as the joined .Labels output can't be split reliably
+		labelKeys := []string{COACH_LABEL_PROJECT, COACH_LABEL_NODE, COACH_LABEL_INSTANCE, COACH_LABEL_ENVIRONMENT}
+		format := "{{.ID}}\t{{.Names}}\t{{.Image}}\t{{.Status}}\t{{.RunningFor}}"
+		for _, key := range labelKeys {
+			format += "\t{{.Label \"" + key + "\"}}"
+		}
+		format += "\t{{.State}}\t{{.Ports}}"
+
+		var lines []string
+		if lines, err = wrapper.Lines("ps", "--all", "--no-trunc", "--format", format); err == nil {
+			containers := []DockerCli_Container{}
+			for _, line := range lines {
+				fields := strings.Split(line, "\t")
+				if len(fields) < 7+len(labelKeys) {
+					continue
+				}
+				container := DockerCli_Container{
+					ID:      fields[0],
+					Names:   strings.Split(fields[1], ","),
+					Image:   fields[2],
+					Status:  fields[3],
+					Created: fields[4],
+					Labels:  map[string]string{},
+				}
+				for index, key := range labelKeys {
+					if value := fields[5+index]; value != "" {
+						container.Labels[key] = value
+					}
+				}
+				container.State = fields[5+len(labelKeys)]
+				if ports := fields[6+len(labelKeys)]; ports != "" {
+					container.Ports = strings.Split(ports, ", ")
+				}
+				containers = append(containers, container)
+			}
+
+			wrapper.cacheLock.Lock()
+			wrapper.cachedContainers = containers
+			wrapper.cacheLock.Unlock()
+		}
+	}
+
+	return err
+}
+
+// Return a list of all of the images known to the docker binary
+func (wrapper *DockerCli_Wrapper) AllImages(refresh bool) ([]DockerCli_Image, error) {
+	var err error
+	wrapper.cacheLock.RLock()
+	images := wrapper.cachedImages
+	wrapper.cacheLock.RUnlock()
+
+	if refresh || images == nil {
+		err = wrapper.Refresh(true, false)
+
+		wrapper.cacheLock.RLock()
+		images = wrapper.cachedImages
+		wrapper.cacheLock.RUnlock()
+	}
+	return images, err
+}
+
+// Return a list of images that have a specific repo tag
+func (wrapper *DockerCli_Wrapper) MatchImages(name string) ([]DockerCli_Image, error) {
+	name = strings.ToLower(name)
+	images, err := wrapper.AllImages(false)
+	filteredImages := []DockerCli_Image{}
+	for _, image := range images {
+		if image.RepoTag == name {
+			filteredImages = append(filteredImages, image)
+		}
+	}
+	return filteredImages, err
+}
+
+// Return a list of all of the containers known to the docker binary
+func (wrapper *DockerCli_Wrapper) AllContainers(refresh bool) ([]DockerCli_Container, error) {
+	var err error
+	wrapper.cacheLock.RLock()
+	containers := wrapper.cachedContainers
+	wrapper.cacheLock.RUnlock()
+
+	if refresh || containers == nil {
+		err = wrapper.Refresh(false, true)
+
+		wrapper.cacheLock.RLock()
+		containers = wrapper.cachedContainers
+		wrapper.cacheLock.RUnlock()
+	}
+	return containers, err
+}
+
+// Return a list of containers that carry all of the passed coach labels.  Containers
+// without any coach labels are matched by name, either exactly or as a "{machineName}_" prefix
+func (wrapper *DockerCli_Wrapper) MatchContainers(labels map[string]string, machineName string, prefix bool, running bool) ([]DockerCli_Container, error) {
+	containers, err := wrapper.AllContainers(false)
+	filteredContainers := []DockerCli_Container{}
+	for _, container := range containers {
+		if running && !container.IsRunning() {
+			continue
+		}
+
+		if HasCoachLabels(container.Labels) {
+			if MatchCoachLabels(container.Labels, labels) {
+				filteredContainers = append(filteredContainers, container)
+			}
+		} else if _, ok := legacyContainerNameMatch(container.Names, machineName, prefix); ok {
+			filteredContainers = append(filteredContainers, container)
+		}
+	}
+	return filteredContainers, err
+}
+
+// A network, as reported by $/> docker network inspect
+type DockerCli_Network struct {
+	Name       string
+	Labels     map[string]string
+	Containers int
+}
+
+// Inspect a network, returning false if the network doesn't exist
+func (wrapper *DockerCli_Wrapper) Network(name string) (DockerCli_Network, bool) {
+	network := DockerCli_Network{Name: name}
+	lines, err := wrapper.Lines("network", "inspect", "--format", "{{json .Labels}}\t{{len .Containers}}", name)
+	if err != nil || len(lines) == 0 {
+		return network, false
+	}
+
+	fields := strings.SplitN(lines[0], "\t", 2)
+	json.Unmarshal([]byte(fields[0]), &network.Labels)
+	if len(fields) > 1 {
+		network.Containers, _ = strconv.Atoi(strings.TrimSpace(fields[1]))
+	}
+	return network, true
+}
+
+// Make sure that a network exists, creating it if it doesn't
+func (wrapper *DockerCli_Wrapper) EnsureNetwork(logger log.Log, name string, labels map[string]string) (created bool, err error) {
+	wrapper.networkLock.Lock()
+	defer wrapper.networkLock.Unlock()
+
+	if _, found := wrapper.Network(name); found {
+		return false, nil
+	}
+
+	args := []string{"network", "create", "--driver", "bridge"}
+	for _, key := range dockerSortedKeys(labels) {
+		args = append(args, "--label", key+"="+labels[key])
+	}
+	output, err := wrapper.Command(append(args, name)...).CombinedOutput()
+	logger.Debug(log.VERBOSITY_DEBUG_LOTS, "Docker network create output:", strings.TrimSpace(string(output)))
+
+	if err != nil {
+		// the network may have been created by another docker client since it was inspected
+		if strings.Contains(string(output), "already exists") {
+			return false, nil
+		}
+		return false, errors.New("docker network create failed: " + strings.TrimSpace(string(output)+" "+err.Error()))
+	}
+	return true, nil
+}
+
+// A volume, as reported by $/> docker volume inspect
+type DockerCli_Volume struct {
+	Name       string
+	Driver     string
+	Mountpoint string
+	Options    map[string]string
+}
+
+// Inspect a volume, returning false if the volume doesn't exist
+func (wrapper *DockerCli_Wrapper) Volume(name string) (DockerCli_Volume, bool) {
+	volume := DockerCli_Volume{Name: name}
+	lines, err := wrapper.Lines("volume", "inspect", "--format", "{{.Driver}}\t{{.Mountpoint}}\t{{json .Options}}", name)
+	if err != nil || len(lines) == 0 {
+		return volume, false
+	}
+
+	fields := strings.SplitN(lines[0], "\t", 3)
+	volume.Driver = fields[0]
+	if len(fields) > 1 {
+		volume.Mountpoint = fields[1]
+	}
+	if len(fields) > 2 {
+		json.Unmarshal([]byte(fields[2]), &volume.Options)
+	}
+	return volume, true
+}
+
+/**
+ * Translate node settings into docker cli flags
+ */
+
+// Build "docker create" arguments from a docker container config and host config
+func dockerCliCreateArgs(name string, config docker.Config, host docker.HostConfig, aliases []string) []string {
+	args := []string{"create", "--name", name}
+
+	if config.Hostname != "" {
+		args = append(args, "--hostname", config.Hostname)
+	}
+	if config.Domainname != "" {
+		args = append(args, "--domainname", config.Domainname)
+	}
+	if config.User != "" {
+		args = append(args, "--user", config.User)
+	}
+	if config.WorkingDir != "" {
+		args = append(args, "--workdir", config.WorkingDir)
+	}
+	if config.Tty {
+		args = append(args, "--tty")
+	}
+	if config.OpenStdin {
+		args = append(args, "--interactive")
+	}
+	if config.StopSignal != "" {
+		args = append(args, "--stop-signal", config.StopSignal)
+	}
+	for _, env := range config.Env {
+		args = append(args, "--env", env)
+	}
+	for _, key := range dockerSortedKeys(config.Labels) {
+		args = append(args, "--label", key+"="+config.Labels[key])
+	}
+	exposed := []string{}
+	for port := range config.ExposedPorts {
+		exposed = append(exposed, string(port))
+	}
+	sort.Strings(exposed)
+	for _, port := range exposed {
+		args = append(args, "--expose", port)
+	}
+	volumes := []string{}
+	for volume := range config.Volumes {
+		volumes = append(volumes, volume)
+	}
+	sort.Strings(volumes)
+	for _, volume := range volumes {
+		args = append(args, "--volume", volume)
+	}
+
+	for _, bind := range host.Binds {
+		args = append(args, "--volume", bind)
+	}
+	for _, link := range host.Links {
+		args = append(args, "--link", link)
+	}
+	for _, volumesFrom := range host.VolumesFrom {
+		args = append(args, "--volumes-from", volumesFrom)
+	}
+	published := []string{}
+	for port := range host.PortBindings {
+		published = append(published, string(port))
+	}
+	sort.Strings(published)
+	for _, port := range published {
+		for _, binding := range host.PortBindings[docker.Port(port)] {
+			publish := binding.HostPort + ":" + port
+			if binding.HostIP != "" {
+				publish = binding.HostIP + ":" + publish
+			}
+			args = append(args, "--publish", publish)
+		}
+	}
+	if host.PublishAllPorts {
+		args = append(args, "--publish-all")
+	}
+	for _, capAdd := range host.CapAdd {
+		args = append(args, "--cap-add", capAdd)
+	}
+	for _, capDrop := range host.CapDrop {
+		args = append(args, "--cap-drop", capDrop)
+	}
+	for _, dns := range host.DNS {
+		args = append(args, "--dns", dns)
+	}
+	for _, dnsSearch := range host.DNSSearch {
+		args = append(args, "--dns-search", dnsSearch)
+	}
+	for _, extraHost := range host.ExtraHosts {
+		args = append(args, "--add-host", extraHost)
+	}
+	for _, securityOpt := range host.SecurityOpt {
+		args = append(args, "--security-opt", securityOpt)
+	}
+	if host.NetworkMode != "" {
+		args = append(args, "--network", host.NetworkMode)
+		if isUserNetworkMode(host.NetworkMode) {
+			for _, alias := range aliases {
+				args = append(args, "--network-alias", alias)
+			}
+		}
+	}
+	if host.Privileged {
+		args = append(args, "--privileged")
+	}
+	if host.ReadonlyRootfs {
+		args = append(args, "--read-only")
+	}
+	if host.Memory > 0 {
+		args = append(args, "--memory", strconv.FormatInt(host.Memory, 10))
+	}
+	if host.CPUShares > 0 {
+		args = append(args, "--cpu-shares", strconv.FormatInt(host.CPUShares, 10))
+	}
+	if host.RestartPolicy.Name != "" {
+		policy := host.RestartPolicy.Name
+		if host.RestartPolicy.MaximumRetryCount > 0 {
+			policy += ":" + strconv.Itoa(host.RestartPolicy.MaximumRetryCount)
+		}
+		args = append(args, "--restart", policy)
+	}
+
+	// the cli only takes a single entrypoint string, so any entrypoint arguments go before the cmd
+	cmd := config.Cmd
+	if len(config.Entrypoint) > 0 {
+		args = append(args, "--entrypoint", config.Entrypoint[0])
+		cmd = append(append([]string{}, config.Entrypoint[1:]...), cmd...)
+	}
+
+	args = append(args, config.Image)
+	return append(args, cmd...)
+}
+
+// shorten an image or container ID for display
+func dockerCliShortId(id string, length int) string {
+	id = strings.TrimPrefix(id, "sha256:")
+	if len(id) > length {
+		id = id[:length]
+	}
+	return id
+}
+
+// list the keys of a string map, in order, so that generated arguments are stable
+func dockerSortedKeys(values map[string]string) []string {
+	keys := []string{}
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+/**
+ * NodeClient meta-methods
+ */
+
+func (client *DockerCli_NodeClient) Images() []DockerCli_Image {
+	image, tag := client.GetImageName()
+
+	images, _ := client.backend.MatchImages(image + ":" + tag)
+	return images
+}
+func (client *DockerCli_NodeClient) HasImage() bool {
+	return len(client.Images()) > 0
+}
+
+func (client *DockerCli_NodeClient) HasVolume() bool {
+	_, found := client.backend.Volume(NodeVolumeName(client.node))
+	return found
+}
+
+func (client *DockerCli_NodeClient) NodeInfo(logger log.Log) {
+	if client.settings.Volume != nil {
+		client.VolumeInfo(logger)
+		return
+	}
+
+	images := client.Images()
+
+	if len(images) == 0 {
+		logger.Message("|-- no image [" + client.node.MachineName() + "]")
+	} else {
+		logger.Message("|-> Images")
+
+		w := new(tabwriter.Writer)
+		w.Init(logger, 8, 12, 2, ' ', 0)
+
+		w.Write([]byte(strings.Join([]string{"|=", "ID", "RepoTags", "Created"}, "\t") + "\n"))
+		for _, image := range images {
+			row := []string{
+				"|-",
+				dockerCliShortId(image.ID, 11),
+				image.RepoTag,
+				image.Created,
+			}
+			w.Write([]byte(strings.Join(row, "\t") + "\n"))
+		}
+		w.Flush()
+	}
+}
+
+func (client *DockerCli_NodeClient) Report() NodeReport {
+	report := NodeReport{}
+
+	if client.settings.Volume != nil {
+		if volume, found := client.backend.Volume(NodeVolumeName(client.node)); found {
+			report.Volume = &VolumeReport{Name: volume.Name, Driver: volume.Driver, Mountpoint: volume.Mountpoint}
+		}
+		return report
+	}
+
+	for _, image := range client.Images() {
+		report.Images = append(report.Images, ImageReport{
+			ID:      image.ID,
+			Tags:    []string{image.RepoTag},
+			Created: image.Created,
+		})
+	}
+	report.HasImage = len(report.Images) > 0
+	return report
+}
+
+func (client *DockerCli_NodeClient) VolumeInfo(logger log.Log) {
+	name := NodeVolumeName(client.node)
+
+	volume, found := client.backend.Volume(name)
+	if !found {
+		logger.Message("|-- no volume [" + name + "]")
+		return
+	}
+
+	logger.Message("|-> Volume")
+
+	w := new(tabwriter.Writer)
+	w.Init(logger, 8, 12, 2, ' ', 0)
+
+	w.Write([]byte(strings.Join([]string{"|=", "Name", "Driver", "Mountpoint", "Options"}, "\t") + "\n"))
+
+	options := []string{}
+	for _, key := range dockerSortedKeys(volume.Options) {
+		options = append(options, key+"="+volume.Options[key])
+	}
+	row := []string{
+		"|-",
+		volume.Name,
+		volume.Driver,
+		volume.Mountpoint,
+		strings.Join(options, ","),
+	}
+	w.Write([]byte(strings.Join(row, "\t") + "\n"))
+	w.Flush()
+}
+
+/**
+ * InstancesClient methods
+ */
+
+func (client *DockerCli_InstancesClient) Containers(running bool) []DockerCli_Container {
+	matchString := client.instances.MachineName()
+	if matchString == INSTANCES_NULL_MACHINENAME {
+		return []DockerCli_Container{}
+	}
+	containers, _ := client.backend.MatchContainers(client.matchLabels(""), matchString, true, running)
+	return containers
+}
+
+func (client *DockerCli_InstancesClient) InstancesInfo(logger log.Log) {
+	instances := client.instances
+
+	if instances.MachineName() == INSTANCES_NULL_MACHINENAME {
+
+	} else if len(instances.InstancesOrder()) == 0 {
+		logger.Message("|-= no containers")
+	} else {
+		logger.Message("|-> instances (containers) MachineName:" + instances.MachineName())
+
+		w := new(tabwriter.Writer)
+		w.Init(logger, 8, 12, 2, ' ', 0)
+
+		row := []string{
+			"|=",
+			"Name",
+			"Container",
+			"Default",
+			"Created",
+			"Running",
+			"Status",
+			"ID",
+			"Created",
+		}
+		w.Write([]byte(strings.Join(row, "\t") + "\n"))
+
+		for _, name := range instances.InstancesOrder() {
+			instance, _ := instances.Instance(name)
+			machineName := instance.MachineName()
+			instanceClient := instance.Client()
+
+			row := []string{
+				"|-",
+				name,
+				machineName,
+			}
+			if instance.IsDefault() {
+				row = append(row, "yes")
+			} else {
+				row = append(row, "no")
+			}
+			if instanceClient.HasContainer() {
+				row = append(row, "yes")
+			} else {
+				row = append(row, "no")
+			}
+			if instanceClient.IsRunning() {
+				row = append(row, "yes")
+			} else {
+				row = append(row, "no")
+			}
+
+			containers, _ := client.backend.MatchContainers(client.matchLabels(instance.Id()), machineName, false, false)
+			for _, container := range containers {
+				row = append(row,
+					container.Status,
+					dockerCliShortId(container.ID, 12),
+					container.Created,
+				)
+				break
+			}
+
+			w.Write([]byte(strings.Join(row, "\t") + "\n"))
+		}
+		w.Flush()
+	}
+}
+
+func (client *DockerCli_InstancesClient) InstancesFound(logger log.Log) []string {
+	ids := []string{}
+	for _, container := range client.Containers(false) {
+		if HasCoachLabels(container.Labels) {
+			if id, ok := container.Labels[COACH_LABEL_INSTANCE]; ok {
+				ids = append(ids, id)
+			}
+		} else if id, ok := legacyContainerNameMatch(container.Names, client.instances.MachineName(), true); ok {
+			if id == "" {
+				id = INSTANCE_SINGLE_ID
+			}
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+/**
+ * InstanceClient meta-methods
+ */
+
+func (client *DockerCli_InstanceClient) Containers(running bool) []DockerCli_Container {
+	instance := client.instance
+	containers, _ := client.backend.MatchContainers(client.matchLabels(instance.Id()), instance.MachineName(), false, running)
+	return containers
+}
+func (client *DockerCli_InstanceClient) HasContainer() bool {
+	return len(client.Containers(false)) > 0
+}
+func (client *DockerCli_InstanceClient) IsRunning() bool {
+	return len(client.Containers(true)) > 0
+}
+func (client *DockerCli_InstanceClient) Report() InstanceReport {
+	report := InstanceReport{Container: client.instance.MachineName()}
+	for _, container := range client.Containers(false) {
+		report.HasContainer = true
+		report.Running = container.IsRunning()
+		report.ID = container.ID
+		report.Image = container.Image
+		report.State = container.State
+		report.Status = container.Status
+		report.Created = container.Created
+		report.Ports = container.Ports
+		break
+	}
+	return report
+}
+func (client *DockerCli_InstanceClient) Inspect(logger log.Log) (InstanceInspect, error) {
+	instance := client.instance
+
+	image, tag := client.GetImageName()
+	if tag != "" && tag != "latest" {
+		image += ":" + tag
+	}
+	inspect := makeInstanceInspect(client.conf, client.settings, client.nodeId, instance, image, MergeCoachLabels(client.settings.Config.Labels, client.labels(instance.Id())))
+
+	if client.HasContainer() {
+		// docker inspect writes a list of documents, one for each container asked for
+		var containers []interface{}
+		output, err := client.backend.Command("inspect", instance.MachineName()).Output()
+		if err == nil {
+			err = json.Unmarshal(output, &containers)
+		}
+		if err != nil || len(containers) == 0 {
+			if err == nil {
+				err = errors.New("no container was returned")
+			}
+			logger.Error("Failed to inspect instance container [" + instance.MachineName() + "] => " + err.Error())
+			return inspect, cliClientError("inspect", instance.MachineName(), err)
+		}
+		inspect.Container = containers[0]
+	}
+	return inspect, nil
+}
+func (client *DockerCli_InstanceClient) IsReady() bool {
+	ready, _ := client.readyCheck()
+	return ready
+}
+func (client *DockerCli_InstanceClient) WaitReady(logger log.Log) error {
+	return waitForReady(logger, client.instance.MachineName(), client.settings.Healthcheck, client.readyCheck)
+}
+
+// Run the instance health checks once, returning a reason if the instance is not ready
+func (client *DockerCli_InstanceClient) readyCheck() (bool, string) {
+	healthcheck := client.settings.Healthcheck
+	if healthcheck == nil {
+		// without health checks, an instance is ready once it has a container
+		return client.HasContainer(), "instance has no container"
+	}
+
+	id := client.instance.MachineName()
+	lines, err := client.backend.Lines("inspect", "--type", "container", "--format", "{{json .State}}\n{{json .NetworkSettings}}", id)
+	if err != nil || len(lines) < 2 {
+		return false, "could not inspect container [" + id + "]"
+	}
+	var state docker.State
+	var network docker.NetworkSettings
+	if err := json.Unmarshal([]byte(lines[0]), &state); err != nil {
+		return false, "could not read container state => " + err.Error()
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &network); err != nil {
+		return false, "could not read container network settings => " + err.Error()
+	}
+	if !state.Running {
+		return false, "container is not running"
+	}
+
+	if healthcheck.Docker && state.Health.Status != "healthy" {
+		return false, "docker health status is [" + state.Health.Status + "]"
+	}
+	if healthcheck.Port > 0 {
+		if ready, reason := healthcheckPort(client.backend.host, healthcheck.Port, &network); !ready {
+			return false, reason
+		}
+	}
+	if len(healthcheck.Command) > 0 {
+		if err := client.backend.Command(append([]string{"exec", id}, healthcheck.Command...)...).Run(); err != nil {
+			return false, "health check command failed => " + err.Error()
+		}
+	}
+	if healthcheck.Log != "" {
+		logs, err := client.backend.Command("logs", id).CombinedOutput()
+		if err != nil {
+			return false, "could not read container logs => " + err.Error()
+		}
+		if ready, reason := healthcheckLog(healthcheck.Log, string(logs)); !ready {
+			return false, reason
+		}
+	}
+
+	return true, ""
+}
+
+/**
+ * NodeClient interface: Operation Methods
+ */
+
+func (client *DockerCli_NodeClient) Build(logger log.Log, force bool, buildOptions BuildOptions) error {
+	image, tag := client.GetImageName()
+
+	if client.settings.BuildPath == "" {
+		logger.Warning("Node image [" + image + ":" + tag + "] not built as an empty path was provided.  You must point Build: to a path inside .coach")
+		return NewClientError(ERROR_INVALID, "build", image+":"+tag, errors.New("no build path"))
+	}
+
+	if !force && client.HasImage() {
+		logger.Info("Node image [" + image + ":" + tag + "] not built as an image already exists.  You can force this operation to build this image")
+		return NewClientError(ERROR_IMAGE_EXISTS, "build", image+":"+tag, nil)
+	}
+
+	// determine an absolute buildPath to the build, for Docker to use.
+	buildPath := ""
+	for _, confBuildPath := range client.conf.Paths.GetConfSubPaths(client.settings.BuildPath) {
+		logger.Debug(log.VERBOSITY_DEBUG_STAAAP, "Looking for Build: "+confBuildPath)
+		if _, err := os.Stat(confBuildPath); !os.IsNotExist(err) {
+			buildPath = confBuildPath
+			break
+		}
+	}
+	if buildPath == "" {
+		logger.Error("No matching build path could be found [" + client.settings.BuildPath + "]")
+		return NewClientError(ERROR_INVALID, "build", image+":"+tag, errors.New("no matching build path ["+client.settings.BuildPath+"]"))
+	}
+
+	logger.Info("Building node image [" + image + ":" + tag + "] From build path [" + buildPath + "]")
+
+	args := []string{"build", "--rm", "--tag", image + ":" + tag}
+	if client.settings.Dockerfile != "" {
+		dockerfile := client.settings.Dockerfile
+		if !path.IsAbs(dockerfile) {
+			dockerfile = path.Join(buildPath, dockerfile)
+		}
+		args = append(args, "--file", dockerfile)
+	}
+	if client.settings.NoCache || buildOptions.NoCache {
+		args = append(args, "--no-cache")
+	}
+	if client.settings.BuildPull || buildOptions.Pull {
+		args = append(args, "--pull")
+	}
+	if client.settings.BuildTarget != "" {
+		args = append(args, "--target", client.settings.BuildTarget)
+	}
+	for _, name := range dockerSortedKeys(client.settings.BuildArgs) {
+		args = append(args, "--build-arg", name+"="+client.settings.BuildArgs[name])
+	}
+	labels := MergeCoachLabels(client.settings.BuildLabels, client.labels(""))
+	for _, name := range dockerSortedKeys(labels) {
+		args = append(args, "--label", name+"="+labels[name])
+	}
+
+	err := client.backend.Run(logger, append(args, buildPath)...)
+	client.backend.Refresh(true, false)
+
+	if err != nil {
+		logger.Error("Node build failed [" + client.node.MachineName() + "] in build path [" + buildPath + "] => " + err.Error())
+		return cliClientError("build", image+":"+tag, err)
+	} else {
+		logger.Message("Node succesfully built image [" + image + ":" + tag + "] From path [" + buildPath + "]")
+		return nil
+	}
+}
+
+func (client *DockerCli_NodeClient) Destroy(logger log.Log, force bool) error {
+	image, tag := client.GetImageName()
+	if tag != "" {
+		image += ":" + tag
+	}
+
+	if !client.HasImage() {
+		logger.Warning("Node has no image to destroy [" + image + "]")
+		return NewClientError(ERROR_IMAGE_NOT_FOUND, "destroy", image, nil)
+	}
+
+	args := []string{"rmi"}
+	if force {
+		args = append(args, "--force")
+	}
+
+	err := client.backend.Run(logger, append(args, image)...)
+	client.backend.Refresh(true, false)
+
+	if err != nil {
+		logger.Error("Node image removal failed [" + image + "] => " + err.Error())
+		return cliClientError("destroy", image, err)
+	} else {
+		logger.Message("Node image was removed [" + image + "]")
+		return nil
+	}
+}
+
+func (client *DockerCli_NodeClient) Pull(logger log.Log, force bool) error {
+	image, tag := client.GetImageName()
+	actionCacheTag := "pull:" + image + ":" + tag
+
+	// another node may still be pulling the image, so wait for its result
+	if result, ok := actionCache.Wait(actionCacheTag); ok {
+		logger.Message("Node image [" + image + ":" + tag + "] was just pulled, so not pulling it again.")
+		return result
+	}
+
+	if !force && client.HasImage() {
+		logger.Info("Node already has an image [" + image + ":" + tag + "], so not pulling it again.  You can force this operation if you want to pull this image.")
+		return NewClientError(ERROR_IMAGE_EXISTS, "pull", image+":"+tag, nil)
+	}
+
+	result, ran := actionCache.Once(actionCacheTag, func() error {
+		// the docker binary takes care of registries and credentials
+		logger.Message("Pulling node image [" + image + ":" + tag + "]")
+		err := client.backend.Run(logger, "pull", image+":"+tag)
+
+		if err != nil {
+			logger.Error("Node image not pulled : " + image + " => " + err.Error())
+			return cliClientError("pull", image+":"+tag, err)
+		} else {
+			client.backend.Refresh(true, false)
+			logger.Message("Node image pulled: " + image + ":" + tag)
+			return nil
+		}
+	})
+	if !ran {
+		logger.Message("Node image [" + image + ":" + tag + "] was just pulled, so not pulling it again.")
+	}
+	return result
+}
+
+func (client *DockerCli_NodeClient) Push(logger log.Log, repository string, tag string) error {
+	image, imageTag := client.GetImageName()
+	if client.settings.Repository != "" {
+		image = client.settings.Repository
+	}
+	if tag == "" {
+		tag = imageTag
+	}
+	if repository == "" {
+		repository = image
+	}
+
+	if image != repository {
+		if err := client.backend.Run(logger.MakeChild("docker"), "tag", image+":"+tag, repository+":"+tag); err != nil {
+			logger.Error("Node image could not be tagged for push [" + image + ":" + tag + "] => [" + repository + ":" + tag + "] : " + err.Error())
+			return cliClientError("tag", image+":"+tag, err)
+		}
+		client.backend.Refresh(true, false)
+	}
+
+	// the docker binary takes care of registries and credentials
+	logger.Message("Pushing node image [" + repository + ":" + tag + "]")
+	if err := client.backend.Run(logger, "push", repository+":"+tag); err != nil {
+		logger.Error("Node image not pushed : " + repository + ":" + tag + " => " + err.Error())
+		return cliClientError("push", repository+":"+tag, err)
+	} else {
+		logger.Message("Node image pushed: " + repository + ":" + tag)
+		return nil
+	}
+}
+
+func (client *DockerCli_NodeClient) CreateVolume(logger log.Log) error {
+	name := NodeVolumeName(client.node)
+
+	if client.settings.Volume == nil {
+		logger.Warning("Node has no Volume: settings, so no volume will be created [" + name + "]")
+		return NewClientError(ERROR_INVALID, "create-volume", name, errors.New("no Volume: settings"))
+	}
+	if client.HasVolume() {
+		logger.Info("Node volume already exists [" + name + "]")
+		return NewClientError(ERROR_VOLUME_EXISTS, "create-volume", name, nil)
+	}
+
+	args := []string{"volume", "create"}
+	if client.settings.Volume.Driver != "" {
+		args = append(args, "--driver", client.settings.Volume.Driver)
+	}
+	for _, key := range dockerSortedKeys(client.settings.Volume.Options) {
+		args = append(args, "--opt", key+"="+client.settings.Volume.Options[key])
+	}
+	labels := MergeCoachLabels(client.settings.Volume.Labels, client.labels(""))
+	for _, key := range dockerSortedKeys(labels) {
+		args = append(args, "--label", key+"="+labels[key])
+	}
+
+	if err := client.backend.Run(logger.MakeChild("docker"), append(args, name)...); err != nil {
+		logger.Error("Failed to create node volume [" + name + "] => " + err.Error())
+		return cliClientError("create-volume", name, err)
+	} else {
+		logger.Message("Created node volume [" + name + "]")
+		return nil
+	}
+}
+
+func (client *DockerCli_NodeClient) RemoveVolume(logger log.Log, force bool) error {
+	name := NodeVolumeName(client.node)
+
+	if !client.HasVolume() {
+		logger.Warning("Node has no volume to remove [" + name + "]")
+		return NewClientError(ERROR_VOLUME_NOT_FOUND, "remove-volume", name, nil)
+	}
+
+	args := []string{"volume", "rm"}
+	if force {
+		args = append(args, "--force")
+	}
+
+	if err := client.backend.Run(logger.MakeChild("docker"), append(args, name)...); err != nil {
+		logger.Error("Node volume removal failed [" + name + "] => " + err.Error())
+		return cliClientError("remove-volume", name, err)
+	} else {
+		logger.Message("Node volume was removed [" + name + "]")
+		return nil
+	}
+}
+
+func (client *DockerCli_NodeClient) RemoveNetworks(logger log.Log) error {
+	errs := Errors{}
+	labels := ProjectNetworkLabels(client.conf)
+	delete(labels, COACH_LABEL_ENVIRONMENT)
+
+	for _, name := range nodeNetworkNames(client.conf, client.settings) {
+		// only the project network belongs to coach, any other networks are shared and never removed
+		if name != ProjectNetworkName(client.conf) {
+			continue
+		}
+		actionCacheTag := "network-remove:" + name
+		if actionCache.Has(actionCacheTag) {
+			continue
+		}
+
+		network, found := client.backend.Network(name)
+		if !found {
+			continue
+		}
+		if !MatchCoachLabels(network.Labels, labels) {
+			logger.Info("Network was not created by coach for this project, so it will not be removed [" + name + "]")
+			actionCache.Set(actionCacheTag, nil)
+			continue
+		}
+		if network.Containers > 0 {
+			logger.Info("Network is still in use by " + strconv.Itoa(network.Containers) + " containers, so it will not be removed [" + name + "]")
+			continue
+		}
+
+		if err := client.backend.Run(logger.MakeChild("docker"), "network", "rm", name); err != nil {
+			logger.Warning("Failed to remove network [" + name + "] : " + err.Error())
+			errs.Add(cliClientError("remove-network", name, err))
+		} else {
+			logger.Message("Removed network [" + name + "]")
+			actionCache.Set(actionCacheTag, nil)
+		}
+	}
+	return errs.Err()
+}
+
+func (client *DockerCli_NodeClient) Events(logger log.Log, options EventsOptions, events chan<- ProjectEvent) error {
+	args := []string{"events", "--format", "{{json .}}"}
+	if options.Since != "" {
+		args = append(args, "--since", options.Since)
+	}
+	if options.Until != "" {
+		args = append(args, "--until", options.Until)
+	}
+	for key, values := range eventsFilters(client.conf, options) {
+		for _, value := range values {
+			args = append(args, "--filter", key+"="+value)
+		}
+	}
+
+	cmd := client.backend.Command(args...)
+	cmd.Stderr = logger
+	output, err := cmd.StdoutPipe()
+	if err == nil {
+		logger.Debug(log.VERBOSITY_DEBUG_LOTS, "Running docker command:", cmd.Args)
+		err = cmd.Start()
+	}
+	if err != nil {
+		logger.Error("Failed to listen to the docker events => " + err.Error())
+		return cliClientError("events", client.nodeId, err)
+	}
+
+	// the docker binary writes the remote API events as json, one per line
+	scanner := bufio.NewScanner(output)
+	for scanner.Scan() {
+		var event docker.APIEvents
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			logger.Warning("Could not read a docker event => " + err.Error())
+			continue
+		}
+		if projectEvent := eventFromDocker(&event); options.HasNode(projectEvent.Node) {
+			events <- projectEvent
+		}
+	}
+
+	if err := cmd.Wait(); err != nil {
+		logger.Error("Failed to listen to the docker events => " + err.Error())
+		return cliClientError("events", client.nodeId, err)
+	}
+	return nil
+}
+
+// All nodes that use the same docker binary wrapper can share an event stream
+func (client *DockerCli_NodeClient) EventsStream() interface{} {
+	return client.backend
+}
+
+/**
+ * InstanceClient : Action methods
+ */
+
+func (client *DockerCli_InstanceClient) Attach(logger log.Log, options AttachOptions) error {
+	id := client.instance.MachineName()
+
+	// the docker binary can't replay logs when attaching, so they are output first
+	if options.Logs {
+		cmd := client.backend.Command("logs", id)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		cmd.Run()
+	}
+
+	args := []string{"attach"}
+	if options.DetachKeys != "" {
+		args = append(args, "--detach-keys", options.DetachKeys)
+	}
+	if !options.Stdin {
+		args = append(args, "--no-stdin")
+	}
+
+	logger.Message("Attaching to instance container [" + id + "]")
+	if err := client.backend.Interactive(append(args, id)...); err != nil {
+		logger.Error("Failed to attach to instance container [" + id + "] =>" + err.Error())
+		return cliClientError("attach", id, err)
+	} else {
+		logger.Message("Disconnected from instance container [" + id + "]")
+		return nil
+	}
+}
+
+func (client *DockerCli_InstanceClient) Create(logger log.Log, overrideCmd []string, force bool) error {
+	instance := client.instance
+
+	if !force && client.HasContainer() {
+		logger.Info("[" + instance.MachineName() + "]: Skipping node instance, which already has a container")
+		return NewClientError(ERROR_CONTAINER_EXISTS, "create", instance.MachineName(), nil)
+	}
+
+	name := instance.MachineName()
+	Config := client.settings.Config
+	Host := client.settings.Host
+
+	image, tag := client.GetImageName()
+	if tag != "" && tag != "latest" {
+		image += ":" + tag
+	}
+	Config.Image = image
+	Config.Labels = MergeCoachLabels(Config.Labels, client.labels(instance.Id()))
+
+	if len(overrideCmd) > 0 {
+		Config.Cmd = overrideCmd
+	}
+
+	// make sure that the instance networks exist, and join the first when creating the container
+	networks := nodeNetworkNames(client.conf, client.settings)
+	aliases := []string{}
+	for _, network := range networks {
+		if created, err := client.backend.EnsureNetwork(logger.MakeChild("docker"), network, networkCreateLabels(client.conf, network)); err != nil {
+			logger.Error("Failed to create network for instance container [" + name + "] : " + network + " => " + err.Error())
+			return cliClientError("create-network", network, err)
+		} else if created {
+			logger.Message("Created network [" + network + "]")
+		}
+	}
+	if len(networks) > 0 {
+		Host.NetworkMode = networks[0]
+		aliases = instanceNetworkAliases(client.nodeId, instance)
+	}
+
+	err := client.backend.Run(logger.MakeChild("docker"), dockerCliCreateArgs(name, Config, Host, aliases)...)
+	client.backend.Refresh(false, true)
+
+	if err != nil {
+		logger.Error("Failed to create instance container [" + name + " FROM " + Config.Image + "] => " + err.Error())
+		return cliClientError("create", name, err)
+	} else {
+		logger.Message("Created instance container [" + name + "]")
+
+		// any additional networks can only be joined after the container exists
+		if len(networks) > 1 {
+			for _, network := range networks[1:] {
+				args := []string{"network", "connect"}
+				for _, alias := range aliases {
+					args = append(args, "--alias", alias)
+				}
+				if err := client.backend.Run(logger.MakeChild("docker"), append(args, network, name)...); err != nil {
+					logger.Warning("Failed to connect instance container to network [" + name + "] : " + network + " => " + err.Error())
+				}
+			}
+		}
+		return nil
+	}
+}
+
+func (client *DockerCli_InstanceClient) Remove(logger log.Log, force bool) error {
+	name := client.instance.MachineName()
+
+	args := []string{"rm"}
+	if force {
+		args = append(args, "--force")
+	}
+
+	err := client.backend.Run(logger.MakeChild("docker"), append(args, name)...)
+	client.backend.Refresh(false, true)
+
+	if err != nil {
+		logger.Error("Failed to remove instance container [" + name + "] =>" + err.Error())
+		return cliClientError("remove", name, err)
+	} else {
+		logger.Message("Removed instance container [" + name + "] ")
+		return nil
+	}
+}
+
+func (client *DockerCli_InstanceClient) Start(logger log.Log, force bool) error {
+	id := client.instance.MachineName()
+
+	err := client.backend.Run(logger.MakeChild("docker"), "start", id)
+	client.backend.Refresh(false, true)
+
+	if err != nil {
+		logger.Error("Failed to start node container [" + id + "] => " + err.Error())
+		return cliClientError("start", id, err)
+	} else {
+		logger.Message("Node instance started [" + id + "]")
+		return nil
+	}
+}
+
+func (client *DockerCli_InstanceClient) Stop(logger log.Log, force bool, timeout uint) error {
+	id := client.instance.MachineName()
+
+	err := client.backend.Run(logger.MakeChild("docker"), "stop", "--time", strconv.FormatUint(uint64(timeout), 10), id)
+	client.backend.Refresh(false, true)
+
+	if err != nil {
+		logger.Error("Failed to stop node container [" + id + "] => " + err.Error())
+		return cliClientError("stop", id, err)
+	} else {
+		logger.Message("Node instance stopped [" + id + "]")
+		return nil
+	}
+}
+
+func (client *DockerCli_InstanceClient) Pause(logger log.Log) error {
+	id := client.instance.MachineName()
+
+	err := client.backend.Run(logger.MakeChild("docker"), "pause", id)
+	client.backend.Refresh(false, true)
+
+	if err != nil {
+		logger.Error("Failed to pause intance [" + client.instance.Id() + "] Container [" + id + "] =>" + err.Error())
+		return cliClientError("pause", id, err)
+	} else {
+		logger.Message("Paused instance [" + client.instance.Id() + "] Container [" + id + "]")
+		return nil
+	}
+}
+
+func (client *DockerCli_InstanceClient) Unpause(logger log.Log) error {
+	id := client.instance.MachineName()
+
+	err := client.backend.Run(logger.MakeChild("docker"), "unpause", id)
+	client.backend.Refresh(false, true)
+
+	if err != nil {
+		logger.Error("Failed to unpause Instance [" + client.instance.Id() + "] Container [" + id + "] =>" + err.Error())
+		return cliClientError("unpause", id, err)
+	} else {
+		logger.Message("Unpaused Instance [" + client.instance.Id() + "] Container [" + id + "]")
+		return nil
+	}
+}
+
+func (client *DockerCli_InstanceClient) Commit(logger log.Log, tag string, message string) error {
+	id := client.instance.MachineName()
+	repo := client.settings.Repository
+	author := client.settings.Author
+
+	if repo == "" {
+		repo, _ = client.GetImageName()
+	}
+	if tag == "" {
+		tag = "latest"
+	}
+	if author == "" {
+		author = client.conf.Author
+	}
+
+	args := []string{"commit"}
+	if author != "" {
+		args = append(args, "--author", author)
+	}
+	if message != "" {
+		args = append(args, "--message", message)
+	}
+	labels := client.labels("")
+	for _, name := range dockerSortedKeys(labels) {
+		args = append(args, "--change", "LABEL "+name+"=\""+labels[name]+"\"")
+	}
+
+	err := client.backend.Run(logger.MakeChild("docker"), append(args, id, repo+":"+tag)...)
+	client.backend.Refresh(true, false)
+
+	if err != nil {
+		logger.Warning("Failed to commit container changes to an image [" + client.instance.Id() + ":" + id + "] : " + tag)
+		return cliClientError("commit", id, err)
+	} else {
+		logger.Message("Committed container changes to an image [" + client.instance.Id() + ":" + id + "] : " + tag)
+		return nil
+	}
+}
+
+func (client *DockerCli_InstanceClient) Logs(logger log.Log, output io.Writer, options LogsOptions) error {
+	id := client.instance.MachineName()
+
+	args := []string{"logs"}
+	if options.Follow {
+		args = append(args, "--follow")
+	}
+	if options.Tail != "" {
+		args = append(args, "--tail", options.Tail)
+	}
+	if options.Since != "" {
+		args = append(args, "--since", options.Since)
+	}
+	if options.Timestamps {
+		args = append(args, "--timestamps")
+	}
+	args = append(args, id)
+
+	cmd := client.backend.Command(args...)
+	cmd.Stdout = output
+	cmd.Stderr = output
+
+	logger.Debug(log.VERBOSITY_DEBUG_LOTS, "Running docker command:", cmd.Args)
+	if err := cmd.Run(); err != nil {
+		logger.Error("Failed to read instance container logs [" + id + "] => " + err.Error())
+		return cliClientError("logs", id, err)
+	}
+	return nil
+}
+
+func (client *DockerCli_InstanceClient) Exec(logger log.Log, cmd []string, options ExecOptions) (int, error) {
+	id := client.instance.MachineName()
+
+	args := []string{"exec"}
+	if options.Stdin {
+		args = append(args, "--interactive")
+	}
+	if options.Tty {
+		args = append(args, "--tty")
+	}
+	if options.User != "" {
+		args = append(args, "--user", options.User)
+	}
+	if options.WorkingDir != "" {
+		args = append(args, "--workdir", options.WorkingDir)
+	}
+	for _, env := range options.Env {
+		args = append(args, "--env", env)
+	}
+	args = append(append(args, id), cmd...)
+
+	logger.Info("Running command in instance container [" + id + "] : " + strings.Join(cmd, " "))
+	if err := client.backend.Interactive(args...); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return exitErr.ExitCode(), nil
+		}
+		logger.Error("Failed to run exec in instance container [" + id + "] => " + err.Error())
+		return -1, cliClientError("exec", id, err)
+	}
+	return 0, nil
+}
+
+func (client *DockerCli_InstanceClient) Stats(logger log.Log) (InstanceStats, error) {
+	id := client.instance.MachineName()
+
+	lines, err := client.backend.Lines("stats", "--no-stream", "--format", "{{json .}}", id)
+	if err != nil || len(lines) == 0 {
+		if err == nil {
+			err = errors.New("no stats were returned")
+		}
+		logger.Error("Failed to read instance container stats [" + id + "] => " + err.Error())
+		return InstanceStats{}, cliClientError("stats", id, err)
+	}
+
+	// the docker binary only writes formatted values, so they are parsed back into numbers
+	var row struct {
+		CPUPerc  string
+		MemUsage string
+		NetIO    string
+		BlockIO  string
+		PIDs     string
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &row); err != nil {
+		logger.Error("Failed to read instance container stats [" + id + "] => " + err.Error())
+		return InstanceStats{}, cliClientError("stats", id, err)
+	}
+
+	stats := InstanceStats{}
+	stats.CPUPercent, _ = strconv.ParseFloat(strings.TrimSuffix(row.CPUPerc, "%"), 64)
+	stats.PIDs, _ = strconv.ParseUint(row.PIDs, 10, 64)
+	if stats.MemoryUsage, stats.MemoryLimit, err = parseDockerSizePair(row.MemUsage); err == nil {
+		if stats.NetworkRx, stats.NetworkTx, err = parseDockerSizePair(row.NetIO); err == nil {
+			stats.BlockRead, stats.BlockWrite, err = parseDockerSizePair(row.BlockIO)
+		}
+	}
+	if err != nil {
+		logger.Warning("Could not read all instance container stats [" + id + "] => " + err.Error())
+	}
+	return stats, nil
+}
+
+func (client *DockerCli_InstanceClient) Upload(logger log.Log, source string, destination string) error {
+	id := client.instance.MachineName()
+	destination = path.Clean(destination)
+
+	// docker cp reads a tar archive from stdin, and extracts it into a container directory
+	err := uploadFromHostPath(source, path.Base(destination), func(reader io.Reader) error {
+		cmd := client.backend.Command("cp", "-", id+":"+path.Dir(destination))
+		cmd.Stdin = reader
+		cmd.Stdout = logger
+		cmd.Stderr = logger
+
+		logger.Debug(log.VERBOSITY_DEBUG_LOTS, "Running docker command:", cmd.Args)
+		return cmd.Run()
+	})
+	if err != nil {
+		logger.Error("Failed to copy [" + source + "] into instance container [" + id + ":" + destination + "] => " + err.Error())
+		return cliClientError("upload", id, err)
+	}
+	logger.Message("Copied [" + source + "] into instance container [" + id + ":" + destination + "]")
+	return nil
+}
+
+func (client *DockerCli_InstanceClient) Download(logger log.Log, source string, destination string) error {
+	id := client.instance.MachineName()
+
+	// docker cp writes a tar archive to stdout, when the destination is -
+	err := downloadToHostPath(destination, func(writer io.Writer) error {
+		cmd := client.backend.Command("cp", id+":"+source, "-")
+		cmd.Stdout = writer
+		cmd.Stderr = logger
+
+		logger.Debug(log.VERBOSITY_DEBUG_LOTS, "Running docker command:", cmd.Args)
+		return cmd.Run()
+	})
+	if err != nil {
+		logger.Error("Failed to copy [" + id + ":" + source + "] from instance container to [" + destination + "] => " + err.Error())
+		return cliClientError("download", id, err)
+	}
+	logger.Message("Copied [" + id + ":" + source + "] from instance container to [" + destination + "]")
+	return nil
+}
+
+func (client *DockerCli_InstanceClient) Run(logger log.Log, cmd []string, options RunOptions) (int, error) {
+	hushedLogger := logger.MakeChild("RunSupport")
+	hushedLogger.Hush()
+
+	instance := client.instance
+
+	// a raw terminal would mangle piped input and output, so only use a TTY if coach is on one
+	client.settings.Config.Tty = client.settings.Config.Tty && options.Tty
+
+	// Set up some additional settings for TTY commands
+	if client.settings.Config.Tty == true {
+
+		// set a default hostname to make a prettier prompt
+		if client.settings.Config.Hostname == "" {
+			client.settings.Config.Hostname = instance.Id()
+		}
+
+		// make sure that all tty runs have openstdin
+		client.settings.Config.OpenStdin = true
+	}
+
+	// piped input is closed when it ends, so that the command sees EOF
+	if options.Stdin {
+		client.settings.Config.OpenStdin = true
+		client.settings.Config.StdinOnce = true
+	}
+
+	// 1. get the container for the instance (create it if needed)
+	if !client.HasContainer() {
+		logger.Info("Creating new disposable RUN container")
+
+		if err := client.Create(hushedLogger, cmd, false); err != nil {
+			logger.Error("Could not create RUN container")
+			return -1, err
+		}
+
+		logger.Debug(log.VERBOSITY_DEBUG, "Created disposable run container")
+		if !options.Persistant {
+			// 3. [DEFERED] remove the container (if not instructed to keep it)
+			defer func(client *DockerCli_InstanceClient, hushedLogger log.Log) {
+				client.backend.Refresh(false, true)
+				client.Remove(hushedLogger, true)
+			}(client, hushedLogger)
+		}
+	} else {
+		logger.Info("Run container already exists")
+	}
+
+	// 2. start the container, attached to the terminal
+	args := []string{"start", "--attach"}
+	if options.Stdin && client.settings.Config.OpenStdin {
+		args = append(args, "--interactive")
+	}
+
+	// docker start --attach exits with the container exit code
+	logger.Info("Starting and attaching to RUN container")
+	if err := client.backend.Interactive(append(args, instance.MachineName())...); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return exitErr.ExitCode(), nil
+		}
+		logger.Error("RUN container failed => " + err.Error())
+		return -1, cliClientError("run", instance.MachineName(), err)
+	}
+	return 0, nil
+}
+
+// Docker binary stderr messages, and the kind of client error that each one means
+var dockerCliErrorMessages = []struct {
+	message string
+	kind    ClientErrorKind
+}{
+	{message: "Cannot connect to the Docker daemon", kind: ERROR_DAEMON_UNREACHABLE},
+	{message: "No such image", kind: ERROR_IMAGE_NOT_FOUND},
+	{message: "No such container", kind: ERROR_CONTAINER_NOT_FOUND},
+	{message: "No such volume", kind: ERROR_VOLUME_NOT_FOUND},
+	{message: "is already in use", kind: ERROR_CONTAINER_EXISTS},
+	{message: "is not running", kind: ERROR_NOT_RUNNING},
+}
+
+// Convert a docker binary error into a client error, using the messages that the docker binary wrote to stderr
+func cliClientError(action string, resource string, err error) error {
+	// the docker binary could not be run at all, which says nothing about the daemon
+	var missing *exec.Error
+	var notStarted *os.PathError
+	if errors.As(err, &missing) || errors.As(err, &notStarted) {
+		return NewClientError(ERROR_CLIENT_MISSING, action, resource, err)
+	}
+
+	stderr := ""
+	var commandError *dockerCliCommandError
+	var exitError *exec.ExitError
+	if errors.As(err, &commandError) {
+		stderr = commandError.stderr
+	} else if errors.As(err, &exitError) {
+		// commands run with Output() keep their stderr in the exit error
+		stderr = string(exitError.Stderr)
+	}
+
+	kind := ERROR_FAILED
+	for _, known := range dockerCliErrorMessages {
+		if strings.Contains(stderr, known.message) {
+			kind = known.kind
+			break
+		}
+	}
+	return NewClientError(kind, action, resource, err)
+}
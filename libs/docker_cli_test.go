@@ -0,0 +1,476 @@
+package libs
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	docker "github.com/fsouza/go-dockerclient"
+
+	"github.com/james-nesbitt/coach/conf"
+	"github.com/james-nesbitt/coach/log"
+)
+
+// A stub docker binary, which logs its arguments, and keeps track of the networks that it creates.  If
+// COACH_TEST_DOCKER_HIDDEN is set, networks are created by another client, and can't be inspected.
+const stubDockerScript = `#!/bin/sh
+echo "$*" >> "$COACH_TEST_DOCKER_LOG"
+for last; do :; done
+case "$*" in
+  *"network inspect"*)
+    [ -z "$COACH_TEST_DOCKER_HIDDEN" ] && [ -f "$COACH_TEST_DOCKER_LOG.$last" ] || exit 1
+    printf '{}\t0\n' ;;
+  *"network create"*)
+    if [ -n "$COACH_TEST_DOCKER_HIDDEN" ] || [ -f "$COACH_TEST_DOCKER_LOG.$last" ]; then
+      echo "Error response from daemon: network with name $last already exists" >&2
+      exit 1
+    fi
+    touch "$COACH_TEST_DOCKER_LOG.$last" ;;
+esac
+exit 0
+`
+
+// Make a project from a map of .coach files in a temporary project folder
+func makeTestProject(t *testing.T, logger log.Log, files map[string]string) *conf.Project {
+	root, err := ioutil.TempDir("", "coach-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(root) })
+
+	coachPath := path.Join(root, conf.COACH_PROJECT_CONF_FOLDER)
+	if err := os.Mkdir(coachPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, contents := range files {
+		if err := os.MkdirAll(path.Dir(path.Join(coachPath, name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path.Join(coachPath, name), []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return conf.MakeCoachProject(logger, root, conf.COACH_CONF_ENVIRONMENTS_DEFAULT)
+}
+
+// Put an executable script on the PATH, returning the folder that it is in
+func installTestBinary(t *testing.T, name string, script string) string {
+	binPath, err := ioutil.TempDir("", "coach-bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(binPath) })
+
+	if err := ioutil.WriteFile(path.Join(binPath, name), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binPath+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return binPath
+}
+
+// Put the stub docker binary on the PATH, returning the file that it logs its calls to
+func installStubDocker(t *testing.T) string {
+	binPath := installTestBinary(t, "docker", stubDockerScript)
+	logPath := path.Join(binPath, "calls.log")
+	t.Setenv("COACH_TEST_DOCKER_LOG", logPath)
+	return logPath
+}
+
+// The calls that the stub docker binary has logged
+func stubDockerCalls(t *testing.T, logPath string) []string {
+	contents, err := ioutil.ReadFile(logPath)
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	calls := []string{}
+	for _, line := range strings.Split(string(contents), "\n") {
+		if line != "" {
+			calls = append(calls, line)
+		}
+	}
+	return calls
+}
+
+func TestDockerCliCreateArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  docker.Config
+		host    docker.HostConfig
+		aliases []string
+		args    []string
+	}{
+		{
+			name:   "image only",
+			config: docker.Config{Image: "library/nginx"},
+			args:   []string{"create", "--name", "image_only", "library/nginx"},
+		},
+		{
+			name: "config",
+			config: docker.Config{
+				Image:        "library/nginx:1.19",
+				Hostname:     "www",
+				User:         "nginx",
+				WorkingDir:   "/app",
+				Tty:          true,
+				OpenStdin:    true,
+				Env:          []string{"A=1", "B=2"},
+				Labels:       map[string]string{"b": "2", "a": "1"},
+				ExposedPorts: map[docker.Port]struct{}{"80/tcp": {}},
+				Volumes:      map[string]struct{}{"/var/www": {}},
+				Cmd:          []string{"nginx", "-g", "daemon off;"},
+			},
+			args: []string{
+				"create", "--name", "config",
+				"--hostname", "www",
+				"--user", "nginx",
+				"--workdir", "/app",
+				"--tty",
+				"--interactive",
+				"--env", "A=1", "--env", "B=2",
+				"--label", "a=1", "--label", "b=2",
+				"--expose", "80/tcp",
+				"--volume", "/var/www",
+				"library/nginx:1.19", "nginx", "-g", "daemon off;",
+			},
+		},
+		{
+			name:   "host",
+			config: docker.Config{Image: "library/nginx"},
+			host: docker.HostConfig{
+				Binds: []string{"/src:/app"},
+				PortBindings: map[docker.Port][]docker.PortBinding{
+					"443/tcp": {{HostIP: "127.0.0.1", HostPort: "8443"}},
+				},
+				NetworkMode:   "project_default",
+				Privileged:    true,
+				Memory:        1024,
+				RestartPolicy: docker.RestartPolicy{Name: "on-failure", MaximumRetryCount: 3},
+			},
+			aliases: []string{"www", "www_1"},
+			args: []string{
+				"create", "--name", "host",
+				"--volume", "/src:/app",
+				"--publish", "127.0.0.1:8443:443/tcp",
+				"--network", "project_default",
+				"--network-alias", "www", "--network-alias", "www_1",
+				"--privileged",
+				"--memory", "1024",
+				"--restart", "on-failure:3",
+				"library/nginx",
+			},
+		},
+		// docker only allows network aliases on user defined networks
+		{
+			name:    "host network mode",
+			config:  docker.Config{Image: "library/nginx"},
+			host:    docker.HostConfig{NetworkMode: "host"},
+			aliases: []string{"www"},
+			args:    []string{"create", "--name", "host_network_mode", "--network", "host", "library/nginx"},
+		},
+		{
+			name:    "container network mode",
+			config:  docker.Config{Image: "library/nginx"},
+			host:    docker.HostConfig{NetworkMode: "container:db"},
+			aliases: []string{"www"},
+			args:    []string{"create", "--name", "container_network_mode", "--network", "container:db", "library/nginx"},
+		},
+		// map settings are passed in a stable order
+		{
+			name: "sorted",
+			config: docker.Config{
+				Image:        "library/nginx",
+				ExposedPorts: map[docker.Port]struct{}{"8080/tcp": {}, "443/tcp": {}, "80/tcp": {}},
+				Volumes:      map[string]struct{}{"/var/www": {}, "/var/log": {}},
+			},
+			host: docker.HostConfig{
+				PortBindings: map[docker.Port][]docker.PortBinding{
+					"80/tcp":  {{HostPort: "8080"}},
+					"443/tcp": {{HostPort: "8443"}, {HostIP: "127.0.0.1", HostPort: "9443"}},
+				},
+			},
+			args: []string{
+				"create", "--name", "sorted",
+				"--expose", "443/tcp", "--expose", "80/tcp", "--expose", "8080/tcp",
+				"--volume", "/var/log", "--volume", "/var/www",
+				"--publish", "8443:443/tcp", "--publish", "127.0.0.1:9443:443/tcp",
+				"--publish", "8080:80/tcp",
+				"library/nginx",
+			},
+		},
+		{
+			name:   "entrypoint",
+			config: docker.Config{Image: "library/php", Entrypoint: []string{"php", "-d", "memory_limit=-1"}, Cmd: []string{"index.php"}},
+			args:   []string{"create", "--name", "entrypoint", "--entrypoint", "php", "library/php", "-d", "memory_limit=-1", "index.php"},
+		},
+	}
+
+	for _, test := range tests {
+		name := strings.Replace(test.name, " ", "_", -1)
+		if args := dockerCliCreateArgs(name, test.config, test.host, test.aliases); !reflect.DeepEqual(args, test.args) {
+			t.Errorf("%s: create args\n got %q\nwant %q", test.name, args, test.args)
+		}
+	}
+}
+
+func TestDockerCliClientFactoryInvalidSettings(t *testing.T) {
+	logger := log.MakeCliLog("test", ioutil.Discard, log.VERBOSITY_MESSAGE)
+	project := makeTestProject(t, logger, map[string]string{"conf.yml": "Project: cliproject\n"})
+
+	factory := &DockerCli_ClientFactory{}
+	if factory.Init(logger, project, ClientFactorySettings(&Fake_ClientFactorySettings{})) {
+		t.Error("The docker CLI factory accepted fake client settings")
+	}
+}
+
+func TestCliClientError(t *testing.T) {
+	logger := log.MakeCliLog("test", ioutil.Discard, log.VERBOSITY_MESSAGE)
+	binPath := installTestBinary(t, "docker", `#!/bin/sh
+echo "$COACH_TEST_DOCKER_STDERR" >&2
+exit 1
+`)
+
+	wrapper := &DockerCli_Wrapper{}
+	if !wrapper.Init(logger, DockerCli_ClientFactorySettings{}) {
+		t.Fatal("Docker CLI wrapper failed to initialize")
+	}
+
+	tests := []struct {
+		stderr string
+		kind   ClientErrorKind
+	}{
+		{stderr: "Cannot connect to the Docker daemon at unix:///var/run/docker.sock. Is the docker daemon running?", kind: ERROR_DAEMON_UNREACHABLE},
+		{stderr: "Error: No such image: library/nginx:1.19", kind: ERROR_IMAGE_NOT_FOUND},
+		{stderr: "Error response from daemon: No such container: project_www", kind: ERROR_CONTAINER_NOT_FOUND},
+		{stderr: "Error: No such volume: project_data", kind: ERROR_VOLUME_NOT_FOUND},
+		{stderr: `Error response from daemon: Conflict. The container name "/project_www" is already in use by container "abc"`, kind: ERROR_CONTAINER_EXISTS},
+		{stderr: "Error response from daemon: Container abc is not running", kind: ERROR_NOT_RUNNING},
+		{stderr: "something else went wrong", kind: ERROR_FAILED},
+	}
+
+	for _, test := range tests {
+		t.Setenv("COACH_TEST_DOCKER_STDERR", test.stderr)
+
+		// commands that are run through the logger, and commands that are run for their output
+		if kind := ErrorKind(cliClientError("test", "www", wrapper.Run(logger, "start", "www"))); kind != test.kind {
+			t.Errorf("[%s]: run error kind %s, expected %s", test.stderr, kind, test.kind)
+		}
+		_, err := wrapper.Command("inspect", "www").Output()
+		if kind := ErrorKind(cliClientError("test", "www", err)); kind != test.kind {
+			t.Errorf("[%s]: output error kind %s, expected %s", test.stderr, kind, test.kind)
+		}
+	}
+
+	// a docker binary that can't be run says nothing about the daemon
+	if err := os.Remove(path.Join(binPath, "docker")); err != nil {
+		t.Fatal(err)
+	}
+	if kind := ErrorKind(cliClientError("test", "www", wrapper.Run(logger, "start", "www"))); kind != ERROR_CLIENT_MISSING {
+		t.Errorf("A missing docker binary gave error kind %s, expected %s", kind, ERROR_CLIENT_MISSING)
+	}
+	wrapper.binary = "coach-test-no-such-docker"
+	if kind := ErrorKind(cliClientError("test", "www", wrapper.Run(logger, "start", "www"))); kind != ERROR_CLIENT_MISSING {
+		t.Errorf("A docker binary that isn't on the PATH gave error kind %s, expected %s", kind, ERROR_CLIENT_MISSING)
+	}
+}
+
+func TestDockerCliClientFactoryMatch(t *testing.T) {
+	logger := log.MakeCliLog("test", ioutil.Discard, log.VERBOSITY_MESSAGE)
+	installStubDocker(t)
+	project := makeTestProject(t, logger, map[string]string{"conf.yml": "Project: cliproject\n"})
+
+	factory := &DockerCli_ClientFactory{}
+	if !factory.Init(logger, project, ClientFactorySettings(&DockerCli_ClientFactorySettings{})) {
+		t.Fatal("The docker CLI factory failed to initialize")
+	}
+
+	tests := []struct {
+		requirements FactoryMatchRequirements
+		match        bool
+	}{
+		{requirements: FactoryMatchRequirements{Type: "dockercli"}, match: true},
+		{requirements: FactoryMatchRequirements{Class: "DockerCli_ClientFactory"}, match: true},
+		{requirements: FactoryMatchRequirements{ID: factory.Id()}, match: true},
+		// nodes without a Client ask for docker, which is left to the fsouza client
+		{requirements: FactoryMatchRequirements{Type: "docker"}, match: false},
+		{requirements: FactoryMatchRequirements{Type: "fake"}, match: false},
+	}
+
+	for _, test := range tests {
+		if match := factory.Match(test.requirements); match != test.match {
+			t.Errorf("%+v: match %v, expected %v", test.requirements, match, test.match)
+		}
+	}
+}
+
+func TestDockerCliInstanceCreateStart(t *testing.T) {
+	logger := log.MakeCliLog("test", ioutil.Discard, log.VERBOSITY_MESSAGE)
+	logPath := installStubDocker(t)
+	project := makeTestProject(t, logger, map[string]string{
+		"conf.yml": "Project: cliproject\n",
+		"clients.yml": `
+cli:
+  Type: dockercli
+  Host: tcp://stub:2375
+  Config: /stub/config
+`,
+		"nodes.yml": `
+www:
+  Type: service
+  Client: cli
+  Docker:
+    Config:
+      Image: library/nginx:1.19
+`,
+	})
+
+	factories := MakeClientFactories(logger, project)
+	nodes := MakeNodes(logger, project, factories)
+	nodes.Prepare(logger)
+
+	node, ok := nodes.Node("www")
+	if !ok {
+		t.Fatal("The www node was not created")
+	}
+	instance, ok := node.Instances().Instance(INSTANCE_SINGLE_ID)
+	if !ok {
+		t.Fatal("The www node has no single instance")
+	}
+
+	if err := instance.Client().Create(logger, []string{}, false); err != nil {
+		t.Fatal("Create failed: ", err)
+	}
+	if err := instance.Client().Start(logger, false); err != nil {
+		t.Fatal("Start failed: ", err)
+	}
+
+	// every call carries the global arguments, and only the calls that change something are checked
+	global := "--config /stub/config --host tcp://stub:2375 "
+	changes := []string{}
+	for _, call := range stubDockerCalls(t, logPath) {
+		if !strings.HasPrefix(call, global) {
+			t.Errorf("Docker call is missing the global arguments: %s", call)
+			continue
+		}
+		call = strings.TrimPrefix(call, global)
+		for _, command := range []string{"network create", "create", "start"} {
+			if strings.HasPrefix(call, command+" ") {
+				changes = append(changes, call)
+				break
+			}
+		}
+	}
+
+	// the project network is created before the container joins it
+	labels := "--label coach.environment=default --label coach.instance=single --label coach.node=www --label coach.project=cliproject"
+	expected := []string{
+		"network create --driver bridge --label coach.environment=default --label coach.project=cliproject cliproject_default",
+		"create --name cliproject_www " + labels + " --network cliproject_default --network-alias www library/nginx:1.19",
+		"start cliproject_www",
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("Unexpected network create, create and start calls:\n got %q\nwant %q", changes, expected)
+	}
+}
+
+func TestDockerCliBuildOptions(t *testing.T) {
+	logger := log.MakeCliLog("test", ioutil.Discard, log.VERBOSITY_MESSAGE)
+	logPath := installStubDocker(t)
+	project := makeTestProject(t, logger, map[string]string{
+		"conf.yml":    "Project: cliproject\n",
+		"clients.yml": "cli:\n  Type: dockercli\n",
+		"nodes.yml": `
+app:
+  Type: build
+  Client: cli
+  Docker:
+    Build: app
+    Dockerfile: Dockerfile.dev
+    Target: dev
+    Pull: true
+    BuildArgs:
+      VERSION: "2"
+      DEBUG: "1"
+    Labels:
+      team: web
+    Config:
+      Image: cliproject/app:dev
+`,
+		"app/Dockerfile.dev": "FROM scratch\n",
+	})
+
+	factories := MakeClientFactories(logger, project)
+	nodes := MakeNodes(logger, project, factories)
+	nodes.Prepare(logger)
+
+	node, ok := nodes.Node("app")
+	if !ok {
+		t.Fatal("The app node was not created")
+	}
+	if err := node.Client().Build(logger, false, BuildOptions{NoCache: true}); err != nil {
+		t.Fatal("Build failed: ", err)
+	}
+
+	buildPath := project.Paths.GetConfSubPaths("app")[0]
+	expected := "build --rm --tag cliproject/app:dev --file " + path.Join(buildPath, "Dockerfile.dev") + " --no-cache --pull --target dev --build-arg DEBUG=1 --build-arg VERSION=2 --label coach.environment=default --label coach.node=app --label coach.project=cliproject --label team=web " + buildPath
+	builds := []string{}
+	for _, call := range stubDockerCalls(t, logPath) {
+		if strings.HasPrefix(call, "build ") {
+			builds = append(builds, call)
+		}
+	}
+	if len(builds) != 1 || builds[0] != expected {
+		t.Errorf("Unexpected build calls:\n got %q\nwant %q", builds, expected)
+	}
+}
+
+func TestDockerCliEnsureNetwork(t *testing.T) {
+	logger := log.MakeCliLog("test", ioutil.Discard, log.VERBOSITY_MESSAGE)
+	logPath := installStubDocker(t)
+
+	wrapper := &DockerCli_Wrapper{}
+	if !wrapper.Init(logger, DockerCli_ClientFactorySettings{}) {
+		t.Fatal("Docker CLI wrapper failed to initialize")
+	}
+
+	// nodes that are created in parallel all ensure the project network, but it is only created once
+	createdCount := 0
+	var lock sync.Mutex
+	var wait sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			created, err := wrapper.EnsureNetwork(logger, "project_default", map[string]string{COACH_LABEL_PROJECT: "project"})
+			if err != nil {
+				t.Error("EnsureNetwork failed:", err)
+			}
+			if created {
+				lock.Lock()
+				createdCount++
+				lock.Unlock()
+			}
+		}()
+	}
+	wait.Wait()
+
+	creates := []string{}
+	for _, call := range stubDockerCalls(t, logPath) {
+		if strings.HasPrefix(call, "network create ") {
+			creates = append(creates, call)
+		}
+	}
+	if createdCount != 1 || len(creates) != 1 {
+		t.Errorf("Expected the network to be created once, was reported created %d times, with calls %q", createdCount, creates)
+	}
+
+	// a network that another client created after it was inspected already exists, which is not an error
+	t.Setenv("COACH_TEST_DOCKER_HIDDEN", "1")
+	if created, err := wrapper.EnsureNetwork(logger, "shared", map[string]string{}); err != nil || created {
+		t.Errorf("Expected an existing network to be accepted, got created %v, err %v", created, err)
+	}
+}
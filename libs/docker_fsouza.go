@@ -16,12 +16,19 @@ package libs
  */
 
 import (
+	"bytes"
 	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
 	"os"
 	"path"
 	"strconv"
 	"strings"
+	"sync"
 	"text/tabwriter"
+	"time"
 
 	"encoding/json"
 
@@ -32,11 +39,11 @@ import (
 )
 
 var (
-	actionCache map[string]bool
+	actionCache *ActionCache // actions shared between nodes, such as image pulls
 )
 
 func init() {
-	actionCache = map[string]bool{}
+	actionCache = MakeActionCache()
 }
 
 /**
@@ -99,6 +106,9 @@ func (clientFactory *FSouza_ClientFactory) Init(logger log.Log, project *conf.Pr
 // Actually build a Client-Wrapper object, one per factory, which makes sense because that is where the settings are
 func (clientFactory *FSouza_ClientFactory) makeFsouzaClientWrapper(logger log.Log) (*FSouza_Wrapper, bool) {
 	wrapper := &FSouza_Wrapper{}
+	if clientFactory.conf != nil {
+		wrapper.project = clientFactory.conf.Name
+	}
 	return wrapper, wrapper.Init(logger, clientFactory.settings)
 }
 
@@ -123,8 +133,31 @@ type FSouza_ClientSettings struct {
 
 	BuildPath string `json:"Build,omitempty" yaml:"Build,omitempty"`
 
+	BuildArgs   map[string]string `json:"BuildArgs,omitempty" yaml:"BuildArgs,omitempty"`
+	Dockerfile  string            `json:"Dockerfile,omitempty" yaml:"Dockerfile,omitempty"`
+	NoCache     bool              `json:"NoCache,omitempty" yaml:"NoCache,omitempty"`
+	BuildPull   bool              `json:"Pull,omitempty" yaml:"Pull,omitempty"`
+	BuildTarget string            `json:"Target,omitempty" yaml:"Target,omitempty"`
+	BuildLabels map[string]string `json:"Labels,omitempty" yaml:"Labels,omitempty"`
+
+	Registry string              `json:"Registry,omitempty" yaml:"Registry,omitempty"`
+	Auth     FSouza_AuthSettings `json:"Auth,omitempty" yaml:"Auth,omitempty"`
+
 	Config docker.Config     `json:"Config,omitempty" yaml:"Config,omitempty"`
 	Host   docker.HostConfig `json:"Host,omitempty" yaml:"Host,omitempty"`
+
+	Networks []string `json:"Networks,omitempty" yaml:"Networks,omitempty"`
+
+	Volume *FSouza_VolumeSettings `json:"Volume,omitempty" yaml:"Volume,omitempty"`
+
+	Healthcheck *FSouza_HealthcheckSettings `json:"Healthcheck,omitempty" yaml:"Healthcheck,omitempty"`
+}
+
+// Named volume settings, for volume nodes that keep their data in a docker volume
+type FSouza_VolumeSettings struct {
+	Driver  string            `json:"Driver,omitempty" yaml:"Driver,omitempty"`
+	Options map[string]string `json:"Options,omitempty" yaml:"Options,omitempty"`
+	Labels  map[string]string `json:"Labels,omitempty" yaml:"Labels,omitempty"`
 }
 
 func (settings *FSouza_ClientSettings) Init(logger log.Log, project *conf.Project) bool {
@@ -148,6 +181,14 @@ func (settings *FSouza_ClientSettings) Prepare(logger log.Log, nodes *Nodes) boo
 			binds = strings.SplitN(bind, ":", 3)
 			bindRoot := binds[0] // this is the bind source
 
+			// a bind source can be a named volume node, which is mapped to the docker volume
+			if node, ok := nodes.Node(bindRoot); ok && node.Can("volume") {
+				settings.dependencies.SetDependency(bindRoot, Dependency(&NodeDependency{Node: node}))
+				binds[0] = NodeVolumeName(node)
+				settings.Host.Binds[index] = strings.Join(binds, ":")
+				continue
+			}
+
 			if path.IsAbs(bindRoot) {
 				continue
 			} else if bindRoot[0:1] == "~" { // one would think that path can handle such terminology
@@ -177,6 +218,9 @@ func (settings *FSouza_ClientSettings) Prepare(logger log.Log, nodes *Nodes) boo
 	// build dependencies by looking at the Host Links and VolumesFrom lists
 	settings.dependenciesFromConfig(logger, nodes, settings.Host.Links)
 	settings.dependenciesFromConfig(logger, nodes, settings.Host.VolumesFrom)
+	if strings.HasPrefix(settings.Host.NetworkMode, "container:") {
+		settings.dependenciesFromConfig(logger, nodes, []string{settings.Host.NetworkMode[len("container:"):]})
+	}
 
 	return true
 }
@@ -196,7 +240,7 @@ func (settings *FSouza_ClientSettings) dependenciesFromConfig(logger log.Log, no
 func (settings *FSouza_ClientSettings) Settings() interface{} {
 	return settings
 }
-func (settings *FSouza_ClientSettings) nodeSettings(client *FSouza_Client, node Node) FSouza_ClientSettings {
+func (settings *FSouza_ClientSettings) nodeSettings(node Node) FSouza_ClientSettings {
 	tokens := conf.Tokens{}
 	tokens.SetToken("NODE", node.Id())
 	tokens.SetToken("NODEMACHINE", node.MachineName())
@@ -204,10 +248,10 @@ func (settings *FSouza_ClientSettings) nodeSettings(client *FSouza_Client, node
 
 	return copy
 }
-func (settings *FSouza_ClientSettings) instancesSettings(client *FSouza_Client, instances Instances) FSouza_ClientSettings {
+func (settings *FSouza_ClientSettings) instancesSettings(instances Instances) FSouza_ClientSettings {
 	return settings.copy(nil)
 }
-func (settings *FSouza_ClientSettings) instanceSettings(client *FSouza_Client, instance Instance) FSouza_ClientSettings {
+func (settings *FSouza_ClientSettings) instanceSettings(instance Instance) FSouza_ClientSettings {
 	tokens := conf.Tokens{}
 	tokens.SetToken("INSTANCE", instance.Id())
 	tokens.SetToken("INSTANCEMACHINE", instance.MachineName())
@@ -273,7 +317,8 @@ type FSouza_Client struct {
 	conf     *conf.Project
 	backend  *FSouza_Wrapper
 
-	id string
+	id     string
+	nodeId string
 }
 
 type FSouza_NodeClient struct {
@@ -285,7 +330,7 @@ type FSouza_NodeClient struct {
 func (nodeClient *FSouza_NodeClient) Init(client *FSouza_Client, node Node) {
 	nodeClient.FSouza_Client = client
 	nodeClient.node = node
-	nodeClient.settings = client.settings.nodeSettings(client, node)
+	nodeClient.settings = client.settings.nodeSettings(node)
 }
 
 type FSouza_InstancesClient struct {
@@ -297,7 +342,7 @@ type FSouza_InstancesClient struct {
 func (instancesClient *FSouza_InstancesClient) Init(client *FSouza_Client, instances Instances) {
 	instancesClient.FSouza_Client = client
 	instancesClient.instances = instances
-	instancesClient.settings = client.settings.instancesSettings(client, instances)
+	instancesClient.settings = client.settings.instancesSettings(instances)
 }
 
 type FSouza_InstanceClient struct {
@@ -309,7 +354,7 @@ type FSouza_InstanceClient struct {
 func (instanceClient *FSouza_InstanceClient) Init(client *FSouza_Client, instance Instance) {
 	instanceClient.FSouza_Client = client
 	instanceClient.instance = instance
-	instanceClient.settings = client.settings.instanceSettings(client, instance)
+	instanceClient.settings = client.settings.instanceSettings(instance)
 }
 
 func (client *FSouza_Client) Init(logger log.Log, project *conf.Project, settings ClientSettings) bool {
@@ -330,6 +375,7 @@ func (client *FSouza_Client) Init(logger log.Log, project *conf.Project, setting
 }
 func (client *FSouza_Client) Prepare(logger log.Log, nodes *Nodes, node Node) bool {
 	client.id = node.MachineName()
+	client.nodeId = node.Id()
 
 	// the settings object has it's own Prepare
 	client.settings.Prepare(logger, nodes)
@@ -353,12 +399,22 @@ func (client *FSouza_Client) InstanceClient(instance Instance) InstanceClient {
 	return InstanceClient(instanceClient)
 }
 
+// The coach labels used to stamp and match client resources, for the node or one of its instances
+func (client *FSouza_Client) labels(instanceId string) map[string]string {
+	return CoachLabels(client.conf, client.nodeId, instanceId)
+}
+func (client *FSouza_Client) matchLabels(instanceId string) map[string]string {
+	return CoachMatchLabels(client.conf, client.nodeId, instanceId)
+}
+
 func (client *FSouza_Client) Can(action string) bool {
 	switch action {
 	case "build":
 		return client.settings.BuildPath != ""
 	case "pull":
 		return client.settings.BuildPath == "" && client.settings.Config.Image != ""
+	case "volume":
+		return client.settings.Volume != nil
 	default:
 		return true
 	}
@@ -376,16 +432,47 @@ func (client *FSouza_Client) DependsOn(target string) bool {
 
 type FSouza_Wrapper struct {
 	*docker.Client
+	settings FSouza_ClientFactorySettings
 
+	cacheLock        sync.RWMutex // nodes may be processed concurrently
 	cachedImages     []docker.APIImages
 	cachedContainers []docker.APIContainers
+	staleImages      bool // the images have changed since they were cached
+	staleContainers  bool // the containers have changed since they were cached
+
+	watchOnce sync.Once // the docker events are watched once the caches are first used
+	project   string    // the project name, used to watch only the project events
+
+	networkLock sync.Mutex // networks are checked and created by one node at a time
 }
 
 // Init constructor for the client wrapper
 func (wrapper *FSouza_Wrapper) Init(logger log.Log, settings FSouza_ClientFactorySettings) bool {
+	logger.Debug(log.VERBOSITY_DEBUG_WOAH, "Docker client conf: ", settings)
+	wrapper.settings = settings
+
+	client, err := makeFsouzaDockerClient(settings)
+	if err == nil {
+		logger.Debug(log.VERBOSITY_DEBUG_WOAH, "FSouza Docker client created:", client)
+		wrapper.Client = client
+		return true
+	} else {
+		logger.Error(err.Error())
+		return false
+	}
+}
+
+// A new docker client with its own event stream.  A docker client shares a single event
+// stream between all of its listeners, using the options of the first listener, so each
+// filtered stream needs its own client.
+func (wrapper *FSouza_Wrapper) EventsClient() (*docker.Client, error) {
+	return makeFsouzaDockerClient(wrapper.settings)
+}
+
+// Create an FSouza docker client from the client factory settings
+func makeFsouzaDockerClient(settings FSouza_ClientFactorySettings) (*docker.Client, error) {
 	var client *docker.Client
 	var err error
-	logger.Debug(log.VERBOSITY_DEBUG_WOAH, "Docker client conf: ", settings)
 
 	if strings.HasPrefix(settings.Host, "tcp://") {
 
@@ -408,20 +495,47 @@ func (wrapper *FSouza_Wrapper) Init(logger log.Log, settings FSouza_ClientFactor
 	} else {
 		err = errors.New("Unknown client host :" + settings.Host)
 	}
+	return client, err
+}
 
-	if err == nil {
-		logger.Debug(log.VERBOSITY_DEBUG_WOAH, "FSouza Docker client created:", client)
-		wrapper.Client = client
-		return true
-	} else {
-		logger.Error(err.Error())
-		return false
+// Mark the cached images and/or containers as changed, so that they are reloaded when they are next used
+func (wrapper *FSouza_Wrapper) Invalidate(images bool, containers bool) {
+	wrapper.cacheLock.Lock()
+	defer wrapper.cacheLock.Unlock()
+	wrapper.staleImages = wrapper.staleImages || images
+	wrapper.staleContainers = wrapper.staleContainers || containers
+}
+
+// Watch the docker events for the project, so that changes made outside of coach (or by
+// other coach processes) invalidate the cached images and containers.  Coach actions
+// invalidate the caches themselves, as an event may arrive after the next cache read.
+func (wrapper *FSouza_Wrapper) watchEvents() {
+	listener := make(chan *docker.APIEvents, 100)
+	options := docker.EventsOptions{}
+	if wrapper.project != "" {
+		options.Filters = map[string][]string{"label": []string{COACH_LABEL_PROJECT + "=" + wrapper.project}}
+	}
+	if err := wrapper.AddEventListenerWithOptions(options, listener); err != nil {
+		// without events, only coach actions invalidate the caches
+		return
 	}
+
+	go func() {
+		for event := range listener {
+			switch event.Type {
+			case "image":
+				wrapper.Invalidate(true, false)
+			case "container", "":
+				wrapper.Invalidate(event.Action == "commit", true)
+			}
+		}
+	}()
 }
 
 // Reload all of the client images and/or containers from the remote client
 func (wrapper *FSouza_Wrapper) Refresh(refreshImages bool, refreshContainers bool) error {
 	var err error
+	wrapper.watchOnce.Do(wrapper.watchEvents)
 
 	if refreshImages {
 		filters := map[string][]string{}
@@ -430,7 +544,17 @@ func (wrapper *FSouza_Wrapper) Refresh(refreshImages bool, refreshContainers boo
 		options := docker.ListImagesOptions{
 			Filters: filters,
 		}
-		wrapper.cachedImages, err = wrapper.ListImages(options)
+		// cleared before listing, so that a change during the listing is not lost
+		wrapper.cacheLock.Lock()
+		wrapper.staleImages = false
+		wrapper.cacheLock.Unlock()
+
+		var images []docker.APIImages
+		images, err = wrapper.ListImages(options)
+
+		wrapper.cacheLock.Lock()
+		wrapper.cachedImages = images
+		wrapper.cacheLock.Unlock()
 	}
 	if refreshContainers {
 		filters := map[string][]string{}
@@ -441,7 +565,16 @@ func (wrapper *FSouza_Wrapper) Refresh(refreshImages bool, refreshContainers boo
 			All:     true,
 			Filters: filters,
 		}
-		wrapper.cachedContainers, err = wrapper.ListContainers(options)
+		wrapper.cacheLock.Lock()
+		wrapper.staleContainers = false
+		wrapper.cacheLock.Unlock()
+
+		var containers []docker.APIContainers
+		containers, err = wrapper.ListContainers(options)
+
+		wrapper.cacheLock.Lock()
+		wrapper.cachedContainers = containers
+		wrapper.cacheLock.Unlock()
 	}
 
 	return err
@@ -450,21 +583,30 @@ func (wrapper *FSouza_Wrapper) Refresh(refreshImages bool, refreshContainers boo
 // Return a list of all of the images registered on the client
 func (wrapper *FSouza_Wrapper) AllImages(refresh bool) ([]docker.APIImages, error) {
 	var err error
-	if refresh || wrapper.cachedImages == nil {
+	wrapper.cacheLock.RLock()
+	images := wrapper.cachedImages
+	stale := wrapper.staleImages
+	wrapper.cacheLock.RUnlock()
+
+	if refresh || stale || images == nil {
 		err = wrapper.Refresh(true, false)
+
+		wrapper.cacheLock.RLock()
+		images = wrapper.cachedImages
+		wrapper.cacheLock.RUnlock()
 	}
-	return wrapper.cachedImages, err
+	return images, err
 }
 
-// Return a list of remote images that have a specific string prefix repo tag
-func (wrapper *FSouza_Wrapper) MatchImages(prefix string) ([]docker.APIImages, error) {
-	prefix = strings.ToLower(prefix)
+// Return a list of remote images that have a specific repo tag
+func (wrapper *FSouza_Wrapper) MatchImages(name string) ([]docker.APIImages, error) {
+	name = strings.ToLower(name)
 	images, err := wrapper.AllImages(false)
 	filteredImages := []docker.APIImages{}
 	for _, image := range images {
 	eachimage:
 		for _, tag := range image.RepoTags {
-			if strings.HasPrefix(tag, prefix) {
+			if tag == name {
 				filteredImages = append(filteredImages, image)
 				continue eachimage
 			}
@@ -476,12 +618,24 @@ func (wrapper *FSouza_Wrapper) MatchImages(prefix string) ([]docker.APIImages, e
 // Return a list of all of the containers registered on the client
 func (wrapper *FSouza_Wrapper) AllContainers(refresh bool) ([]docker.APIContainers, error) {
 	var err error
-	if refresh || wrapper.cachedContainers == nil {
+	wrapper.cacheLock.RLock()
+	containers := wrapper.cachedContainers
+	stale := wrapper.staleContainers
+	wrapper.cacheLock.RUnlock()
+
+	if refresh || stale || containers == nil {
 		err = wrapper.Refresh(false, true)
+
+		wrapper.cacheLock.RLock()
+		containers = wrapper.cachedContainers
+		wrapper.cacheLock.RUnlock()
 	}
-	return wrapper.cachedContainers, err
+	return containers, err
 }
-func (wrapper *FSouza_Wrapper) MatchContainers(prefix string, running bool) ([]docker.APIContainers, error) {
+
+// Return a list of remote containers that carry all of the passed coach labels.  Containers
+// without any coach labels are matched by name, either exactly or as a "{machineName}_" prefix
+func (wrapper *FSouza_Wrapper) MatchContainers(labels map[string]string, machineName string, prefix bool, running bool) ([]docker.APIContainers, error) {
 	containers, err := wrapper.AllContainers(false)
 	filteredContainers := []docker.APIContainers{}
 	for _, container := range containers {
@@ -489,27 +643,43 @@ func (wrapper *FSouza_Wrapper) MatchContainers(prefix string, running bool) ([]d
 			continue
 		}
 
-	eachcontainer:
-
-		for _, containerName := range container.Names {
-			/**
-			 * Remember that container names also include names used for mapping/linking
-			 * into other containers.  A container example_db linked into a container
-			 * example_fpm as db.app will also have a name /example_fpm/db.app.
-			 * We test for link names by counting the number of "/" characters in the container
-			 * name.
-			 *
-			 * @TODO is this correct approach.
-			 */
-			if strings.Count(containerName, "/")<2 && strings.HasPrefix(containerName, "/"+prefix) {
+		if HasCoachLabels(container.Labels) {
+			if MatchCoachLabels(container.Labels, labels) {
 				filteredContainers = append(filteredContainers, container)
-				continue eachcontainer
 			}
+		} else if _, ok := legacyContainerNameMatch(container.Names, machineName, prefix); ok {
+			filteredContainers = append(filteredContainers, container)
 		}
 	}
 	return filteredContainers, err
 }
 
+// Make sure that a network exists, creating it if it doesn't
+func (wrapper *FSouza_Wrapper) EnsureNetwork(name string, labels map[string]string) (created bool, err error) {
+	wrapper.networkLock.Lock()
+	defer wrapper.networkLock.Unlock()
+
+	if _, err = wrapper.NetworkInfo(name); err == nil {
+		return false, nil
+	} else if _, missing := err.(*docker.NoSuchNetwork); !missing {
+		return false, err
+	}
+
+	options := docker.CreateNetworkOptions{
+		Name:           name,
+		Driver:         "bridge",
+		Labels:         labels,
+		CheckDuplicate: true,
+	}
+	_, err = wrapper.CreateNetwork(options)
+
+	// the network may have been created by another docker client since it was inspected
+	if apiErr, conflict := err.(*docker.Error); (conflict && apiErr.Status == http.StatusConflict) || err == docker.ErrNetworkAlreadyExists {
+		return false, nil
+	}
+	return err == nil, err
+}
+
 /**
  * NodeClient meta-methods
  */
@@ -521,30 +691,34 @@ func (client *FSouza_Client) GetImageName() (image, tag string) {
 	if client.settings.Config.Image == "" {
 		return
 	}
-	if strings.Contains(client.settings.Config.Image, ":") {
-		split := strings.SplitN(client.settings.Config.Image, ":", 2)
-		image = split[0]
-		tag = split[1]
-	} else {
-		image = client.settings.Config.Image
+	image, tag = dockerSplitImageTag(client.settings.Config.Image)
+	if tag == "" {
+		tag = "latest"
 	}
 	return
 }
 
 func (client *FSouza_NodeClient) Images() []docker.APIImages {
-	matchString := client.node.MachineName()
-	if client.settings.Config.Image != "" {
-		matchString = client.settings.Config.Image
-	}
+	image, tag := client.GetImageName()
 
-	images, _ := client.backend.MatchImages(matchString)
+	images, _ := client.backend.MatchImages(image + ":" + tag)
 	return images
 }
 func (client *FSouza_NodeClient) HasImage() bool {
 	return len(client.Images()) > 0
 }
 
+func (client *FSouza_NodeClient) HasVolume() bool {
+	_, err := client.backend.InspectVolume(NodeVolumeName(client.node))
+	return err == nil
+}
+
 func (client *FSouza_NodeClient) NodeInfo(logger log.Log) {
+	if client.settings.Volume != nil {
+		client.VolumeInfo(logger)
+		return
+	}
+
 	images := client.Images()
 
 	if len(images) == 0 {
@@ -586,6 +760,58 @@ func (client *FSouza_NodeClient) NodeInfo(logger log.Log) {
 	}
 }
 
+func (client *FSouza_NodeClient) Report() NodeReport {
+	report := NodeReport{}
+
+	if client.settings.Volume != nil {
+		if volume, err := client.backend.InspectVolume(NodeVolumeName(client.node)); err == nil {
+			report.Volume = &VolumeReport{Name: volume.Name, Driver: volume.Driver, Mountpoint: volume.Mountpoint}
+		}
+		return report
+	}
+
+	for _, image := range client.Images() {
+		report.Images = append(report.Images, ImageReport{
+			ID:      image.ID,
+			Tags:    image.RepoTags,
+			Created: time.Unix(image.Created, 0).Format(time.RFC3339),
+		})
+	}
+	report.HasImage = len(report.Images) > 0
+	return report
+}
+
+func (client *FSouza_NodeClient) VolumeInfo(logger log.Log) {
+	name := NodeVolumeName(client.node)
+
+	volume, err := client.backend.InspectVolume(name)
+	if err != nil {
+		logger.Message("|-- no volume [" + name + "]")
+		return
+	}
+
+	logger.Message("|-> Volume")
+
+	w := new(tabwriter.Writer)
+	w.Init(logger, 8, 12, 2, ' ', 0)
+
+	w.Write([]byte(strings.Join([]string{"|=", "Name", "Driver", "Mountpoint", "Options"}, "\t") + "\n"))
+
+	options := []string{}
+	for _, key := range dockerSortedKeys(volume.Options) {
+		options = append(options, key+"="+volume.Options[key])
+	}
+	row := []string{
+		"|-",
+		volume.Name,
+		volume.Driver,
+		volume.Mountpoint,
+		strings.Join(options, ","),
+	}
+	w.Write([]byte(strings.Join(row, "\t") + "\n"))
+	w.Flush()
+}
+
 /**
  * InstancesInfo Interface
  */
@@ -643,7 +869,7 @@ func (client *FSouza_InstancesClient) InstancesInfo(logger log.Log) {
 				row = append(row, "no")
 			}
 
-			containers, _ := client.backend.MatchContainers(machineName, false)
+			containers, _ := client.backend.MatchContainers(client.matchLabels(instance.Id()), machineName, false, false)
 			for _, container := range containers {
 				row = append(row,
 					container.Status,
@@ -662,20 +888,17 @@ func (client *FSouza_InstancesClient) InstancesInfo(logger log.Log) {
 }
 
 func (client *FSouza_InstancesClient) InstancesFound(logger log.Log) []string {
-	instancesMachineNamePrefix := "/"+client.instances.MachineName()
-	instancesMachineNamePrefixLength := len(instancesMachineNamePrefix)+1 // the +1 comes from the underscore spacer
-
 	ids := []string{}
 	for _, container := range client.Containers(false) {
-		for _, name := range container.Names {
-			if strings.HasPrefix(name, instancesMachineNamePrefix) {
-				if len(name)>instancesMachineNamePrefixLength {
-					ids = append(ids, name[instancesMachineNamePrefixLength:])
-				} else {
-					ids = append(ids, "single")
-				}
-				break
+		if HasCoachLabels(container.Labels) {
+			if id, ok := container.Labels[COACH_LABEL_INSTANCE]; ok {
+				ids = append(ids, id)
 			}
+		} else if id, ok := legacyContainerNameMatch(container.Names, client.instances.MachineName(), true); ok {
+			if id == "" {
+				id = INSTANCE_SINGLE_ID
+			}
+			ids = append(ids, id)
 		}
 	}
 	return ids
@@ -691,7 +914,7 @@ func (client *FSouza_InstancesClient) Containers(running bool) []docker.APIConta
 	if matchString == INSTANCES_NULL_MACHINENAME {
 		return []docker.APIContainers{}
 	} else {
-		containers, _ := client.backend.MatchContainers(matchString, running)
+		containers, _ := client.backend.MatchContainers(client.matchLabels(""), matchString, true, running)
 		return containers
 	}
 }
@@ -701,7 +924,7 @@ func (client *FSouza_InstanceClient) Containers(running bool) []docker.APIContai
 	if matchString == INSTANCES_NULL_MACHINENAME {
 		return []docker.APIContainers{}
 	} else {
-		containers, _ := client.backend.MatchContainers(matchString, running)
+		containers, _ := client.backend.MatchContainers(client.matchLabels(instance.Id()), matchString, false, running)
 		return containers
 	}
 }
@@ -711,22 +934,134 @@ func (client *FSouza_InstanceClient) HasContainer() bool {
 func (client *FSouza_InstanceClient) IsRunning() bool {
 	return len(client.Containers(true)) > 0
 }
+func (client *FSouza_InstanceClient) Report() InstanceReport {
+	report := InstanceReport{Container: client.instance.MachineName()}
+	for _, container := range client.Containers(false) {
+		report.HasContainer = true
+		report.Running = strings.Contains(container.Status, "Up")
+		report.ID = container.ID
+		report.Image = container.Image
+		report.State = container.State
+		report.Status = container.Status
+		report.Created = time.Unix(container.Created, 0).Format(time.RFC3339)
+		for _, port := range container.Ports {
+			published := strconv.FormatInt(port.PrivatePort, 10) + "/" + port.Type
+			if port.PublicPort != 0 {
+				published = port.IP + ":" + strconv.FormatInt(port.PublicPort, 10) + "->" + published
+			}
+			report.Ports = append(report.Ports, published)
+		}
+		break
+	}
+	return report
+}
+func (client *FSouza_InstanceClient) Inspect(logger log.Log) (InstanceInspect, error) {
+	instance := client.instance
+
+	image, tag := client.GetImageName()
+	if tag != "" && tag != "latest" {
+		image += ":" + tag
+	}
+	inspect := makeInstanceInspect(client.conf, client.settings, client.nodeId, instance, image, MergeCoachLabels(client.settings.Config.Labels, client.labels(instance.Id())))
+
+	if client.HasContainer() {
+		container, err := client.backend.InspectContainer(instance.MachineName())
+		if err != nil {
+			logger.Error("Failed to inspect instance container [" + instance.MachineName() + "] => " + err.Error())
+			return inspect, fsouzaClientError("inspect", instance.MachineName(), err)
+		}
+		inspect.Container = container
+	}
+	return inspect, nil
+}
+func (client *FSouza_InstanceClient) IsReady() bool {
+	ready, _ := client.readyCheck()
+	return ready
+}
+func (client *FSouza_InstanceClient) WaitReady(logger log.Log) error {
+	return waitForReady(logger, client.instance.MachineName(), client.settings.Healthcheck, client.readyCheck)
+}
+
+// Run the instance health checks once, returning a reason if the instance is not ready
+func (client *FSouza_InstanceClient) readyCheck() (bool, string) {
+	healthcheck := client.settings.Healthcheck
+	if healthcheck == nil {
+		// without health checks, an instance is ready once it has a container
+		return client.HasContainer(), "instance has no container"
+	}
+
+	id := client.instance.MachineName()
+	container, err := client.backend.InspectContainer(id)
+	if err != nil {
+		return false, err.Error()
+	}
+	if !container.State.Running {
+		return false, "container is not running"
+	}
+
+	if healthcheck.Docker && container.State.Health.Status != "healthy" {
+		return false, "docker health status is [" + container.State.Health.Status + "]"
+	}
+	if healthcheck.Port > 0 {
+		if ready, reason := healthcheckPort(client.backend.Endpoint(), healthcheck.Port, container.NetworkSettings); !ready {
+			return false, reason
+		}
+	}
+	if len(healthcheck.Command) > 0 {
+		exec, err := client.backend.CreateExec(docker.CreateExecOptions{
+			Container:    id,
+			Cmd:          healthcheck.Command,
+			AttachStdout: true,
+			AttachStderr: true,
+		})
+		if err == nil {
+			err = client.backend.StartExec(exec.ID, docker.StartExecOptions{OutputStream: ioutil.Discard, ErrorStream: ioutil.Discard})
+		}
+		if err != nil {
+			return false, "health check command failed => " + err.Error()
+		}
+		if inspect, err := client.backend.InspectExec(exec.ID); err != nil {
+			return false, "health check command failed => " + err.Error()
+		} else if inspect.ExitCode != 0 {
+			return false, "health check command exited with code " + strconv.Itoa(inspect.ExitCode)
+		}
+	}
+	if healthcheck.Log != "" {
+		var logs bytes.Buffer
+		err := client.backend.Logs(docker.LogsOptions{
+			Container:    id,
+			OutputStream: &logs,
+			ErrorStream:  &logs,
+			Stdout:       true,
+			Stderr:       true,
+			RawTerminal:  container.Config != nil && container.Config.Tty,
+		})
+		if err != nil {
+			return false, "could not read container logs => " + err.Error()
+		}
+		if ready, reason := healthcheckLog(healthcheck.Log, logs.String()); !ready {
+			return false, reason
+		}
+	}
+
+	return true, ""
+}
 
 /**
  * NodeClient interface: Operation Methods
  */
 
-func (client *FSouza_NodeClient) Build(logger log.Log, force bool) bool {
+func (client *FSouza_NodeClient) Build(logger log.Log, force bool, buildOptions BuildOptions) error {
 	image, tag := client.GetImageName()
 
 	if client.settings.BuildPath == "" {
 		logger.Warning("Node image [" + image + ":" + tag + "] not built as an empty path was provided.  You must point Build: to a path inside .coach")
-		return false
+		return NewClientError(ERROR_INVALID, "build", image+":"+tag, errors.New("no build path"))
 	}
 
 	if !force && client.HasImage() {
 		logger.Info("Node image [" + image + ":" + tag + "] not built as an image already exists.  You can force this operation to build this image")
-		return false
+		return NewClientError(ERROR_IMAGE_EXISTS, "build", image+":"+tag, nil)
 	}
 
 	// determine an absolute buildPath to the build, for Docker to use.
@@ -747,6 +1082,15 @@ func (client *FSouza_NodeClient) Build(logger log.Log, force bool) bool {
 		ContextDir:     buildPath,
 		RmTmpContainer: true,
 		OutputStream:   logger,
+
+		Dockerfile: client.settings.Dockerfile,
+		NoCache:    client.settings.NoCache || buildOptions.NoCache,
+		Pull:       client.settings.BuildPull || buildOptions.Pull,
+		Target:     client.settings.BuildTarget,
+		Labels:     MergeCoachLabels(client.settings.BuildLabels, client.labels("")),
+	}
+	for _, name := range dockerSortedKeys(client.settings.BuildArgs) {
+		options.BuildArgs = append(options.BuildArgs, docker.BuildArg{Name: name, Value: client.settings.BuildArgs[name]})
 	}
 
 	logger.Info("Building node image [" + image + ":" + tag + "] From build path [" + buildPath + "]")
@@ -756,16 +1100,16 @@ func (client *FSouza_NodeClient) Build(logger log.Log, force bool) bool {
 
 	if err != nil {
 		logger.Error("Node build failed [" + client.node.MachineName() + "] in build path [" + buildPath + "] => " + err.Error())
-		return false
+		return fsouzaClientError("build", image+":"+tag, err)
 	} else {
-		client.backend.Refresh(true, false)
+		client.backend.Invalidate(true, false)
 		logger.Message("Node succesfully built image [" + image + ":" + tag + "] From path [" + buildPath + "]")
-		return true
+		return nil
 	}
 
 }
 
-func (client *FSouza_NodeClient) Destroy(logger log.Log, force bool) bool {
+func (client *FSouza_NodeClient) Destroy(logger log.Log, force bool) error {
 	// Get the image name
 	image, tag := client.GetImageName()
 	if tag != "" {
@@ -774,7 +1118,7 @@ func (client *FSouza_NodeClient) Destroy(logger log.Log, force bool) bool {
 
 	if !client.HasImage() {
 		logger.Warning("Node has no image to destroy [" + image + "]")
-		return false
+		return NewClientError(ERROR_IMAGE_NOT_FOUND, "destroy", image, nil)
 	}
 
 	options := docker.RemoveImageOptions{
@@ -786,28 +1130,40 @@ func (client *FSouza_NodeClient) Destroy(logger log.Log, force bool) bool {
 
 	if err != nil {
 		logger.Error("Node image removal failed [" + image + "] => " + err.Error())
-		return false
+		return fsouzaClientError("destroy", image, err)
 	} else {
-		client.backend.Refresh(true, false)
+		client.backend.Invalidate(true, false)
 		logger.Message("Node image was removed [" + image + "]")
-		return true
+		return nil
 	}
 }
 
-func (client *FSouza_NodeClient) Pull(logger log.Log, force bool) bool {
+func (client *FSouza_NodeClient) Pull(logger log.Log, force bool) error {
 	image, tag := client.GetImageName()
 	actionCacheTag := "pull:" + image + ":" + tag
 
-	if _, ok := actionCache[actionCacheTag]; ok {
+	// another node may still be pulling the image, so wait for its result
+	if result, ok := actionCache.Wait(actionCacheTag); ok {
 		logger.Message("Node image [" + image + ":" + tag + "] was just pulled, so not pulling it again.")
-		return true
+		return result
 	}
 
 	if !force && client.HasImage() {
 		logger.Info("Node already has an image [" + image + ":" + tag + "], so not pulling it again.  You can force this operation if you want to pull this image.")
-		return false
+		return NewClientError(ERROR_IMAGE_EXISTS, "pull", image+":"+tag, nil)
 	}
 
+	result, ran := actionCache.Once(actionCacheTag, func() error {
+		return client.pullImage(logger, image, tag)
+	})
+	if !ran {
+		logger.Message("Node image [" + image + ":" + tag + "] was just pulled, so not pulling it again.")
+	}
+	return result
+}
+
+// Pull a node image, using the registry auth for the image
+func (client *FSouza_NodeClient) pullImage(logger log.Log, image string, tag string) error {
 	options := docker.PullImageOptions{
 		Repository:    image,
 		OutputStream:  logger,
@@ -818,80 +1174,281 @@ func (client *FSouza_NodeClient) Pull(logger log.Log, force bool) bool {
 		options.Tag = tag
 	}
 
-	var auth docker.AuthConfiguration
-	// 		var ok bool
-	//options.Registry = "https://index.docker.io/v1/"
-
-	// 		auths, _ := docker.NewAuthConfigurationsFromDockerCfg()
-	// 		if auth, ok = auths.Configs[registry]; ok {
-	// 			options.Registry = registry
-	// 		} else {
-	// 			node.log.Warning("You have no local login credentials for any repo. Defaulting to no login.")
-	auth = docker.AuthConfiguration{}
-	options.Registry = "https://index.docker.io/v1/"
-	// 		}
+	auth := client.RegistryAuth(logger, image)
+	options.Registry = auth.ServerAddress
 
 	logger.Message("Pulling node image [" + image + ":" + tag + "] from server [" + options.Registry + "] using auth [" + auth.Username + "] : " + image + ":" + tag)
-	logger.Debug(log.VERBOSITY_DEBUG_LOTS, "AUTH USED: ", map[string]string{"Username": auth.Username, "Password": auth.Password, "Email": auth.Email, "ServerAdddress": auth.ServerAddress})
+	logger.Debug(log.VERBOSITY_DEBUG_LOTS, "AUTH USED: ", map[string]string{"Username": auth.Username, "Email": auth.Email, "ServerAdddress": auth.ServerAddress})
 
 	// ask the docker client to build the image
 	err := client.backend.PullImage(options, auth)
 
 	if err != nil {
 		logger.Error("Node image not pulled : " + image + " => " + err.Error())
-		actionCache[actionCacheTag] = false
-		return false
+		return fsouzaClientError("pull", image+":"+tag, err)
 	} else {
-		client.backend.Refresh(true, false)
+		client.backend.Invalidate(true, false)
 		logger.Message("Node image pulled: " + image + ":" + tag)
-		actionCache[actionCacheTag] = false
-		return true
+		return nil
+	}
+}
+
+func (client *FSouza_NodeClient) Push(logger log.Log, repository string, tag string) error {
+	image, imageTag := client.GetImageName()
+	if client.settings.Repository != "" {
+		image = client.settings.Repository
+	}
+	if tag == "" {
+		tag = imageTag
+	}
+	if repository == "" {
+		repository = image
+	}
+
+	if image != repository {
+		options := docker.TagImageOptions{
+			Repo:  repository,
+			Tag:   tag,
+			Force: true,
+		}
+		if err := client.backend.TagImage(image+":"+tag, options); err != nil {
+			logger.Error("Node image could not be tagged for push [" + image + ":" + tag + "] => [" + repository + ":" + tag + "] : " + err.Error())
+			return fsouzaClientError("tag", image+":"+tag, err)
+		}
+		client.backend.Invalidate(true, false)
+	}
+
+	options := docker.PushImageOptions{
+		Name:          repository,
+		Tag:           tag,
+		OutputStream:  logger,
+		RawJSONStream: false,
+	}
+
+	auth := client.RegistryAuth(logger, repository)
+
+	logger.Message("Pushing node image [" + repository + ":" + tag + "] to server [" + auth.ServerAddress + "] using auth [" + auth.Username + "]")
+	logger.Debug(log.VERBOSITY_DEBUG_LOTS, "AUTH USED: ", map[string]string{"Username": auth.Username, "Email": auth.Email, "ServerAdddress": auth.ServerAddress})
+
+	if err := client.backend.PushImage(options, auth); err != nil {
+		logger.Error("Node image not pushed : " + repository + ":" + tag + " => " + err.Error())
+		return fsouzaClientError("push", repository+":"+tag, err)
+	} else {
+		logger.Message("Node image pushed: " + repository + ":" + tag)
+		return nil
 	}
 }
 
+func (client *FSouza_NodeClient) CreateVolume(logger log.Log) error {
+	name := NodeVolumeName(client.node)
+
+	if client.settings.Volume == nil {
+		logger.Warning("Node has no Volume: settings, so no volume will be created [" + name + "]")
+		return NewClientError(ERROR_INVALID, "create-volume", name, errors.New("no Volume: settings"))
+	}
+	if client.HasVolume() {
+		logger.Info("Node volume already exists [" + name + "]")
+		return NewClientError(ERROR_VOLUME_EXISTS, "create-volume", name, nil)
+	}
+
+	options := docker.CreateVolumeOptions{
+		Name:       name,
+		Driver:     client.settings.Volume.Driver,
+		DriverOpts: client.settings.Volume.Options,
+		Labels:     MergeCoachLabels(client.settings.Volume.Labels, client.labels("")),
+	}
+
+	if _, err := client.backend.CreateVolume(options); err != nil {
+		logger.Error("Failed to create node volume [" + name + "] => " + err.Error())
+		return fsouzaClientError("create-volume", name, err)
+	} else {
+		logger.Message("Created node volume [" + name + "]")
+		return nil
+	}
+}
+
+func (client *FSouza_NodeClient) RemoveVolume(logger log.Log, force bool) error {
+	name := NodeVolumeName(client.node)
+
+	options := docker.RemoveVolumeOptions{
+		Name:  name,
+		Force: force,
+	}
+
+	switch err := client.backend.RemoveVolumeWithOptions(options); err {
+	case nil:
+		logger.Message("Node volume was removed [" + name + "]")
+		return nil
+	case docker.ErrNoSuchVolume:
+		logger.Warning("Node has no volume to remove [" + name + "]")
+		return fsouzaClientError("remove-volume", name, err)
+	case docker.ErrVolumeInUse:
+		logger.Error("Node volume removal failed [" + name + "] => the volume is still in use by a container")
+		return fsouzaClientError("remove-volume", name, err)
+	default:
+		logger.Error("Node volume removal failed [" + name + "] => " + err.Error())
+		return fsouzaClientError("remove-volume", name, err)
+	}
+}
+
+func (client *FSouza_NodeClient) RemoveNetworks(logger log.Log) error {
+	errs := Errors{}
+	labels := ProjectNetworkLabels(client.conf)
+	delete(labels, COACH_LABEL_ENVIRONMENT)
+
+	for _, name := range nodeNetworkNames(client.conf, client.settings) {
+		// only the project network belongs to coach, any other networks are shared and never removed
+		if name != ProjectNetworkName(client.conf) {
+			continue
+		}
+		actionCacheTag := "network-remove:" + name
+		if actionCache.Has(actionCacheTag) {
+			continue
+		}
+
+		network, err := client.backend.NetworkInfo(name)
+		if err != nil {
+			if _, missing := err.(*docker.NoSuchNetwork); !missing {
+				logger.Warning("Could not inspect network [" + name + "] : " + err.Error())
+				errs.Add(fsouzaClientError("remove-network", name, err))
+			}
+			continue
+		}
+		if !MatchCoachLabels(network.Labels, labels) {
+			logger.Info("Network was not created by coach for this project, so it will not be removed [" + name + "]")
+			actionCache.Set(actionCacheTag, nil)
+			continue
+		}
+		if len(network.Containers) > 0 {
+			logger.Info("Network is still in use by " + strconv.Itoa(len(network.Containers)) + " containers, so it will not be removed [" + name + "]")
+			continue
+		}
+
+		if err := client.backend.RemoveNetwork(network.ID); err != nil {
+			logger.Warning("Failed to remove network [" + name + "] : " + err.Error())
+			errs.Add(fsouzaClientError("remove-network", name, err))
+		} else {
+			logger.Message("Removed network [" + name + "]")
+			actionCache.Set(actionCacheTag, nil)
+		}
+	}
+	return errs.Err()
+}
+
+func (client *FSouza_NodeClient) Events(logger log.Log, options EventsOptions, events chan<- ProjectEvent) error {
+	since, err := eventsTimestamp(options.Since)
+	if err != nil {
+		logger.Error("Invalid events since time [" + options.Since + "] => " + err.Error())
+		return NewClientError(ERROR_INVALID, "events", client.nodeId, err)
+	}
+	until, err := eventsTimestamp(options.Until)
+	if err != nil {
+		logger.Error("Invalid events until time [" + options.Until + "] => " + err.Error())
+		return NewClientError(ERROR_INVALID, "events", client.nodeId, err)
+	}
+
+	stream, err := client.backend.EventsClient()
+	if err != nil {
+		logger.Error("Failed to connect to the docker events => " + err.Error())
+		return fsouzaClientError("events", client.nodeId, err)
+	}
+
+	// the listener is closed when the stream ends, which only happens with an until time
+	listener := make(chan *docker.APIEvents, 100)
+	eventsOptions := docker.EventsOptions{
+		Since:   since,
+		Until:   until,
+		Filters: eventsFilters(client.conf, options),
+	}
+	if err := stream.AddEventListenerWithOptions(eventsOptions, listener); err != nil {
+		logger.Error("Failed to listen to the docker events => " + err.Error())
+		return fsouzaClientError("events", client.nodeId, err)
+	}
+	defer stream.RemoveEventListener(listener)
+
+	for event := range listener {
+		if projectEvent := eventFromDocker(event); options.HasNode(projectEvent.Node) {
+			events <- projectEvent
+		}
+	}
+	return nil
+}
+
+// All nodes that use the same docker client can share an event stream
+func (client *FSouza_NodeClient) EventsStream() interface{} {
+	return client.backend
+}
+
+// Determine the registry auth to use for an image, preferring the node settings over the docker config
+func (client *FSouza_NodeClient) RegistryAuth(logger log.Log, image string) docker.AuthConfiguration {
+	registry := client.settings.Registry
+	if registry == "" {
+		registry = DockerRegistryFromImage(image)
+	}
+
+	if !client.settings.Auth.IsEmpty() {
+		logger.Debug(log.VERBOSITY_DEBUG, "Using node registry credentials for registry ["+registry+"]")
+		return client.settings.Auth.AuthConfiguration(registry)
+	}
+
+	if config, ok := LoadDockerConfig(logger, client.conf); ok {
+		if auth, ok := config.AuthConfiguration(logger, registry); ok {
+			auth.ServerAddress = registry
+			return auth
+		}
+	}
+
+	logger.Debug(log.VERBOSITY_DEBUG, "No login credentials found for registry ["+registry+"].  Defaulting to no login.")
+	return docker.AuthConfiguration{ServerAddress: registry}
+}
+
 /**
  * InstanceClient : Action methods
  */
 
-func (client *FSouza_InstanceClient) Attach(logger log.Log) bool {
+func (client *FSouza_InstanceClient) Attach(logger log.Log, attachOptions AttachOptions) error {
 	id := client.instance.MachineName()
 
 	// build options for the docker attach operation
 	options := docker.AttachToContainerOptions{
 		Container:    id,
-		InputStream:  os.Stdin,
 		OutputStream: os.Stdout,
 		ErrorStream:  logger,
 
-		Logs:   true, // Get container logs, sending it to OutputStream.
-		Stream: true, // Stream the response?
+		Logs:   attachOptions.Logs, // Get container logs, sending it to OutputStream.
+		Stream: true,               // Stream the response?
 
-		Stdin:  true, // Attach to stdin, and use InputStream.
-		Stdout: true, // Attach to stdout, and use OutputStream.
+		Stdin:  attachOptions.Stdin, // Attach to stdin, and use InputStream.
+		Stdout: true,                // Attach to stdout, and use OutputStream.
 		Stderr: true,
 
+		DetachKeys: attachOptions.DetachKeys,
+
 		//Success chan struct{}
 
 		RawTerminal: client.settings.Config.Tty, // Use raw terminal? Usually true when the container contains a TTY.
 	}
+	if attachOptions.Stdin {
+		options.InputStream = os.Stdin
+	}
 
 	logger.Message("Attaching to instance container [" + id + "]")
 	err := client.backend.AttachToContainer(options)
 	if err != nil {
 		logger.Error("Failed to attach to instance container [" + id + "] =>" + err.Error())
-		return false
+		return fsouzaClientError("attach", id, err)
 	} else {
 		logger.Message("Disconnected from instance container [" + id + "]")
-		return true
+		return nil
 	}
 }
 
-func (client *FSouza_InstanceClient) Create(logger log.Log, overrideCmd []string, force bool) bool {
+func (client *FSouza_InstanceClient) Create(logger log.Log, overrideCmd []string, force bool) error {
 	instance := client.instance
 
 	if !force && client.HasContainer() {
 		logger.Info("[" + instance.MachineName() + "]: Skipping node instance, which already has a container")
-		return false
+		return NewClientError(ERROR_CONTAINER_EXISTS, "create", instance.MachineName(), nil)
 	}
 
 	/**
@@ -909,20 +1466,41 @@ func (client *FSouza_InstanceClient) Create(logger log.Log, overrideCmd []string
 		image += ":" + tag
 	}
 	Config.Image = image
+	Config.Labels = MergeCoachLabels(Config.Labels, client.labels(instance.Id()))
 
 	if len(overrideCmd) > 0 {
 		Config.Cmd = overrideCmd
 	}
 
+	// make sure that the instance networks exist, and join the first when creating the container
+	networks := nodeNetworkNames(client.conf, client.settings)
+	aliases := instanceNetworkAliases(client.nodeId, instance)
+	for _, network := range networks {
+		if created, err := client.backend.EnsureNetwork(network, networkCreateLabels(client.conf, network)); err != nil {
+			logger.Error("Failed to create network for instance container [" + name + "] : " + network + " => " + err.Error())
+			return fsouzaClientError("create-network", network, err)
+		} else if created {
+			logger.Message("Created network [" + network + "]")
+		}
+	}
+
 	// ask the docker client to create a container for this instance
 	options := docker.CreateContainerOptions{
 		Name:       name,
 		Config:     &Config,
 		HostConfig: &Host,
 	}
+	if len(networks) > 0 {
+		Host.NetworkMode = networks[0]
+		options.NetworkingConfig = &docker.NetworkingConfig{
+			EndpointsConfig: map[string]*docker.EndpointConfig{
+				networks[0]: &docker.EndpointConfig{Aliases: aliases},
+			},
+		}
+	}
 
 	container, err := client.backend.CreateContainer(options)
-	client.backend.Refresh(false, true)
+	client.backend.Invalidate(false, true)
 
 	if err != nil {
 
@@ -934,23 +1512,39 @@ func (client *FSouza_InstanceClient) Create(logger log.Log, overrideCmd []string
 		* container.  It is not clear if this failure occurs in the
 		* remote API, or in the dockerclient library.
 		 */
-		client.backend.Refresh(false, true)
-		if err.Error() == "no such image" && client.HasContainer() {
-			logger.Message("Created instance container [" + name + " FROM " + Config.Image + "] => " + container.ID[:12])
+		client.backend.Invalidate(false, true)
+		if err == docker.ErrNoSuchImage && client.HasContainer() {
+			logger.Message("Created instance container [" + name + " FROM " + Config.Image + "]")
 			logger.Warning("Docker created the container, but reported an error due to a 'missing image'.  This is a known bug, that can be ignored")
-			return true
+			return nil
 		}
 
 		logger.Error("Failed to create instance container [" + name + " FROM " + Config.Image + "] => " + err.Error())
-		return false
+		if err == docker.ErrNoSuchImage {
+			return fsouzaClientError("create", Config.Image, err)
+		}
+		return fsouzaClientError("create", name, err)
 	} else {
-		client.backend.Refresh(false, true)
+		client.backend.Invalidate(false, true)
 		logger.Message("Created instance container [" + name + "] => " + container.ID[:12])
-		return true
+
+		// any additional networks can only be joined after the container exists
+		if len(networks) > 1 {
+			for _, network := range networks[1:] {
+				options := docker.NetworkConnectionOptions{
+					Container:      container.ID,
+					EndpointConfig: &docker.EndpointConfig{Aliases: aliases},
+				}
+				if err := client.backend.ConnectNetwork(network, options); err != nil {
+					logger.Warning("Failed to connect instance container to network [" + name + "] : " + network + " => " + err.Error())
+				}
+			}
+		}
+		return nil
 	}
 }
 
-func (client *FSouza_InstanceClient) Remove(logger log.Log, force bool) bool {
+func (client *FSouza_InstanceClient) Remove(logger log.Log, force bool) error {
 	name := client.instance.MachineName()
 	options := docker.RemoveContainerOptions{
 		ID: name,
@@ -961,17 +1555,15 @@ func (client *FSouza_InstanceClient) Remove(logger log.Log, force bool) bool {
 
 	if err != nil {
 		logger.Error("Failed to remove instance container [" + name + "] =>" + err.Error())
-		return false
+		return fsouzaClientError("remove", name, err)
 	} else {
-		client.backend.Refresh(false, true)
+		client.backend.Invalidate(false, true)
 		logger.Message("Removed instance container [" + name + "] ")
-		return true
+		return nil
 	}
-
-	return false
 }
 
-func (client *FSouza_InstanceClient) Start(logger log.Log, force bool) bool {
+func (client *FSouza_InstanceClient) Start(logger log.Log, force bool) error {
 	// Convert the node data into docker data (transform node keys to container IDs for things like Links & VolumesFrom)
 	id := client.instance.MachineName()
 	Host := client.settings.Host
@@ -981,57 +1573,57 @@ func (client *FSouza_InstanceClient) Start(logger log.Log, force bool) bool {
 
 	if err != nil {
 		logger.Error("Failed to start node container [" + id + "] => " + err.Error())
-		return false
+		return fsouzaClientError("start", id, err)
 	} else {
 		logger.Message("Node instance started [" + id + "]")
-		client.backend.Refresh(false, true)
-		return true
+		client.backend.Invalidate(false, true)
+		return nil
 	}
 }
 
-func (client *FSouza_InstanceClient) Stop(logger log.Log, force bool, timeout uint) bool {
+func (client *FSouza_InstanceClient) Stop(logger log.Log, force bool, timeout uint) error {
 	id := client.instance.MachineName()
 
 	err := client.backend.StopContainer(id, timeout)
 	if err != nil {
 		logger.Error("Failed to stop node container [" + id + "] => " + err.Error())
-		return false
+		return fsouzaClientError("stop", id, err)
 	} else {
-		client.backend.Refresh(false, true)
+		client.backend.Invalidate(false, true)
 		logger.Message("Node instance stopped [" + id + "]")
-		return true
+		return nil
 	}
 }
 
-func (client *FSouza_InstanceClient) Pause(logger log.Log) bool {
+func (client *FSouza_InstanceClient) Pause(logger log.Log) error {
 	id := client.instance.MachineName()
 
 	err := client.backend.PauseContainer(id)
 	if err != nil {
 		logger.Error("Failed to pause intance [" + client.instance.Id() + "] Container [" + id + "] =>" + err.Error())
-		return false
+		return fsouzaClientError("pause", id, err)
 	} else {
-		client.backend.Refresh(false, true)
+		client.backend.Invalidate(false, true)
 		logger.Message("Paused instance [" + client.instance.Id() + "] Container [" + id + "]")
-		return true
+		return nil
 	}
 }
 
-func (client *FSouza_InstanceClient) Unpause(logger log.Log) bool {
+func (client *FSouza_InstanceClient) Unpause(logger log.Log) error {
 	id := client.instance.MachineName()
 
 	err := client.backend.UnpauseContainer(id)
 	if err != nil {
 		logger.Error("Failed to unpause Instance [" + client.instance.Id() + "] Container [" + id + "] =>" + err.Error())
-		return false
+		return fsouzaClientError("unpause", id, err)
 	} else {
-		client.backend.Refresh(false, true)
+		client.backend.Invalidate(false, true)
 		logger.Message("Unpaused Instance [" + client.instance.Id() + "] Container [" + id + "]")
-		return true
+		return nil
 	}
 }
 
-func (client *FSouza_InstanceClient) Commit(logger log.Log, tag string, message string) bool {
+func (client *FSouza_InstanceClient) Commit(logger log.Log, tag string, message string) error {
 	id := client.instance.MachineName()
 	config := client.settings.Config
 	repo := client.settings.Repository
@@ -1040,6 +1632,7 @@ func (client *FSouza_InstanceClient) Commit(logger log.Log, tag string, message
 	if repo == "" {
 		repo, _ = client.GetImageName()
 	}
+	config.Labels = MergeCoachLabels(config.Labels, client.labels(""))
 
 	options := docker.CommitContainerOptions{
 		Container:  id,
@@ -1058,20 +1651,164 @@ func (client *FSouza_InstanceClient) Commit(logger log.Log, tag string, message
 	_, err := client.backend.CommitContainer(options)
 	if err != nil {
 		logger.Warning("Failed to commit container changes to an image [" + client.instance.Id() + ":" + id + "] : " + tag)
-		return false
+		return fsouzaClientError("commit", id, err)
 	} else {
-		client.backend.Refresh(true, false)
+		client.backend.Invalidate(true, false)
 		logger.Message("Committed container changes to an image [" + client.instance.Id() + ":" + id + "] : " + tag)
-		return true
+		return nil
 	}
 }
 
-func (client *FSouza_InstanceClient) Run(logger log.Log, persistant bool, cmd []string) bool {
+func (client *FSouza_InstanceClient) Logs(logger log.Log, output io.Writer, logsOptions LogsOptions) error {
+	id := client.instance.MachineName()
+
+	since, err := dockerSinceTimestamp(logsOptions.Since)
+	if err != nil {
+		logger.Error("Invalid logs since value [" + logsOptions.Since + "] => " + err.Error())
+		return NewClientError(ERROR_INVALID, "logs", id, err)
+	}
+	tail := logsOptions.Tail
+	if tail == "" {
+		tail = "all"
+	}
+
+	options := docker.LogsOptions{
+		Container:    id,
+		OutputStream: output,
+		ErrorStream:  output,
+
+		Stdout:     true,
+		Stderr:     true,
+		Follow:     logsOptions.Follow,
+		Timestamps: logsOptions.Timestamps,
+		Tail:       tail,
+		Since:      since,
+
+		RawTerminal: client.settings.Config.Tty, // containers with a TTY don't multiplex stdout/stderr
+	}
+
+	if err := client.backend.Logs(options); err != nil {
+		logger.Error("Failed to read instance container logs [" + id + "] => " + err.Error())
+		return fsouzaClientError("logs", id, err)
+	}
+	return nil
+}
+
+func (client *FSouza_InstanceClient) Exec(logger log.Log, cmd []string, options ExecOptions) (int, error) {
+	id := client.instance.MachineName()
+
+	exec, err := client.backend.CreateExec(docker.CreateExecOptions{
+		Container:  id,
+		Cmd:        cmd,
+		User:       options.User,
+		WorkingDir: options.WorkingDir,
+		Env:        options.Env,
+
+		AttachStdin:  options.Stdin,
+		AttachStdout: true,
+		AttachStderr: true,
+		Tty:          options.Tty,
+	})
+	if err != nil {
+		logger.Error("Failed to create exec in instance container [" + id + "] => " + err.Error())
+		return -1, fsouzaClientError("exec", id, err)
+	}
+
+	startOptions := docker.StartExecOptions{
+		OutputStream: os.Stdout,
+		ErrorStream:  os.Stderr,
+
+		Tty:         options.Tty,
+		RawTerminal: options.Tty, // Use raw terminal? Usually true when the exec has a TTY.
+	}
+	if options.Stdin {
+		startOptions.InputStream = os.Stdin
+	}
+
+	logger.Info("Running command in instance container [" + id + "] : " + strings.Join(cmd, " "))
+	if err := client.backend.StartExec(exec.ID, startOptions); err != nil {
+		logger.Error("Failed to run exec in instance container [" + id + "] => " + err.Error())
+		return -1, fsouzaClientError("exec", id, err)
+	}
+
+	inspect, err := client.backend.InspectExec(exec.ID)
+	if err != nil {
+		logger.Error("Failed to read the exec exit code from instance container [" + id + "] => " + err.Error())
+		return -1, fsouzaClientError("exec", id, err)
+	}
+	return inspect.ExitCode, nil
+}
+
+func (client *FSouza_InstanceClient) Stats(logger log.Log) (InstanceStats, error) {
+	id := client.instance.MachineName()
+
+	// a single sample still includes the previous cpu usage, which is needed for the cpu percentage
+	samples := make(chan *docker.Stats, 1)
+	errs := make(chan error, 1)
+	go func() {
+		errs <- client.backend.Stats(docker.StatsOptions{
+			ID:      id,
+			Stats:   samples,
+			Stream:  false,
+			Timeout: 10 * time.Second,
+		})
+	}()
+
+	sample, received := <-samples
+	if err := <-errs; err != nil || !received || sample == nil {
+		if err == nil {
+			err = errors.New("no stats were returned")
+		}
+		logger.Error("Failed to read instance container stats [" + id + "] => " + err.Error())
+		return InstanceStats{}, fsouzaClientError("stats", id, err)
+	}
+	return statsFromDocker(sample), nil
+}
+
+func (client *FSouza_InstanceClient) Upload(logger log.Log, source string, destination string) error {
+	id := client.instance.MachineName()
+	destination = path.Clean(destination)
+
+	err := uploadFromHostPath(source, path.Base(destination), func(reader io.Reader) error {
+		return client.backend.UploadToContainer(id, docker.UploadToContainerOptions{
+			InputStream: reader,
+			Path:        path.Dir(destination),
+		})
+	})
+	if err != nil {
+		logger.Error("Failed to copy [" + source + "] into instance container [" + id + ":" + destination + "] => " + err.Error())
+		return fsouzaClientError("upload", id, err)
+	}
+	logger.Message("Copied [" + source + "] into instance container [" + id + ":" + destination + "]")
+	return nil
+}
+
+func (client *FSouza_InstanceClient) Download(logger log.Log, source string, destination string) error {
+	id := client.instance.MachineName()
+
+	err := downloadToHostPath(destination, func(writer io.Writer) error {
+		return client.backend.DownloadFromContainer(id, docker.DownloadFromContainerOptions{
+			OutputStream: writer,
+			Path:         source,
+		})
+	})
+	if err != nil {
+		logger.Error("Failed to copy [" + id + ":" + source + "] from instance container to [" + destination + "] => " + err.Error())
+		return fsouzaClientError("download", id, err)
+	}
+	logger.Message("Copied [" + id + ":" + source + "] from instance container to [" + destination + "]")
+	return nil
+}
+
+func (client *FSouza_InstanceClient) Run(logger log.Log, cmd []string, options RunOptions) (int, error) {
 	hushedLogger := logger.MakeChild("RunSupport")
 	hushedLogger.Hush()
 
 	instance := client.instance
 
+	// a raw terminal would mangle piped input and output, so only use a TTY if coach is on one
+	client.settings.Config.Tty = client.settings.Config.Tty && options.Tty
+
 	// Set up some additional settings for TTY commands
 	if client.settings.Config.Tty == true {
 
@@ -1084,52 +1821,108 @@ func (client *FSouza_InstanceClient) Run(logger log.Log, persistant bool, cmd []
 		client.settings.Config.OpenStdin = true
 	}
 
-	client.settings.Config.AttachStdin = true
+	// piped input is closed when it ends, so that the command sees EOF
+	if options.Stdin {
+		client.settings.Config.OpenStdin = true
+		client.settings.Config.StdinOnce = true
+	}
+
+	client.settings.Config.AttachStdin = options.Stdin
 	client.settings.Config.AttachStdout = true
 	client.settings.Config.AttachStderr = true
 
 	// 1. get the container for the instance (create it if needed)
-	hasContainer := client.HasContainer()
-	if !hasContainer {
+	if !client.HasContainer() {
 		logger.Info("Creating new disposable RUN container")
 
-		if hasContainer = client.Create(hushedLogger, cmd, false); hasContainer {
-			logger.Debug(log.VERBOSITY_DEBUG, "Created disposable run container")
-			if !persistant {
-				// 5. [DEFERED] remove the container (if not instructed to keep it)
-				defer func(client *FSouza_InstanceClient, hushedLogger log.Log) {
-					client.backend.Refresh(false, true)
-					if client.IsRunning() {
-						client.Stop(hushedLogger, true, 0)
-					}
-					client.Remove(hushedLogger, true)
-				}(client, hushedLogger)
-			}
-		} else {
-			logger.Error("Failed to create disposable run container")
+		if err := client.Create(hushedLogger, cmd, false); err != nil {
+			logger.Error("Could not create RUN container")
+			return -1, err
+		}
+
+		logger.Debug(log.VERBOSITY_DEBUG, "Created disposable run container")
+		if !options.Persistant {
+			// 6. [DEFERED] remove the container (if not instructed to keep it)
+			defer func(client *FSouza_InstanceClient, hushedLogger log.Log) {
+				client.backend.Invalidate(false, true)
+				if client.IsRunning() {
+					client.Stop(hushedLogger, true, 0)
+				}
+				client.Remove(hushedLogger, true)
+			}(client, hushedLogger)
 		}
 	} else {
 		logger.Info("Run container already exists")
 	}
 
-	if hasContainer {
+	// 3. start the container (set up a remove)
+	logger.Info("Starting RUN container")
+	if err := client.Start(hushedLogger, false); err != nil {
+		logger.Error("Could not start RUN container")
+		return -1, err
+	}
 
-		// 3. start the container (set up a remove)
-		logger.Info("Starting RUN container")
-		ok := client.Start(hushedLogger, false)
+	// 4. attach to the container
+	logger.Info("Attaching to disposable RUN container")
+	client.Attach(logger, AttachOptions{Stdin: options.Stdin, Logs: true})
 
-		// 4. attach to the container
-		if ok {
-			logger.Info("Attaching to disposable RUN container")
-			client.Attach(logger)
-			return true
-		} else {
-			logger.Error("Could not start RUN container")
-			return false
+	// 5. wait for the container to exit, for the command exit code
+	exitCode, err := client.backend.WaitContainer(instance.MachineName())
+	if err != nil {
+		logger.Error("Failed to wait for RUN container => " + err.Error())
+		return -1, fsouzaClientError("run", instance.MachineName(), err)
+	}
+	return exitCode, nil
+}
+
+// Convert a docker remote API error into a client error, so that callers can check what went wrong
+func fsouzaClientError(action string, resource string, err error) error {
+	kind := ERROR_FAILED
+
+	switch typed := err.(type) {
+	case *docker.NoSuchContainer:
+		kind = ERROR_CONTAINER_NOT_FOUND
+	case *docker.ContainerNotRunning:
+		kind = ERROR_NOT_RUNNING
+	case *docker.ContainerAlreadyRunning:
+		kind = ERROR_CONFLICT
+	case *docker.Error:
+		if typed.Status == http.StatusConflict {
+			kind = ERROR_CONFLICT
 		}
+	case net.Error:
+		kind = ERROR_DAEMON_UNREACHABLE
+	}
 
-	} else {
-		logger.Error("Could not create RUN container")
+	switch err {
+	case docker.ErrNoSuchImage:
+		kind = ERROR_IMAGE_NOT_FOUND
+	case docker.ErrContainerAlreadyExists:
+		kind = ERROR_CONTAINER_EXISTS
+	case docker.ErrNoSuchVolume:
+		kind = ERROR_VOLUME_NOT_FOUND
+	case docker.ErrVolumeInUse:
+		kind = ERROR_CONFLICT
+	case docker.ErrConnectionRefused:
+		kind = ERROR_DAEMON_UNREACHABLE
+	}
+
+	return NewClientError(kind, action, resource, err)
+}
+
+// Convert a logs "since" value (a unix timestamp, an RFC3339 time or a relative duration like "10m") to a unix timestamp
+func dockerSinceTimestamp(since string) (int64, error) {
+	if since == "" {
+		return 0, nil
+	}
+	if timestamp, err := strconv.ParseInt(since, 10, 64); err == nil {
+		return timestamp, nil
+	}
+	if duration, err := time.ParseDuration(since); err == nil {
+		return time.Now().Add(-duration).Unix(), nil
+	}
+	if timestamp, err := time.Parse(time.RFC3339, since); err == nil {
+		return timestamp.Unix(), nil
 	}
-	return false
+	return 0, errors.New("expected a unix timestamp, an RFC3339 time or a duration like 10m")
 }
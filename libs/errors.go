@@ -0,0 +1,210 @@
+package libs
+
+/**
+ * @file Client errors
+ *
+ * Node and instance client actions return typed errors, so that operations,
+ * and code that embeds libs, can react to what went wrong without parsing log
+ * messages.  Clients still log their own messages, the errors are for callers.
+ */
+
+import (
+	"errors"
+	"strings"
+)
+
+// What kind of failure a client error is
+type ClientErrorKind string
+
+const (
+	ERROR_FAILED             ClientErrorKind = "failed"             // the action failed, for a reason that has no kind
+	ERROR_INVALID            ClientErrorKind = "invalid"            // the node settings or action arguments can't be used
+	ERROR_DAEMON_UNREACHABLE ClientErrorKind = "daemon-unreachable" // the docker daemon could not be reached
+	ERROR_CLIENT_MISSING     ClientErrorKind = "client-missing"     // the client program, such as the docker binary, could not be run
+	ERROR_CONFLICT           ClientErrorKind = "conflict"           // the resource is in use, or in the wrong state for the action
+
+	ERROR_IMAGE_NOT_FOUND     ClientErrorKind = "image-not-found"
+	ERROR_CONTAINER_NOT_FOUND ClientErrorKind = "container-not-found"
+	ERROR_VOLUME_NOT_FOUND    ClientErrorKind = "volume-not-found"
+	ERROR_NOT_RUNNING         ClientErrorKind = "not-running" // the container needs to be running
+	ERROR_NOT_READY           ClientErrorKind = "not-ready"   // the container did not pass its health checks
+
+	// the resource already exists, so an action that was not forced was skipped
+	ERROR_IMAGE_EXISTS     ClientErrorKind = "image-exists"
+	ERROR_CONTAINER_EXISTS ClientErrorKind = "container-exists"
+	ERROR_VOLUME_EXISTS    ClientErrorKind = "volume-exists"
+)
+
+// An error from a node or instance client action
+type ClientError struct {
+	Kind     ClientErrorKind
+	Action   string // the client action, e.g. create, start
+	Resource string // the docker resource, e.g. a container or image name
+	Err      error  // the underlying error, if there is one
+}
+
+// Constructor for a client error
+func NewClientError(kind ClientErrorKind, action string, resource string, err error) *ClientError {
+	return &ClientError{Kind: kind, Action: action, Resource: resource, Err: err}
+}
+
+func (clientError *ClientError) Error() string {
+	message := clientError.Action + " [" + clientError.Resource + "] " + string(clientError.Kind)
+	if clientError.Err != nil {
+		message += " => " + clientError.Err.Error()
+	}
+	return message
+}
+func (clientError *ClientError) Unwrap() error {
+	return clientError.Err
+}
+
+// The kind of a client error, or ERROR_FAILED for other errors
+func ErrorKind(err error) ClientErrorKind {
+	var clientError *ClientError
+	if errors.As(err, &clientError) {
+		return clientError.Kind
+	}
+	return ERROR_FAILED
+}
+
+// Is an error (or any error in an aggregate) a client error of a kind
+func IsErrorKind(err error, kind ClientErrorKind) bool {
+	if list, ok := err.(Errors); ok {
+		for _, each := range list {
+			if IsErrorKind(each, kind) {
+				return true
+			}
+		}
+		return false
+	}
+	return err != nil && ErrorKind(err) == kind
+}
+
+// Is an error only a skipped action, because the resource already exists
+func IsSkipped(err error) bool {
+	if list, ok := err.(Errors); ok {
+		for _, each := range list {
+			if !IsSkipped(each) {
+				return false
+			}
+		}
+		return len(list) > 0
+	}
+
+	var skipped skippedError
+	if errors.As(err, &skipped) {
+		return true
+	}
+
+	switch ErrorKind(err) {
+	case ERROR_IMAGE_EXISTS, ERROR_CONTAINER_EXISTS, ERROR_VOLUME_EXISTS:
+		return err != nil
+	}
+	return false
+}
+
+// An error for an action that had nothing to do, which is skipped rather than failed
+type skippedError struct {
+	error
+}
+
+func (skipped skippedError) Unwrap() error {
+	return skipped.error
+}
+
+// Skip actions on resources that were not found, for teardown actions, such as remove
+// or destroy, which have nothing to do when the resource is already gone
+func SkipNotFound(err error) error {
+	if list, ok := err.(Errors); ok {
+		skipped := Errors{}
+		for _, each := range list {
+			skipped.Add(SkipNotFound(each))
+		}
+		return skipped.Err()
+	}
+
+	switch ErrorKind(err) {
+	case ERROR_IMAGE_NOT_FOUND, ERROR_CONTAINER_NOT_FOUND, ERROR_VOLUME_NOT_FOUND:
+		return skippedError{err}
+	}
+	return err
+}
+
+// An error that stops Targets.Process from starting any more targets
+type stopProcessError struct {
+	error
+}
+
+func (stop stopProcessError) Unwrap() error {
+	return stop.error
+}
+
+// Wrap an error, so that Targets.Process doesn't process any targets after it
+func StopProcess(err error) error {
+	if err == nil {
+		return nil
+	}
+	return stopProcessError{err}
+}
+
+// Wrap an error with StopProcess, unless it is only a skipped action, so that nodes which depend on a failed target are not processed
+func StopProcessOnFailure(err error) error {
+	if err == nil || IsSkipped(err) {
+		return err
+	}
+	return StopProcess(err)
+}
+
+// Did an error come from StopProcess
+func isStopProcess(err error) bool {
+	var stop stopProcessError
+	return errors.As(err, &stop)
+}
+
+/**
+ * Aggregated errors, used to collect the errors from actions on many nodes and instances
+ */
+type Errors []error
+
+// Add an error, if it isn't nil
+func (list *Errors) Add(err error) {
+	if err == nil {
+		return
+	}
+	if more, ok := err.(Errors); ok {
+		*list = append(*list, more...)
+	} else {
+		*list = append(*list, err)
+	}
+}
+
+// The aggregated errors, or nil if there are none
+func (list Errors) Err() error {
+	if len(list) == 0 {
+		return nil
+	}
+	return list
+}
+
+// The errors that are not skipped actions
+func (list Errors) Failures() Errors {
+	failures := Errors{}
+	for _, err := range list {
+		if !IsSkipped(err) {
+			failures = append(failures, err)
+		}
+	}
+	return failures
+}
+
+func (list Errors) Error() string {
+	messages := []string{}
+	for _, err := range list {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+func (list Errors) Unwrap() []error {
+	return list
+}
@@ -0,0 +1,149 @@
+package libs
+
+import (
+	"errors"
+	"testing"
+
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+func TestErrorKinds(t *testing.T) {
+	failed := errors.New("failed")
+	notFound := NewClientError(ERROR_CONTAINER_NOT_FOUND, "start", "www", nil)
+	exists := NewClientError(ERROR_CONTAINER_EXISTS, "create", "www", nil)
+	imageExists := NewClientError(ERROR_IMAGE_EXISTS, "pull", "nginx", nil)
+
+	tests := []struct {
+		name     string
+		err      error
+		kind     ClientErrorKind // the first client error kind
+		notFound bool            // IsErrorKind(ERROR_CONTAINER_NOT_FOUND)
+		skipped  bool
+	}{
+		{name: "nil", err: nil, kind: ERROR_FAILED},
+		{name: "plain error", err: failed, kind: ERROR_FAILED},
+		{name: "client error", err: notFound, kind: ERROR_CONTAINER_NOT_FOUND, notFound: true},
+		{name: "exists", err: exists, kind: ERROR_CONTAINER_EXISTS, skipped: true},
+		{name: "stopped", err: StopProcess(notFound), kind: ERROR_CONTAINER_NOT_FOUND, notFound: true},
+		{name: "all skipped", err: Errors{exists, imageExists}, kind: ERROR_CONTAINER_EXISTS, skipped: true},
+		{name: "some skipped", err: Errors{exists, notFound}, kind: ERROR_CONTAINER_EXISTS, notFound: true},
+		{name: "empty aggregate", err: Errors{}, kind: ERROR_FAILED},
+	}
+
+	for _, test := range tests {
+		if kind := ErrorKind(test.err); kind != test.kind {
+			t.Errorf("%s: kind %s, expected %s", test.name, kind, test.kind)
+		}
+		if notFound := IsErrorKind(test.err, ERROR_CONTAINER_NOT_FOUND); notFound != test.notFound {
+			t.Errorf("%s: container not found %v, expected %v", test.name, notFound, test.notFound)
+		}
+		if skipped := IsSkipped(test.err); skipped != test.skipped {
+			t.Errorf("%s: skipped %v, expected %v", test.name, skipped, test.skipped)
+		}
+	}
+}
+
+func TestErrorsAggregate(t *testing.T) {
+	exists := NewClientError(ERROR_CONTAINER_EXISTS, "create", "www", nil)
+	failed := NewClientError(ERROR_FAILED, "start", "db", errors.New("exit 1"))
+
+	errs := Errors{}
+	if errs.Err() != nil {
+		t.Error("An empty aggregate was an error")
+	}
+	errs.Add(nil)
+	errs.Add(exists)
+	errs.Add(Errors{failed})
+	if len(errs) != 2 {
+		t.Fatalf("Expected nils to be dropped and aggregates flattened, got %d errors", len(errs))
+	}
+	if failures := errs.Failures(); len(failures) != 1 || failures[0] != failed {
+		t.Errorf("Expected only the start error to be a failure, got %v", failures)
+	}
+	if message := errs.Error(); message != "create [www] container-exists; start [db] failed => exit 1" {
+		t.Errorf("Unexpected aggregate message: %s", message)
+	}
+}
+
+func TestFSouzaClientError(t *testing.T) {
+	tests := []struct {
+		err  error
+		kind ClientErrorKind
+	}{
+		{err: docker.ErrNoSuchImage, kind: ERROR_IMAGE_NOT_FOUND},
+		{err: docker.ErrContainerAlreadyExists, kind: ERROR_CONTAINER_EXISTS},
+		{err: docker.ErrNoSuchVolume, kind: ERROR_VOLUME_NOT_FOUND},
+		{err: docker.ErrConnectionRefused, kind: ERROR_DAEMON_UNREACHABLE},
+		{err: &docker.NoSuchContainer{ID: "www"}, kind: ERROR_CONTAINER_NOT_FOUND},
+		{err: &docker.ContainerNotRunning{ID: "www"}, kind: ERROR_NOT_RUNNING},
+		{err: &docker.Error{Status: 409, Message: "conflict"}, kind: ERROR_CONFLICT},
+		{err: errors.New("something else"), kind: ERROR_FAILED},
+	}
+
+	for _, test := range tests {
+		err := fsouzaClientError("test", "www", test.err)
+		if kind := ErrorKind(err); kind != test.kind {
+			t.Errorf("%v: kind %s, expected %s", test.err, kind, test.kind)
+		}
+		if !errors.Is(err, test.err) {
+			t.Errorf("%v: the client error doesn't wrap the docker error", test.err)
+		}
+	}
+}
+
+func TestStopProcessOnFailure(t *testing.T) {
+	exists := NewClientError(ERROR_CONTAINER_EXISTS, "create", "www", nil)
+	failed := NewClientError(ERROR_FAILED, "start", "www", nil)
+
+	tests := []struct {
+		name string
+		err  error
+		stop bool
+	}{
+		{name: "nil", err: nil, stop: false},
+		{name: "skipped", err: exists, stop: false},
+		{name: "failed", err: failed, stop: true},
+		{name: "some skipped", err: Errors{exists, failed}, stop: true},
+	}
+
+	for _, test := range tests {
+		err := StopProcessOnFailure(test.err)
+		if stop := isStopProcess(err); stop != test.stop {
+			t.Errorf("%s: stop %v, expected %v", test.name, stop, test.stop)
+		}
+		if (err == nil) != (test.err == nil) || (err != nil && err.Error() != test.err.Error()) {
+			t.Errorf("%s: the original error was lost: %v", test.name, err)
+		}
+	}
+}
+
+func TestSkipNotFound(t *testing.T) {
+	notFound := NewClientError(ERROR_CONTAINER_NOT_FOUND, "remove", "www", nil)
+	conflict := NewClientError(ERROR_CONFLICT, "remove", "www", nil)
+
+	tests := []struct {
+		name    string
+		err     error
+		skipped bool
+		kind    ClientErrorKind
+	}{
+		{name: "nil", err: nil, skipped: false, kind: ERROR_FAILED},
+		{name: "container not found", err: notFound, skipped: true, kind: ERROR_CONTAINER_NOT_FOUND},
+		{name: "image not found", err: NewClientError(ERROR_IMAGE_NOT_FOUND, "destroy", "www", nil), skipped: true, kind: ERROR_IMAGE_NOT_FOUND},
+		{name: "volume not found", err: NewClientError(ERROR_VOLUME_NOT_FOUND, "remove-volume", "data", nil), skipped: true, kind: ERROR_VOLUME_NOT_FOUND},
+		{name: "conflict", err: conflict, skipped: false, kind: ERROR_CONFLICT},
+		{name: "all not found", err: Errors{notFound, notFound}, skipped: true, kind: ERROR_CONTAINER_NOT_FOUND},
+		{name: "some not found", err: Errors{notFound, conflict}, skipped: false, kind: ERROR_CONTAINER_NOT_FOUND},
+	}
+
+	for _, test := range tests {
+		err := SkipNotFound(test.err)
+		if skipped := IsSkipped(err); skipped != test.skipped {
+			t.Errorf("%s: skipped %v, expected %v", test.name, skipped, test.skipped)
+		}
+		// the kind is kept, so that it can be used as the reason for the skip
+		if kind := ErrorKind(err); kind != test.kind {
+			t.Errorf("%s: kind %s, expected %s", test.name, kind, test.kind)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package libs
+
+/**
+ * @file Project events
+ *
+ * Clients stream the docker events for the resources that belong to the
+ * project, which are matched using the coach ownership labels, and mapped
+ * back to the node and instance that they belong to using the labels of the
+ * resource.  A single stream is used for all of the nodes that share a
+ * client backend.  Resources created before coach used labels, and project
+ * networks which are shared by all nodes, have no node labels, so they
+ * produce no node events.
+ */
+
+import (
+	"strconv"
+	"time"
+
+	docker "github.com/fsouza/go-dockerclient"
+
+	"github.com/james-nesbitt/coach/conf"
+)
+
+// An event for a project resource
+type ProjectEvent struct {
+	Time     time.Time
+	Type     string // container, image, volume or network
+	Action   string // what happened, e.g. create, start, die, destroy
+	ID       string // the docker id of the resource
+	Name     string // the docker name of the resource, if it has one
+	Node     string // the node that the resource belongs to
+	Instance string // the node instance that the resource belongs to, if it is a container
+}
+
+/**
+ * Run time events options, used to filter the events stream
+ */
+type EventsOptions struct {
+	Since   string   // only show events since a timestamp, or a relative duration like "10m"
+	Until   string   // stop streaming at a timestamp, or a relative duration
+	Types   []string // only show events for these resource types
+	Actions []string // only show these event actions
+	Nodes   []string // only show events for these nodes, as the project event stream includes all of them
+}
+
+// Should an event for a node be passed on
+func (options EventsOptions) HasNode(nodeId string) bool {
+	if nodeId == "" {
+		return false
+	}
+	if len(options.Nodes) == 0 {
+		return true
+	}
+	for _, node := range options.Nodes {
+		if node == nodeId {
+			return true
+		}
+	}
+	return false
+}
+
+// The docker events filters for the resources of a project
+func eventsFilters(project *conf.Project, options EventsOptions) map[string][]string {
+	filters := map[string][]string{}
+	if project != nil {
+		filters["label"] = []string{COACH_LABEL_PROJECT + "=" + project.Name}
+	}
+	if len(options.Types) > 0 {
+		filters["type"] = options.Types
+	}
+	if len(options.Actions) > 0 {
+		filters["event"] = options.Actions
+	}
+	return filters
+}
+
+// Convert a docker remote API event into a project event, using the resource labels to find the node and instance
+func eventFromDocker(event *docker.APIEvents) ProjectEvent {
+	projectEvent := ProjectEvent{
+		Type:   event.Type,
+		Action: event.Action,
+		ID:     event.Actor.ID,
+		Name:   event.Actor.Attributes["name"],
+
+		Node:     event.Actor.Attributes[COACH_LABEL_NODE],
+		Instance: event.Actor.Attributes[COACH_LABEL_INSTANCE],
+	}
+
+	// older docker APIs only have the container status fields
+	if projectEvent.Action == "" {
+		projectEvent.Action = event.Status
+	}
+	if projectEvent.ID == "" {
+		projectEvent.ID = event.ID
+	}
+	if projectEvent.Type == "" {
+		projectEvent.Type = "container"
+	}
+
+	if event.TimeNano > 0 {
+		projectEvent.Time = time.Unix(0, event.TimeNano)
+	} else {
+		projectEvent.Time = time.Unix(event.Time, 0)
+	}
+	return projectEvent
+}
+
+// Convert a since/until events option into a unix timestamp for the docker remote API
+func eventsTimestamp(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	timestamp, err := dockerSinceTimestamp(value)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(timestamp, 10), nil
+}
@@ -0,0 +1,48 @@
+package libs
+
+import (
+	"testing"
+
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+func TestEventFromDocker(t *testing.T) {
+	options := EventsOptions{Nodes: []string{"www"}}
+
+	tests := []struct {
+		name     string
+		event    docker.APIEvents
+		node     string
+		instance string
+		passed   bool
+	}{
+		{
+			name:     "instance container",
+			event:    docker.APIEvents{Type: "container", Action: "start", Actor: docker.APIActor{ID: "abc", Attributes: map[string]string{COACH_LABEL_PROJECT: "project", COACH_LABEL_NODE: "www", COACH_LABEL_INSTANCE: "1"}}},
+			node:     "www",
+			instance: "1",
+			passed:   true,
+		},
+		{
+			name:   "node that is not a target",
+			event:  docker.APIEvents{Type: "container", Action: "start", Actor: docker.APIActor{ID: "def", Attributes: map[string]string{COACH_LABEL_PROJECT: "project", COACH_LABEL_NODE: "db", COACH_LABEL_INSTANCE: "single"}}},
+			node:   "db",
+			passed: false,
+		},
+		{
+			name:   "project network",
+			event:  docker.APIEvents{Type: "network", Action: "create", Actor: docker.APIActor{ID: "ghi", Attributes: map[string]string{COACH_LABEL_PROJECT: "project"}}},
+			passed: false,
+		},
+	}
+
+	for _, test := range tests {
+		event := eventFromDocker(&test.event)
+		if event.Node != test.node || (test.instance != "" && event.Instance != test.instance) {
+			t.Errorf("%s: mapped to node [%s] instance [%s]", test.name, event.Node, event.Instance)
+		}
+		if passed := options.HasNode(event.Node); passed != test.passed {
+			t.Errorf("%s: passed on %v, expected %v", test.name, passed, test.passed)
+		}
+	}
+}
@@ -0,0 +1,1152 @@
+package libs
+
+/**
+ * @file Coach Client and ClientFactory, which keep all images and containers
+ * in memory, without talking to any container backend.
+ *
+ * The fake client is meant for testing nodes.yml layouts and operations
+ * offline.  It uses the same node Docker: settings as the FSouza client, so
+ * that any existing project can be pointed at it by adding a clients.yml entry,
+ * and selecting it in the nodes.yml with "Client: fake":
+ *
+ *   fake:
+ *     Type: fake
+ *     Images:
+ *       - "library/mariadb:latest"
+ *
+ * COMPONENTS:
+ *
+ * Fake_Backend : the in memory image and container store, which records every
+ *   action call that it receives, so that the sequence of actions that an
+ *   operation took can be checked.  The backend returns copies of its images
+ *   and containers, and changes them only while it holds its lock, as nodes
+ *   may be processed concurrently.  Composite actions, such as run, record the
+ *   primitive calls (create, start, attach ...) that they are made of.
+ *
+ * ClientFactory & ClientFactorySettings : The Coach Client factory, and settings
+ * 		that create Client objects from
+ */
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/james-nesbitt/coach/conf"
+	"github.com/james-nesbitt/coach/log"
+)
+
+/**
+ * Coach: ClientFactory
+ */
+type Fake_ClientFactorySettings struct {
+	Images []string `json:"Images,omitempty" yaml:"Images,omitempty"` // images that the backend should start off with
+}
+
+func (settings *Fake_ClientFactorySettings) Settings() interface{} {
+	return settings
+}
+
+type Fake_ClientFactory struct {
+	settings Fake_ClientFactorySettings
+	log      log.Log
+	conf     *conf.Project
+	backend  *Fake_Backend
+}
+
+// Provide a unique string identifier for this client-factory/type
+func (clientFactory *Fake_ClientFactory) Id() string {
+	return "Fake:Memory"
+}
+
+// Return a boolean for if this client factory matches the requirements
+// @note the fake never matches docker requirements, so that real nodes can't be routed to it by accident
+func (clientFactory *Fake_ClientFactory) Match(requirements FactoryMatchRequirements) bool {
+	clientFactory.log.Debug(log.VERBOSITY_DEBUG_STAAAP, "Match test for Fake client factory:", requirements.Type)
+	return requirements.Type == "fake" || requirements.ID == clientFactory.Id() || requirements.Class == "Fake_ClientFactory"
+}
+
+func (clientFactory *Fake_ClientFactory) Init(logger log.Log, project *conf.Project, settings ClientFactorySettings) bool {
+	clientFactory.log = logger
+	clientFactory.conf = project
+
+	// make sure that the settings that were given, where the proper "Fake_ClientFactory" type
+	typedSettings := settings.Settings()
+	switch asserted := typedSettings.(type) {
+	case *Fake_ClientFactorySettings:
+		clientFactory.settings = *asserted
+	default:
+		logger.Error("Invalid settings type passed to Fake Factory")
+		logger.Debug(log.VERBOSITY_DEBUG, "Settings passed:", asserted)
+		return false
+	}
+
+	if clientFactory.backend == nil {
+		clientFactory.backend = &Fake_Backend{}
+		clientFactory.backend.Init(logger.MakeChild("backend"), clientFactory.settings)
+	}
+	return true
+}
+
+// Give access to the in memory backend, so that state and recorded calls can be inspected
+func (clientFactory *Fake_ClientFactory) Backend() *Fake_Backend {
+	return clientFactory.backend
+}
+
+// Get an actual client object from the Factory, from settings
+func (clientFactory *Fake_ClientFactory) MakeClient(logger log.Log, settings ClientSettings) (Client, bool) {
+	client := &Fake_Client{backend: clientFactory.backend}
+	return Client(client), client.Init(logger, clientFactory.conf, settings)
+}
+
+/**
+ * Fake Backend
+ */
+
+// A single recorded action call
+type Fake_Call struct {
+	Action string   // the client method that was called, such as "create"
+	Target string   // the image or container name that the action was run against
+	Args   []string // any additional arguments, such as a run command or commit tag
+}
+
+// String representation of a call, usefull for comparing call sequences
+func (call Fake_Call) String() string {
+	parts := []string{call.Action, call.Target}
+	if len(call.Args) > 0 {
+		parts = append(parts, strings.Join(call.Args, " "))
+	}
+	return strings.Join(parts, ":")
+}
+
+// An in memory image
+type Fake_Image struct {
+	ID     string
+	Name   string // repo:tag
+	Labels map[string]string
+}
+
+// An in memory container
+type Fake_Container struct {
+	ID     string
+	Name   string
+	Image  string
+	Cmd    []string
+	Labels map[string]string
+
+	Networks map[string][]string // network name => aliases
+	Binds    []string
+
+	Running   bool
+	Paused    bool
+	Unhealthy bool     // fail any health checks
+	Output    []string // lines returned as the container logs
+	ExitCode  int      // exit code returned by exec and run commands
+
+	Stats InstanceStats // resource usage returned while the container is running
+}
+
+// An in memory named volume
+type Fake_Volume struct {
+	Name    string
+	Driver  string
+	Options map[string]string
+	Labels  map[string]string
+}
+
+// An in memory network
+type Fake_Network struct {
+	Name   string
+	Labels map[string]string
+}
+
+type Fake_Backend struct {
+	log  log.Log
+	lock sync.RWMutex // nodes may be processed concurrently
+
+	images     map[string]*Fake_Image
+	containers map[string]*Fake_Container
+	networks   map[string]*Fake_Network
+	volumes    map[string]*Fake_Volume
+
+	calls []Fake_Call
+	count int // used to generate IDs
+}
+
+// Init constructor for the backend
+func (backend *Fake_Backend) Init(logger log.Log, settings Fake_ClientFactorySettings) bool {
+	backend.log = logger
+	backend.images = map[string]*Fake_Image{}
+	backend.containers = map[string]*Fake_Container{}
+	backend.networks = map[string]*Fake_Network{}
+	backend.volumes = map[string]*Fake_Volume{}
+	backend.calls = []Fake_Call{}
+
+	for _, image := range settings.Images {
+		backend.AddImage(image)
+	}
+	return true
+}
+
+// Record an action call
+func (backend *Fake_Backend) record(action string, target string, args ...string) {
+	backend.log.Debug(log.VERBOSITY_DEBUG_LOTS, "Fake call: "+action+" => "+target, args)
+
+	backend.lock.Lock()
+	defer backend.lock.Unlock()
+	backend.calls = append(backend.calls, Fake_Call{Action: action, Target: target, Args: args})
+}
+
+// Return all of the calls recorded, in the order that they were received
+func (backend *Fake_Backend) Calls() []Fake_Call {
+	backend.lock.RLock()
+	defer backend.lock.RUnlock()
+	return append([]Fake_Call{}, backend.calls...)
+}
+
+// Forget any recorded calls
+func (backend *Fake_Backend) ResetCalls() {
+	backend.lock.Lock()
+	defer backend.lock.Unlock()
+	backend.calls = []Fake_Call{}
+}
+
+// Make a new ID (the caller must hold the backend lock)
+func (backend *Fake_Backend) makeID() string {
+	backend.count++
+	return fmt.Sprintf("%012x", backend.count)
+}
+
+// Add an image to the backend, without recording a call
+func (backend *Fake_Backend) AddImage(name string) Fake_Image {
+	return backend.addLabelledImage(name, nil)
+}
+
+// Add an image, or replace the labels of an existing image
+func (backend *Fake_Backend) addLabelledImage(name string, labels map[string]string) Fake_Image {
+	name = fakeImageName(name)
+
+	backend.lock.Lock()
+	defer backend.lock.Unlock()
+	image, ok := backend.images[name]
+	if !ok {
+		image = &Fake_Image{ID: backend.makeID(), Name: name}
+		backend.images[name] = image
+	}
+	if labels != nil {
+		image.Labels = labels
+	}
+	return *image
+}
+func (backend *Fake_Backend) Image(name string) (Fake_Image, bool) {
+	backend.lock.RLock()
+	defer backend.lock.RUnlock()
+	if image, ok := backend.images[fakeImageName(name)]; ok {
+		return *image, true
+	}
+	return Fake_Image{}, false
+}
+func (backend *Fake_Backend) removeImage(name string) {
+	backend.lock.Lock()
+	defer backend.lock.Unlock()
+	delete(backend.images, fakeImageName(name))
+}
+
+// Return all images, ordered by name
+func (backend *Fake_Backend) Images() []Fake_Image {
+	backend.lock.RLock()
+	defer backend.lock.RUnlock()
+
+	names := []string{}
+	for name := range backend.images {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	images := []Fake_Image{}
+	for _, name := range names {
+		images = append(images, *backend.images[name])
+	}
+	return images
+}
+
+func (backend *Fake_Backend) Container(name string) (Fake_Container, bool) {
+	backend.lock.RLock()
+	defer backend.lock.RUnlock()
+	if container, ok := backend.containers[name]; ok {
+		return *container, true
+	}
+	return Fake_Container{}, false
+}
+
+// Change a container while holding the backend lock, returning false if there is no such container
+func (backend *Fake_Backend) UpdateContainer(name string, update func(container *Fake_Container)) bool {
+	backend.lock.Lock()
+	defer backend.lock.Unlock()
+	container, ok := backend.containers[name]
+	if ok {
+		update(container)
+	}
+	return ok
+}
+func (backend *Fake_Backend) addContainer(container *Fake_Container) {
+	backend.lock.Lock()
+	defer backend.lock.Unlock()
+	container.ID = backend.makeID()
+	backend.containers[container.Name] = container
+}
+func (backend *Fake_Backend) removeContainer(name string) {
+	backend.lock.Lock()
+	defer backend.lock.Unlock()
+	delete(backend.containers, name)
+}
+
+// Return all containers, ordered by name
+func (backend *Fake_Backend) Containers() []Fake_Container {
+	backend.lock.RLock()
+	defer backend.lock.RUnlock()
+
+	names := []string{}
+	for name := range backend.containers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	containers := []Fake_Container{}
+	for _, name := range names {
+		containers = append(containers, *backend.containers[name])
+	}
+	return containers
+}
+
+func (backend *Fake_Backend) Volume(name string) (volume *Fake_Volume, ok bool) {
+	backend.lock.RLock()
+	defer backend.lock.RUnlock()
+	volume, ok = backend.volumes[name]
+	return
+}
+func (backend *Fake_Backend) addVolume(volume *Fake_Volume) {
+	backend.lock.Lock()
+	defer backend.lock.Unlock()
+	backend.volumes[volume.Name] = volume
+}
+func (backend *Fake_Backend) removeVolume(name string) {
+	backend.lock.Lock()
+	defer backend.lock.Unlock()
+	delete(backend.volumes, name)
+}
+
+// Return the names of all containers that bind a volume, ordered by name
+func (backend *Fake_Backend) VolumeContainers(name string) []string {
+	names := []string{}
+	for _, container := range backend.Containers() {
+		for _, bind := range container.Binds {
+			if strings.SplitN(bind, ":", 2)[0] == name {
+				names = append(names, container.Name)
+				break
+			}
+		}
+	}
+	return names
+}
+
+func (backend *Fake_Backend) Network(name string) (network *Fake_Network, ok bool) {
+	backend.lock.RLock()
+	defer backend.lock.RUnlock()
+	network, ok = backend.networks[name]
+	return
+}
+
+// Add a network if it doesn't already exist, returning true if it was added
+func (backend *Fake_Backend) ensureNetwork(name string, labels map[string]string) bool {
+	backend.lock.Lock()
+	defer backend.lock.Unlock()
+	if _, ok := backend.networks[name]; ok {
+		return false
+	}
+	backend.networks[name] = &Fake_Network{Name: name, Labels: labels}
+	return true
+}
+func (backend *Fake_Backend) removeNetwork(name string) {
+	backend.lock.Lock()
+	defer backend.lock.Unlock()
+	delete(backend.networks, name)
+}
+
+// Return the names of all containers that have joined a network, ordered by name
+func (backend *Fake_Backend) NetworkContainers(name string) []string {
+	names := []string{}
+	for _, container := range backend.Containers() {
+		if _, ok := container.Networks[name]; ok {
+			names = append(names, container.Name)
+		}
+	}
+	return names
+}
+
+// image names without a tag are "latest" images (a registry host may also contain a ":")
+func fakeImageName(name string) string {
+	if !strings.Contains(name[strings.LastIndex(name, "/")+1:], ":") {
+		name += ":latest"
+	}
+	return strings.ToLower(name)
+}
+
+/**
+ * Coach: Client
+ */
+
+// Fake Coach Client object, which uses the node Docker: settings
+type Fake_Client struct {
+	settings FSouza_ClientSettings
+	log      log.Log
+	conf     *conf.Project
+	backend  *Fake_Backend
+
+	id     string
+	nodeId string
+}
+
+type Fake_NodeClient struct {
+	*Fake_Client
+	node Node
+}
+
+type Fake_InstancesClient struct {
+	*Fake_Client
+	instances Instances
+}
+
+type Fake_InstanceClient struct {
+	*Fake_Client
+	instance Instance
+}
+
+func (client *Fake_Client) Init(logger log.Log, project *conf.Project, settings ClientSettings) bool {
+	client.log = logger
+	client.conf = project
+
+	// the fake client accepts the same node settings as the FSouza client
+	settingsTyped := settings.Settings()
+	switch asserted := settingsTyped.(type) {
+	case *FSouza_ClientSettings:
+		client.settings = *asserted
+		client.settings.Init(logger, project)
+		return true
+	default:
+		logger.Error("Invalid settings type passed to Fake Client")
+		return false
+	}
+}
+func (client *Fake_Client) Prepare(logger log.Log, nodes *Nodes, node Node) bool {
+	client.id = node.MachineName()
+	client.nodeId = node.Id()
+
+	// the settings object has it's own Prepare, which collects dependencies
+	client.settings.Prepare(logger, nodes)
+
+	return true
+}
+
+func (client *Fake_Client) NodeClient(node Node) NodeClient {
+	return NodeClient(&Fake_NodeClient{Fake_Client: client, node: node})
+}
+func (client *Fake_Client) InstancesClient(instances Instances) InstancesClient {
+	return InstancesClient(&Fake_InstancesClient{Fake_Client: client, instances: instances})
+}
+func (client *Fake_Client) InstanceClient(instance Instance) InstanceClient {
+	return InstanceClient(&Fake_InstanceClient{Fake_Client: client, instance: instance})
+}
+
+// The coach labels used to stamp and match client resources, for the node or one of its instances
+func (client *Fake_Client) labels(instanceId string) map[string]string {
+	return CoachLabels(client.conf, client.nodeId, instanceId)
+}
+func (client *Fake_Client) matchLabels(instanceId string) map[string]string {
+	return CoachMatchLabels(client.conf, client.nodeId, instanceId)
+}
+
+func (client *Fake_Client) Can(action string) bool {
+	switch action {
+	case "build":
+		return client.settings.BuildPath != ""
+	case "pull":
+		return client.settings.BuildPath == "" && client.settings.Config.Image != ""
+	case "volume":
+		return client.settings.Volume != nil
+	default:
+		return true
+	}
+}
+
+func (client *Fake_Client) DependsOn(target string) bool {
+	_, ok := client.settings.dependencies.Dependency(target)
+	return ok
+}
+
+// The image name that this client would use, following the same rules as the FSouza client
+func (client *Fake_Client) GetImageName() string {
+	image := strings.ToLower(client.id)
+	if client.settings.Config.Image != "" {
+		image = client.settings.Config.Image
+	}
+	return fakeImageName(image)
+}
+
+/**
+ * NodeClient interface
+ */
+
+func (client *Fake_NodeClient) HasImage() bool {
+	_, ok := client.backend.Image(client.GetImageName())
+	return ok
+}
+
+func (client *Fake_NodeClient) HasVolume() bool {
+	_, ok := client.backend.Volume(NodeVolumeName(client.node))
+	return ok
+}
+
+func (client *Fake_NodeClient) NodeInfo(logger log.Log) {
+	if client.settings.Volume != nil {
+		name := NodeVolumeName(client.node)
+		if volume, ok := client.backend.Volume(name); !ok {
+			logger.Message("|-- no volume [" + name + "]")
+		} else {
+			logger.Message("|-> Volume")
+
+			w := new(tabwriter.Writer)
+			w.Init(logger, 8, 12, 2, ' ', 0)
+
+			w.Write([]byte(strings.Join([]string{"|=", "Name", "Driver"}, "\t") + "\n"))
+			w.Write([]byte(strings.Join([]string{"|-", volume.Name, volume.Driver}, "\t") + "\n"))
+			w.Flush()
+		}
+		return
+	}
+
+	image, ok := client.backend.Image(client.GetImageName())
+
+	if !ok {
+		logger.Message("|-- no image [" + client.node.MachineName() + "]")
+	} else {
+		logger.Message("|-> Images")
+
+		w := new(tabwriter.Writer)
+		w.Init(logger, 8, 12, 2, ' ', 0)
+
+		w.Write([]byte(strings.Join([]string{"|=", "ID", "RepoTags"}, "\t") + "\n"))
+		w.Write([]byte(strings.Join([]string{"|-", image.ID, image.Name}, "\t") + "\n"))
+		w.Flush()
+	}
+}
+
+func (client *Fake_NodeClient) Report() NodeReport {
+	report := NodeReport{}
+
+	if client.settings.Volume != nil {
+		if volume, ok := client.backend.Volume(NodeVolumeName(client.node)); ok {
+			report.Volume = &VolumeReport{Name: volume.Name, Driver: volume.Driver}
+		}
+		return report
+	}
+
+	if image, ok := client.backend.Image(client.GetImageName()); ok {
+		report.HasImage = true
+		report.Images = []ImageReport{{ID: image.ID, Tags: []string{image.Name}}}
+	}
+	return report
+}
+
+func (client *Fake_NodeClient) Build(logger log.Log, force bool, options BuildOptions) error {
+	image := client.GetImageName()
+	args := []string{}
+	if options.NoCache {
+		args = append(args, "--no-cache")
+	}
+	if options.Pull {
+		args = append(args, "--pull")
+	}
+	client.backend.record("build", image, args...)
+
+	if client.settings.BuildPath == "" {
+		logger.Warning("Node image [" + image + "] not built as an empty path was provided.  You must point Build: to a path inside .coach")
+		return NewClientError(ERROR_INVALID, "build", image, errors.New("no build path"))
+	}
+	if !force && client.HasImage() {
+		logger.Info("Node image [" + image + "] not built as an image already exists.  You can force this operation to build this image")
+		return NewClientError(ERROR_IMAGE_EXISTS, "build", image, nil)
+	}
+
+	client.backend.addLabelledImage(image, MergeCoachLabels(client.settings.BuildLabels, client.labels("")))
+	logger.Message("Node succesfully built image [" + image + "] From path [" + client.settings.BuildPath + "]")
+	return nil
+}
+
+func (client *Fake_NodeClient) Destroy(logger log.Log, force bool) error {
+	image := client.GetImageName()
+	client.backend.record("destroy", image)
+
+	if !client.HasImage() {
+		logger.Warning("Node has no image to destroy [" + image + "]")
+		return NewClientError(ERROR_IMAGE_NOT_FOUND, "destroy", image, nil)
+	}
+	if !force {
+		for _, container := range client.backend.Containers() {
+			if container.Image == image {
+				logger.Error("Node image removal failed [" + image + "] => image is in use by container " + container.Name)
+				return NewClientError(ERROR_CONFLICT, "destroy", image, errors.New("image is in use by container "+container.Name))
+			}
+		}
+	}
+
+	client.backend.removeImage(image)
+	logger.Message("Node image was removed [" + image + "]")
+	return nil
+}
+
+func (client *Fake_NodeClient) Pull(logger log.Log, force bool) error {
+	image := client.GetImageName()
+	client.backend.record("pull", image)
+
+	if !force && client.HasImage() {
+		logger.Info("Node already has an image [" + image + "], so not pulling it again.  You can force this operation if you want to pull this image.")
+		return NewClientError(ERROR_IMAGE_EXISTS, "pull", image, nil)
+	}
+
+	client.backend.AddImage(image)
+	logger.Message("Node image pulled: " + image)
+	return nil
+}
+
+func (client *Fake_NodeClient) Push(logger log.Log, repository string, tag string) error {
+	image, imageTag := dockerSplitImageTag(client.GetImageName())
+	if client.settings.Repository != "" {
+		image = client.settings.Repository
+	}
+	if tag == "" {
+		tag = imageTag
+	}
+	if repository == "" {
+		repository = image
+	}
+	client.backend.record("push", repository+":"+tag)
+
+	if _, found := client.backend.Image(image + ":" + tag); !found {
+		logger.Error("Node image not pushed, as there is no image : " + image + ":" + tag)
+		return NewClientError(ERROR_IMAGE_NOT_FOUND, "push", image+":"+tag, nil)
+	}
+	if image != repository {
+		client.backend.AddImage(repository + ":" + tag)
+	}
+
+	logger.Message("Node image pushed: " + repository + ":" + tag)
+	return nil
+}
+
+func (client *Fake_NodeClient) CreateVolume(logger log.Log) error {
+	name := NodeVolumeName(client.node)
+	client.backend.record("volume-create", name)
+
+	if client.settings.Volume == nil {
+		logger.Warning("Node has no Volume: settings, so no volume will be created [" + name + "]")
+		return NewClientError(ERROR_INVALID, "create-volume", name, errors.New("no Volume: settings"))
+	}
+	if client.HasVolume() {
+		logger.Info("Node volume already exists [" + name + "]")
+		return NewClientError(ERROR_VOLUME_EXISTS, "create-volume", name, nil)
+	}
+
+	driver := client.settings.Volume.Driver
+	if driver == "" {
+		driver = "local"
+	}
+	client.backend.addVolume(&Fake_Volume{
+		Name:    name,
+		Driver:  driver,
+		Options: client.settings.Volume.Options,
+		Labels:  MergeCoachLabels(client.settings.Volume.Labels, client.labels("")),
+	})
+	logger.Message("Created node volume [" + name + "]")
+	return nil
+}
+
+func (client *Fake_NodeClient) RemoveVolume(logger log.Log, force bool) error {
+	name := NodeVolumeName(client.node)
+	client.backend.record("volume-remove", name)
+
+	if !client.HasVolume() {
+		logger.Warning("Node has no volume to remove [" + name + "]")
+		return NewClientError(ERROR_VOLUME_NOT_FOUND, "remove-volume", name, nil)
+	}
+	if containers := client.backend.VolumeContainers(name); len(containers) > 0 && !force {
+		logger.Error("Node volume removal failed [" + name + "] => the volume is still in use by container " + containers[0])
+		return NewClientError(ERROR_CONFLICT, "remove-volume", name, errors.New("the volume is still in use by container "+containers[0]))
+	}
+
+	client.backend.removeVolume(name)
+	logger.Message("Node volume was removed [" + name + "]")
+	return nil
+}
+
+func (client *Fake_NodeClient) RemoveNetworks(logger log.Log) error {
+	labels := ProjectNetworkLabels(client.conf)
+	delete(labels, COACH_LABEL_ENVIRONMENT)
+
+	for _, name := range nodeNetworkNames(client.conf, client.settings) {
+		// only the project network belongs to coach, any other networks are shared and never removed
+		if name != ProjectNetworkName(client.conf) {
+			continue
+		}
+		network, ok := client.backend.Network(name)
+		if !ok {
+			continue
+		}
+		client.backend.record("network-remove", name)
+
+		if !MatchCoachLabels(network.Labels, labels) {
+			logger.Info("Network was not created by coach for this project, so it will not be removed [" + name + "]")
+		} else if containers := client.backend.NetworkContainers(name); len(containers) > 0 {
+			logger.Info("Network is still in use by " + strconv.Itoa(len(containers)) + " containers, so it will not be removed [" + name + "]")
+		} else {
+			client.backend.removeNetwork(name)
+			logger.Message("Removed network [" + name + "]")
+		}
+	}
+	return nil
+}
+
+// The fake backend has no event stream, so there are never any events
+func (client *Fake_NodeClient) Events(logger log.Log, options EventsOptions, events chan<- ProjectEvent) error {
+	client.backend.record("events", client.conf.Name, "--since="+options.Since, "--until="+options.Until, "--nodes="+strings.Join(options.Nodes, ","))
+	return nil
+}
+
+// All nodes that use the same fake backend share an event stream
+func (client *Fake_NodeClient) EventsStream() interface{} {
+	return client.backend
+}
+
+/**
+ * InstancesClient interface
+ */
+
+func (client *Fake_InstancesClient) InstancesFound(logger log.Log) []string {
+	prefix := client.instances.MachineName()
+
+	ids := []string{}
+	if prefix == INSTANCES_NULL_MACHINENAME {
+		return ids
+	}
+	labels := client.matchLabels("")
+	for _, container := range client.backend.Containers() {
+		if !MatchCoachLabels(container.Labels, labels) {
+			continue
+		}
+		if id, ok := container.Labels[COACH_LABEL_INSTANCE]; ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func (client *Fake_InstancesClient) InstancesInfo(logger log.Log) {
+	instances := client.instances
+
+	if instances.MachineName() == INSTANCES_NULL_MACHINENAME {
+
+	} else if len(instances.InstancesOrder()) == 0 {
+		logger.Message("|-= no containers")
+	} else {
+		logger.Message("|-> instances (containers) MachineName:" + instances.MachineName())
+
+		w := new(tabwriter.Writer)
+		w.Init(logger, 8, 12, 2, ' ', 0)
+
+		row := []string{
+			"|=",
+			"Name",
+			"Container",
+			"Default",
+			"Created",
+			"Running",
+			"Paused",
+			"ID",
+		}
+		w.Write([]byte(strings.Join(row, "\t") + "\n"))
+
+		for _, name := range instances.InstancesOrder() {
+			instance, _ := instances.Instance(name)
+			machineName := instance.MachineName()
+			container, hasContainer := client.backend.Container(machineName)
+
+			row := []string{
+				"|-",
+				name,
+				machineName,
+				fakeYesNo(instance.IsDefault()),
+				fakeYesNo(hasContainer),
+				fakeYesNo(hasContainer && container.Running),
+				fakeYesNo(hasContainer && container.Paused),
+			}
+			if hasContainer {
+				row = append(row, container.ID)
+			}
+
+			w.Write([]byte(strings.Join(row, "\t") + "\n"))
+		}
+		w.Flush()
+	}
+}
+
+func fakeYesNo(value bool) string {
+	if value {
+		return "yes"
+	}
+	return "no"
+}
+
+/**
+ * InstanceClient interface
+ */
+
+func (client *Fake_InstanceClient) HasContainer() bool {
+	_, ok := client.backend.Container(client.instance.MachineName())
+	return ok
+}
+func (client *Fake_InstanceClient) IsRunning() bool {
+	container, ok := client.backend.Container(client.instance.MachineName())
+	return ok && container.Running
+}
+func (client *Fake_InstanceClient) Report() InstanceReport {
+	report := InstanceReport{Container: client.instance.MachineName()}
+	if container, ok := client.backend.Container(client.instance.MachineName()); ok {
+		report.HasContainer = true
+		report.Running = container.Running
+		report.ID = container.ID
+		report.Image = container.Image
+		switch {
+		case container.Paused:
+			report.State = "paused"
+		case container.Running:
+			report.State = "running"
+		default:
+			report.State = "exited"
+		}
+	}
+	return report
+}
+func (client *Fake_InstanceClient) Inspect(logger log.Log) (InstanceInspect, error) {
+	instance := client.instance
+	inspect := makeInstanceInspect(client.conf, client.settings, client.nodeId, instance, client.GetImageName(), MergeCoachLabels(client.settings.Config.Labels, client.labels(instance.Id())))
+
+	if container, ok := client.backend.Container(instance.MachineName()); ok {
+		inspect.Container = container
+	}
+	return inspect, nil
+}
+func (client *Fake_InstanceClient) IsReady() bool {
+	ready, _ := client.readyCheck()
+	return ready
+}
+func (client *Fake_InstanceClient) WaitReady(logger log.Log) error {
+	client.backend.record("wait-ready", client.instance.MachineName())
+	return waitForReady(logger, client.instance.MachineName(), client.settings.Healthcheck, client.readyCheck)
+}
+
+// The fake health checks pass for any running container that isn't marked as unhealthy
+func (client *Fake_InstanceClient) readyCheck() (bool, string) {
+	if client.settings.Healthcheck == nil {
+		return client.HasContainer(), "instance has no container"
+	}
+
+	container, ok := client.backend.Container(client.instance.MachineName())
+	if !ok || !container.Running {
+		return false, "container is not running"
+	} else if container.Unhealthy {
+		return false, "container is unhealthy"
+	}
+	return true, ""
+}
+
+func (client *Fake_InstanceClient) Attach(logger log.Log, options AttachOptions) error {
+	name := client.instance.MachineName()
+	client.backend.record("attach", name, "--detach-keys="+options.DetachKeys, "--stdin="+strconv.FormatBool(options.Stdin), "--logs="+strconv.FormatBool(options.Logs))
+
+	if !client.IsRunning() {
+		logger.Error("Failed to attach to instance container [" + name + "] => container is not running")
+		return NewClientError(ERROR_NOT_RUNNING, "attach", name, nil)
+	}
+
+	logger.Message("Attaching to instance container [" + name + "]")
+	return nil
+}
+
+func (client *Fake_InstanceClient) Create(logger log.Log, overrideCmd []string, force bool) error {
+	name := client.instance.MachineName()
+	image := client.GetImageName()
+	client.backend.record("create", name, overrideCmd...)
+
+	if client.HasContainer() {
+		if !force {
+			logger.Info("[" + name + "]: Skipping node instance, which already has a container")
+			return NewClientError(ERROR_CONTAINER_EXISTS, "create", name, nil)
+		}
+		logger.Error("Failed to create instance container [" + name + " FROM " + image + "] => container already exists")
+		return NewClientError(ERROR_CONFLICT, "create", name, errors.New("container already exists"))
+	}
+	if _, ok := client.backend.Image(image); !ok {
+		logger.Error("Failed to create instance container [" + name + " FROM " + image + "] => no such image")
+		return NewClientError(ERROR_IMAGE_NOT_FOUND, "create", image, nil)
+	}
+
+	cmd := client.settings.Config.Cmd
+	if len(overrideCmd) > 0 {
+		cmd = overrideCmd
+	}
+
+	container := &Fake_Container{
+		Name:  name,
+		Image: image,
+		Cmd:   cmd,
+
+		Labels: MergeCoachLabels(client.settings.Config.Labels, client.labels(client.instance.Id())),
+
+		Networks: map[string][]string{},
+		Binds:    client.settings.Host.Binds,
+	}
+
+	aliases := instanceNetworkAliases(client.nodeId, client.instance)
+	for _, network := range nodeNetworkNames(client.conf, client.settings) {
+		if client.backend.ensureNetwork(network, networkCreateLabels(client.conf, network)) {
+			logger.Message("Created network [" + network + "]")
+		}
+		container.Networks[network] = aliases
+	}
+	client.backend.addContainer(container)
+
+	logger.Message("Created instance container [" + name + "] => " + container.ID)
+	return nil
+}
+
+func (client *Fake_InstanceClient) Remove(logger log.Log, force bool) error {
+	name := client.instance.MachineName()
+	client.backend.record("remove", name)
+
+	container, ok := client.backend.Container(name)
+	if !ok {
+		logger.Error("Failed to remove instance container [" + name + "] => no such container")
+		return NewClientError(ERROR_CONTAINER_NOT_FOUND, "remove", name, nil)
+	}
+	if container.Running && !force {
+		logger.Error("Failed to remove instance container [" + name + "] => container is running")
+		return NewClientError(ERROR_CONFLICT, "remove", name, errors.New("container is running"))
+	}
+
+	client.backend.removeContainer(name)
+	logger.Message("Removed instance container [" + name + "] ")
+	return nil
+}
+
+func (client *Fake_InstanceClient) Start(logger log.Log, force bool) error {
+	name := client.instance.MachineName()
+	client.backend.record("start", name)
+
+	if !client.backend.UpdateContainer(name, func(container *Fake_Container) { container.Running = true }) {
+		logger.Error("Failed to start node container [" + name + "] => no such container")
+		return NewClientError(ERROR_CONTAINER_NOT_FOUND, "start", name, nil)
+	}
+
+	logger.Message("Node instance started [" + name + "]")
+	return nil
+}
+
+func (client *Fake_InstanceClient) Stop(logger log.Log, force bool, timeout uint) error {
+	name := client.instance.MachineName()
+	client.backend.record("stop", name)
+
+	stopped := client.backend.UpdateContainer(name, func(container *Fake_Container) {
+		container.Running = false
+		container.Paused = false
+	})
+	if !stopped {
+		logger.Error("Failed to stop node container [" + name + "] => no such container")
+		return NewClientError(ERROR_CONTAINER_NOT_FOUND, "stop", name, nil)
+	}
+
+	logger.Message("Node instance stopped [" + name + "]")
+	return nil
+}
+
+func (client *Fake_InstanceClient) Pause(logger log.Log) error {
+	name := client.instance.MachineName()
+	client.backend.record("pause", name)
+
+	paused := false
+	client.backend.UpdateContainer(name, func(container *Fake_Container) {
+		if container.Running {
+			container.Paused = true
+			paused = true
+		}
+	})
+	if !paused {
+		logger.Error("Failed to pause intance [" + client.instance.Id() + "] Container [" + name + "] => container is not running")
+		return NewClientError(ERROR_NOT_RUNNING, "pause", name, nil)
+	}
+
+	logger.Message("Paused instance [" + client.instance.Id() + "] Container [" + name + "]")
+	return nil
+}
+
+func (client *Fake_InstanceClient) Unpause(logger log.Log) error {
+	name := client.instance.MachineName()
+	client.backend.record("unpause", name)
+
+	unpaused := false
+	client.backend.UpdateContainer(name, func(container *Fake_Container) {
+		if container.Paused {
+			container.Paused = false
+			unpaused = true
+		}
+	})
+	if !unpaused {
+		logger.Error("Failed to unpause Instance [" + client.instance.Id() + "] Container [" + name + "] => container is not paused")
+		return NewClientError(ERROR_CONFLICT, "unpause", name, errors.New("container is not paused"))
+	}
+
+	logger.Message("Unpaused Instance [" + client.instance.Id() + "] Container [" + name + "]")
+	return nil
+}
+
+func (client *Fake_InstanceClient) Commit(logger log.Log, tag string, message string) error {
+	name := client.instance.MachineName()
+	client.backend.record("commit", name, tag, message)
+
+	if !client.HasContainer() {
+		logger.Warning("Failed to commit container changes to an image [" + client.instance.Id() + ":" + name + "] : " + tag)
+		return NewClientError(ERROR_CONTAINER_NOT_FOUND, "commit", name, nil)
+	}
+
+	repo := client.settings.Repository
+	if repo == "" {
+		repo, _ = dockerSplitImageTag(client.GetImageName())
+	}
+	if tag == "" {
+		tag = "latest"
+	}
+
+	client.backend.addLabelledImage(repo+":"+tag, MergeCoachLabels(client.settings.Config.Labels, client.labels("")))
+	logger.Message("Committed container changes to an image [" + client.instance.Id() + ":" + name + "] : " + tag)
+	return nil
+}
+
+func (client *Fake_InstanceClient) Logs(logger log.Log, output io.Writer, options LogsOptions) error {
+	name := client.instance.MachineName()
+	client.backend.record("logs", name, "--tail="+options.Tail, "--since="+options.Since, "--follow="+strconv.FormatBool(options.Follow))
+
+	container, ok := client.backend.Container(name)
+	if !ok {
+		logger.Error("Failed to read instance container logs [" + name + "] => no such container")
+		return NewClientError(ERROR_CONTAINER_NOT_FOUND, "logs", name, nil)
+	}
+
+	lines := container.Output
+	if tail, err := strconv.Atoi(options.Tail); err == nil && tail >= 0 && tail < len(lines) {
+		lines = lines[len(lines)-tail:]
+	}
+	for _, line := range lines {
+		io.WriteString(output, line+"\n")
+	}
+	return nil
+}
+
+func (client *Fake_InstanceClient) Exec(logger log.Log, cmd []string, options ExecOptions) (int, error) {
+	name := client.instance.MachineName()
+	client.backend.record("exec", name, cmd...)
+
+	container, ok := client.backend.Container(name)
+	if !ok || !container.Running {
+		logger.Error("Failed to run exec in instance container [" + name + "] => container is not running")
+		return -1, NewClientError(ERROR_NOT_RUNNING, "exec", name, nil)
+	}
+	return container.ExitCode, nil
+}
+
+func (client *Fake_InstanceClient) Stats(logger log.Log) (InstanceStats, error) {
+	name := client.instance.MachineName()
+	client.backend.record("stats", name)
+
+	container, ok := client.backend.Container(name)
+	if !ok || !container.Running {
+		logger.Error("Failed to read instance container stats [" + name + "] => container is not running")
+		return InstanceStats{}, NewClientError(ERROR_NOT_RUNNING, "stats", name, nil)
+	}
+	return container.Stats, nil
+}
+
+func (client *Fake_InstanceClient) Upload(logger log.Log, source string, destination string) error {
+	name := client.instance.MachineName()
+	client.backend.record("upload", name, source, destination)
+
+	if !client.HasContainer() {
+		logger.Error("Failed to copy [" + source + "] into instance container [" + name + "] => no such container")
+		return NewClientError(ERROR_CONTAINER_NOT_FOUND, "upload", name, nil)
+	}
+	if _, err := os.Lstat(source); err != nil {
+		logger.Error("Failed to copy [" + source + "] into instance container [" + name + "] => " + err.Error())
+		return NewClientError(ERROR_INVALID, "upload", name, err)
+	}
+	logger.Message("Copied [" + source + "] into instance container [" + name + ":" + destination + "]")
+	return nil
+}
+
+func (client *Fake_InstanceClient) Download(logger log.Log, source string, destination string) error {
+	name := client.instance.MachineName()
+	client.backend.record("download", name, source, destination)
+
+	if !client.HasContainer() {
+		logger.Error("Failed to copy [" + name + ":" + source + "] from instance container => no such container")
+		return NewClientError(ERROR_CONTAINER_NOT_FOUND, "download", name, nil)
+	}
+	logger.Message("Copied [" + name + ":" + source + "] from instance container to [" + destination + "]")
+	return nil
+}
+
+func (client *Fake_InstanceClient) Run(logger log.Log, cmd []string, options RunOptions) (int, error) {
+	name := client.instance.MachineName()
+
+	// run is made of primitive actions, which record their own calls
+	if !client.HasContainer() {
+		if err := client.Create(logger, cmd, false); err != nil {
+			logger.Error("Could not create RUN container")
+			return -1, err
+		}
+		if !options.Persistant {
+			defer func(client *Fake_InstanceClient, logger log.Log) {
+				if client.IsRunning() {
+					client.Stop(logger, true, 0)
+				}
+				client.Remove(logger, true)
+			}(client, logger)
+		}
+	}
+
+	if err := client.Start(logger, false); err != nil {
+		logger.Error("Could not start RUN container")
+		return -1, err
+	}
+	if err := client.Attach(logger, AttachOptions{Stdin: options.Stdin, Logs: true}); err != nil {
+		return -1, err
+	}
+
+	if container, ok := client.backend.Container(name); ok {
+		return container.ExitCode, nil
+	}
+	return 0, nil
+}
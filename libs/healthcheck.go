@@ -0,0 +1,210 @@
+package libs
+
+/**
+ * @file Coach readiness health checks
+ *
+ * A node can have a Healthcheck: setting, which tells coach how to decide
+ * that a node instance is ready to be used by other nodes, which is often
+ * later than the moment that its container starts.  The up and start
+ * operations wait for the instances of any node that a target depends on
+ * to be ready, before starting the target.
+ *
+ * Any combination of the following checks can be used, all of which must
+ * pass for an instance to be ready:
+ *
+ *   Healthcheck:
+ *     Port: 3306             # a TCP port that must accept connections
+ *     Command: ["mysqladmin", "ping"]  # a command exec that must exit 0
+ *     Log: "ready for connections"     # a regex to find in the logs
+ *     Docker: true           # the docker HEALTHCHECK status must be healthy
+ *     Timeout: 60            # seconds to wait before failing
+ *     Interval: 1            # seconds between checks
+ */
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"time"
+
+	docker "github.com/fsouza/go-dockerclient"
+
+	"github.com/james-nesbitt/coach/log"
+)
+
+const (
+	HEALTHCHECK_DEFAULT_TIMEOUT  = 60 // seconds to wait for an instance to be ready
+	HEALTHCHECK_DEFAULT_INTERVAL = 1  // seconds between readiness checks
+
+	HEALTHCHECK_DIAL_TIMEOUT = time.Second
+)
+
+// Readiness health check settings for a node
+type FSouza_HealthcheckSettings struct {
+	Port     int      `json:"Port,omitempty" yaml:"Port,omitempty"`
+	Command  []string `json:"Command,omitempty" yaml:"Command,omitempty"`
+	Log      string   `json:"Log,omitempty" yaml:"Log,omitempty"`
+	Docker   bool     `json:"Docker,omitempty" yaml:"Docker,omitempty"`
+	Timeout  int      `json:"Timeout,omitempty" yaml:"Timeout,omitempty"`
+	Interval int      `json:"Interval,omitempty" yaml:"Interval,omitempty"`
+}
+
+func (settings *FSouza_HealthcheckSettings) timeout() time.Duration {
+	if settings.Timeout > 0 {
+		return time.Duration(settings.Timeout) * time.Second
+	}
+	return HEALTHCHECK_DEFAULT_TIMEOUT * time.Second
+}
+func (settings *FSouza_HealthcheckSettings) interval() time.Duration {
+	if settings.Interval > 0 {
+		return time.Duration(settings.Interval) * time.Second
+	}
+	return HEALTHCHECK_DEFAULT_INTERVAL * time.Second
+}
+
+// Repeat a readiness check until it passes, or until the health check timeout runs out
+func waitForReady(logger log.Log, name string, settings *FSouza_HealthcheckSettings, check func() (bool, string)) error {
+	if settings == nil {
+		// without a health check there is nothing to wait for
+		return nil
+	}
+
+	deadline := time.Now().Add(settings.timeout())
+	for {
+		ready, reason := check()
+		if ready {
+			logger.Info("Instance is ready [" + name + "]")
+			return nil
+		}
+		if time.Now().After(deadline) {
+			logger.Error("Instance did not become ready within " + settings.timeout().String() + " [" + name + "] => " + reason)
+			return NewClientError(ERROR_NOT_READY, "wait-ready", name, errors.New(reason))
+		}
+
+		logger.Debug(log.VERBOSITY_DEBUG, "Waiting for instance to be ready ["+name+"] :", reason)
+		time.Sleep(settings.interval())
+	}
+}
+
+// The address to use for a port check, preferring a port published on the docker host, then the container IP
+func healthcheckAddress(endpoint string, port int, network *docker.NetworkSettings) (string, bool) {
+	if network == nil {
+		return "", false
+	}
+
+	for _, binding := range network.Ports[docker.Port(strconv.Itoa(port)+"/tcp")] {
+		if binding.HostPort == "" {
+			continue
+		}
+		host := binding.HostIP
+		if host == "" || host == "0.0.0.0" || host == "::" {
+			host = dockerHostAddress(endpoint)
+		}
+		return net.JoinHostPort(host, binding.HostPort), true
+	}
+
+	for _, container := range network.Networks {
+		if container.IPAddress != "" {
+			return net.JoinHostPort(container.IPAddress, strconv.Itoa(port)), true
+		}
+	}
+	if network.IPAddress != "" {
+		return net.JoinHostPort(network.IPAddress, strconv.Itoa(port)), true
+	}
+	return "", false
+}
+
+// The address of the docker host, from a docker endpoint (local sockets use the loopback address)
+func dockerHostAddress(endpoint string) string {
+	if parsed, err := url.Parse(endpoint); err == nil {
+		switch parsed.Scheme {
+		case "tcp", "http", "https":
+			if host, _, err := net.SplitHostPort(parsed.Host); err == nil {
+				return host
+			} else if parsed.Host != "" {
+				return parsed.Host
+			}
+		}
+	}
+	return "127.0.0.1"
+}
+
+// Check that an instance port accepts TCP connections
+func healthcheckPort(endpoint string, port int, network *docker.NetworkSettings) (bool, string) {
+	address, ok := healthcheckAddress(endpoint, port, network)
+	if !ok {
+		return false, "no address found for port " + strconv.Itoa(port)
+	}
+	connection, err := net.DialTimeout("tcp", address, HEALTHCHECK_DIAL_TIMEOUT)
+	if err != nil {
+		return false, "port " + strconv.Itoa(port) + " is not accepting connections on " + address
+	}
+	connection.Close()
+	return true, ""
+}
+
+// Check that instance logs contain a line matching a regex
+func healthcheckLog(pattern string, logs string) (bool, string) {
+	expression, err := regexp.Compile(pattern)
+	if err != nil {
+		return false, "invalid log regex [" + pattern + "] => " + err.Error()
+	}
+	if !expression.MatchString(logs) {
+		return false, "no log line matches [" + pattern + "]"
+	}
+	return true, ""
+}
+
+/**
+ * Dependency gating
+ */
+
+// Wait for the instances of any project nodes that a node depends on to be ready.  Dependencies that
+// are targets are waited for on their target instances, as the operation is starting them, while other
+// dependencies are waited for on any of their instances that are already running.
+func (targets *Targets) WaitForDependencies(logger log.Log, node Node) error {
+	dependencyIDs := targets.TargetOrder()
+	if targets.nodes != nil {
+		dependencyIDs = targets.nodes.NodeNames()
+	}
+
+	for _, dependencyID := range dependencyIDs {
+		if dependencyID == node.Id() || !node.DependsOn(dependencyID) {
+			continue
+		}
+
+		waitFor := []Instance{}
+		if target, isTarget := targets.Target(dependencyID); isTarget {
+			dependency, hasNode := target.Node()
+			instances, hasInstances := target.Instances()
+			if !hasNode || !hasInstances || !dependency.Can("start") {
+				continue
+			}
+			for _, id := range instances.InstancesOrder() {
+				instance, _ := instances.Instance(id)
+				waitFor = append(waitFor, instance)
+			}
+		} else {
+			dependency, hasNode := targets.nodes.Node(dependencyID)
+			if !hasNode || !dependency.Can("start") {
+				continue
+			}
+			instances := dependency.Instances()
+			for _, id := range instances.InstancesOrder() {
+				if instance, ok := instances.Instance(id); ok && instance.IsRunning() {
+					waitFor = append(waitFor, instance)
+				}
+			}
+		}
+
+		for _, instance := range waitFor {
+			if err := instance.Client().WaitReady(logger); err != nil {
+				logger.Error("Dependency [" + dependencyID + ":" + instance.Id() + "] is not ready, so node [" + node.Id() + "] will not be started")
+				return err
+			}
+		}
+	}
+	return nil
+}
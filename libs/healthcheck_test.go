@@ -0,0 +1,98 @@
+package libs
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+
+	docker "github.com/fsouza/go-dockerclient"
+
+	"github.com/james-nesbitt/coach/log"
+)
+
+func TestHealthcheckLog(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		logs    string
+		ready   bool
+	}{
+		{name: "matching line", pattern: "ready for connections", logs: "starting\nready for connections\n", ready: true},
+		{name: "regex", pattern: "^listening on [0-9]+$", logs: "listening on 80", ready: true},
+		{name: "no match", pattern: "ready for connections", logs: "starting\n"},
+		{name: "invalid regex", pattern: "ready (", logs: "ready ("},
+	}
+
+	for _, test := range tests {
+		if ready, reason := healthcheckLog(test.pattern, test.logs); ready != test.ready {
+			t.Errorf("%s: ready %v, expected %v (%s)", test.name, ready, test.ready, reason)
+		}
+	}
+}
+
+func TestHealthcheckAddress(t *testing.T) {
+	tests := []struct {
+		name     string
+		endpoint string
+		network  *docker.NetworkSettings
+		address  string
+	}{
+		{name: "no network settings", endpoint: "unix:///var/run/docker.sock"},
+		{
+			name:     "published port on a local socket",
+			endpoint: "unix:///var/run/docker.sock",
+			network:  &docker.NetworkSettings{Ports: map[docker.Port][]docker.PortBinding{"3306/tcp": {{HostIP: "0.0.0.0", HostPort: "13306"}}}},
+			address:  "127.0.0.1:13306",
+		},
+		{
+			name:     "published port on a remote host",
+			endpoint: "tcp://docker.example.com:2376",
+			network:  &docker.NetworkSettings{Ports: map[docker.Port][]docker.PortBinding{"3306/tcp": {{HostPort: "13306"}}}},
+			address:  "docker.example.com:13306",
+		},
+		{
+			name:     "published port on a host IP",
+			endpoint: "tcp://docker.example.com:2376",
+			network:  &docker.NetworkSettings{Ports: map[docker.Port][]docker.PortBinding{"3306/tcp": {{HostIP: "10.0.0.5", HostPort: "13306"}}}},
+			address:  "10.0.0.5:13306",
+		},
+		{
+			name:     "container network IP",
+			endpoint: "unix:///var/run/docker.sock",
+			network:  &docker.NetworkSettings{Networks: map[string]docker.ContainerNetwork{"project_default": {IPAddress: "172.18.0.2"}}},
+			address:  "172.18.0.2:3306",
+		},
+	}
+
+	for _, test := range tests {
+		address, ok := healthcheckAddress(test.endpoint, 3306, test.network)
+		if address != test.address || ok != (test.address != "") {
+			t.Errorf("%s: address [%s] %v, expected [%s]", test.name, address, ok, test.address)
+		}
+	}
+}
+
+func TestWaitForReady(t *testing.T) {
+	logger := log.MakeCliLog("test", ioutil.Discard, log.VERBOSITY_MESSAGE)
+
+	// without a health check, the check is never run
+	if err := waitForReady(logger, "none", nil, func() (bool, string) { return false, "checked" }); err != nil {
+		t.Error("An instance without a health check was not ready: ", err)
+	}
+
+	// the check is repeated until it passes
+	checks := 0
+	settings := &FSouza_HealthcheckSettings{Timeout: 5}
+	start := time.Now()
+	if err := waitForReady(logger, "eventually", settings, func() (bool, string) { checks++; return checks > 1, "not yet" }); err != nil {
+		t.Error("An instance that became ready was not ready: ", err)
+	}
+	if checks != 2 || time.Since(start) < settings.interval() {
+		t.Errorf("Expected two checks, an interval apart, got %d checks in %s", checks, time.Since(start))
+	}
+
+	// the check gives up when the timeout runs out
+	if err := waitForReady(logger, "never", &FSouza_HealthcheckSettings{Timeout: 1}, func() (bool, string) { return false, "never" }); !IsErrorKind(err, ERROR_NOT_READY) {
+		t.Errorf("An instance that never became ready returned %v, expected a %s error", err, ERROR_NOT_READY)
+	}
+}
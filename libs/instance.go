@@ -55,7 +55,7 @@ func (instance *BaseInstance) IsDefault() bool {
 	return instance.isDefault
 }
 func (instance *BaseInstance) IsReady() bool {
-	return instance.Client().HasContainer()
+	return instance.Client().IsReady()
 }
 func (instance *BaseInstance) IsRunning() bool {
 	return instance.Client().IsRunning()
@@ -0,0 +1,100 @@
+package libs
+
+/**
+ * @file Coach ownership labels
+ *
+ * Coach stamps the containers and images that it creates with labels which
+ * identify the project, node, instance and environment that they belong to.
+ * Clients use these labels to discover project resources, so that ownership
+ * checks are exact, where name prefixes can't tell an "app" project from an
+ * "app2" project.
+ */
+
+import (
+	"strings"
+
+	"github.com/james-nesbitt/coach/conf"
+)
+
+const (
+	COACH_LABEL_PROJECT     = "coach.project"
+	COACH_LABEL_NODE        = "coach.node"
+	COACH_LABEL_INSTANCE    = "coach.instance"
+	COACH_LABEL_ENVIRONMENT = "coach.environment"
+)
+
+// The labels that mark a resource as owned by a project node, and optionally an instance of the node
+func CoachLabels(project *conf.Project, nodeId string, instanceId string) map[string]string {
+	labels := map[string]string{
+		COACH_LABEL_NODE: nodeId,
+	}
+	if project != nil {
+		labels[COACH_LABEL_PROJECT] = project.Name
+		if project.Environment != "" {
+			labels[COACH_LABEL_ENVIRONMENT] = project.Environment
+		}
+	}
+	if instanceId != "" {
+		labels[COACH_LABEL_INSTANCE] = instanceId
+	}
+	return labels
+}
+
+// The labels that are used to match a resource to a project node, and optionally an instance.
+// The environment is left out, so that switching environments doesn't orphan resources.
+func CoachMatchLabels(project *conf.Project, nodeId string, instanceId string) map[string]string {
+	labels := CoachLabels(project, nodeId, instanceId)
+	delete(labels, COACH_LABEL_ENVIRONMENT)
+	return labels
+}
+
+// Merge coach labels into a set of labels, returning a new map so that settings are not changed
+func MergeCoachLabels(labels map[string]string, coachLabels map[string]string) map[string]string {
+	merged := map[string]string{}
+	for key, value := range labels {
+		merged[key] = value
+	}
+	for key, value := range coachLabels {
+		merged[key] = value
+	}
+	return merged
+}
+
+// Is a resource labelled as belonging to any coach project
+func HasCoachLabels(labels map[string]string) bool {
+	_, ok := labels[COACH_LABEL_PROJECT]
+	return ok
+}
+
+// Does a set of resource labels contain all of the required labels
+func MatchCoachLabels(labels map[string]string, required map[string]string) bool {
+	for key, value := range required {
+		if found, ok := labels[key]; !ok || found != value {
+			return false
+		}
+	}
+	return true
+}
+
+/**
+ * Containers created before coach used labels can only be matched by name.
+ * Container names also include names used for linking into other containers,
+ * such as /example_fpm/db.app, which are ignored.
+ */
+
+// Match container names to a machine name, returning anything after a "{machineName}_" prefix
+func legacyContainerNameMatch(names []string, machineName string, prefix bool) (string, bool) {
+	for _, name := range names {
+		name = strings.TrimPrefix(name, "/")
+		if strings.Contains(name, "/") {
+			continue
+		}
+		if name == machineName {
+			return "", true
+		}
+		if prefix && strings.HasPrefix(name, machineName+"_") {
+			return name[len(machineName)+1:], true
+		}
+	}
+	return "", false
+}
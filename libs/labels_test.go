@@ -0,0 +1,75 @@
+package libs
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/james-nesbitt/coach/conf"
+)
+
+func TestCoachLabels(t *testing.T) {
+	project := &conf.Project{Name: "app", Environment: "dev"}
+
+	labels := CoachLabels(project, "www", "1")
+	expected := map[string]string{COACH_LABEL_PROJECT: "app", COACH_LABEL_NODE: "www", COACH_LABEL_INSTANCE: "1", COACH_LABEL_ENVIRONMENT: "dev"}
+	if !reflect.DeepEqual(labels, expected) {
+		t.Errorf("CoachLabels: got %v, expected %v", labels, expected)
+	}
+
+	// matching leaves out the environment, and the instance when none is given
+	match := CoachMatchLabels(project, "www", "")
+	expected = map[string]string{COACH_LABEL_PROJECT: "app", COACH_LABEL_NODE: "www"}
+	if !reflect.DeepEqual(match, expected) {
+		t.Errorf("CoachMatchLabels: got %v, expected %v", match, expected)
+	}
+
+	// merging doesn't change the node settings labels
+	settings := map[string]string{"team": "web"}
+	if merged := MergeCoachLabels(settings, match); len(merged) != 3 || len(settings) != 1 {
+		t.Errorf("MergeCoachLabels: got %v, with settings %v", merged, settings)
+	}
+}
+
+func TestMatchCoachLabels(t *testing.T) {
+	required := CoachMatchLabels(&conf.Project{Name: "app"}, "www", "")
+
+	tests := []struct {
+		name   string
+		labels map[string]string
+		match  bool
+	}{
+		{name: "node instance", labels: map[string]string{COACH_LABEL_PROJECT: "app", COACH_LABEL_NODE: "www", COACH_LABEL_INSTANCE: "1"}, match: true},
+		{name: "other environment", labels: map[string]string{COACH_LABEL_PROJECT: "app", COACH_LABEL_NODE: "www", COACH_LABEL_ENVIRONMENT: "prod"}, match: true},
+		{name: "other project with a prefixed name", labels: map[string]string{COACH_LABEL_PROJECT: "app2", COACH_LABEL_NODE: "www"}},
+		{name: "other node", labels: map[string]string{COACH_LABEL_PROJECT: "app", COACH_LABEL_NODE: "db"}},
+		{name: "unlabelled", labels: map[string]string{}},
+	}
+
+	for _, test := range tests {
+		if match := MatchCoachLabels(test.labels, required); match != test.match {
+			t.Errorf("%s: matched %v, expected %v", test.name, match, test.match)
+		}
+	}
+}
+
+func TestLegacyContainerNameMatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		names  []string
+		prefix bool
+		suffix string
+		match  bool
+	}{
+		{name: "exact", names: []string{"/app_www"}, suffix: "", match: true},
+		{name: "instance", names: []string{"/app_www_1"}, prefix: true, suffix: "1", match: true},
+		{name: "instance without prefix matching", names: []string{"/app_www_1"}},
+		{name: "link name", names: []string{"/app_fpm/app_www"}},
+		{name: "other project", names: []string{"/app2_www"}, prefix: true},
+	}
+
+	for _, test := range tests {
+		if suffix, match := legacyContainerNameMatch(test.names, "app_www", test.prefix); suffix != test.suffix || match != test.match {
+			t.Errorf("%s: matched [%s] %v, expected [%s] %v", test.name, suffix, match, test.suffix, test.match)
+		}
+	}
+}
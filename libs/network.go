@@ -0,0 +1,85 @@
+package libs
+
+/**
+ * @file Coach project networks
+ *
+ * Every project gets a user-defined docker network, which all of the node
+ * instance containers join, using their node and instance names as DNS
+ * aliases.  This replaces the legacy docker links, which docker has
+ * deprecated.  Nodes can also join extra networks, using a Networks: list
+ * in their docker settings.
+ *
+ * Networks are created as needed when containers are created.  Only the
+ * project network belongs to coach: it is labelled for the project, and is
+ * removed by the clean operation once no containers are using it.  Any
+ * extra networks are shared with other projects, so they are created
+ * without labels if they don't exist, and are never removed.
+ */
+
+import (
+	"strings"
+
+	"github.com/james-nesbitt/coach/conf"
+)
+
+const (
+	COACH_PROJECT_NETWORK_SUFFIX = "_default" // the project network is named {project}_default
+)
+
+// The name of the project network
+func ProjectNetworkName(project *conf.Project) string {
+	return strings.ToLower(project.Name) + COACH_PROJECT_NETWORK_SUFFIX
+}
+
+// The labels used to mark a network as belonging to a project
+func ProjectNetworkLabels(project *conf.Project) map[string]string {
+	labels := map[string]string{
+		COACH_LABEL_PROJECT: project.Name,
+	}
+	if project.Environment != "" {
+		labels[COACH_LABEL_ENVIRONMENT] = project.Environment
+	}
+	return labels
+}
+
+// The labels to create a network with, which mark only the project network as belonging to the project
+func networkCreateLabels(project *conf.Project, name string) map[string]string {
+	if name == ProjectNetworkName(project) {
+		return ProjectNetworkLabels(project)
+	}
+	return map[string]string{}
+}
+
+// The networks that a node instance should join, with the project network first.
+// Instances with a Host NetworkMode manage their own networking, and join no networks.
+func nodeNetworkNames(project *conf.Project, settings FSouza_ClientSettings) []string {
+	if settings.Host.NetworkMode != "" {
+		return []string{}
+	}
+
+	names := []string{ProjectNetworkName(project)}
+	for _, name := range settings.Networks {
+		if name != "" && name != names[0] {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Is a NetworkMode a user defined network, as docker only allows network aliases on those
+func isUserNetworkMode(mode string) bool {
+	switch mode {
+	case "", "default", "bridge", "host", "none":
+		return false
+	}
+	return !strings.HasPrefix(mode, "container:")
+}
+
+// The DNS aliases for a node instance on its networks
+func instanceNetworkAliases(nodeId string, instance Instance) []string {
+	aliases := []string{nodeId}
+	if instance.Id() != INSTANCE_SINGLE_ID {
+		aliases = append(aliases, nodeId+"_"+instance.Id())
+	}
+	return aliases
+}
@@ -0,0 +1,85 @@
+package libs
+
+import (
+	"reflect"
+	"testing"
+
+	docker "github.com/fsouza/go-dockerclient"
+
+	"github.com/james-nesbitt/coach/conf"
+)
+
+func TestNodeNetworkNames(t *testing.T) {
+	project := &conf.Project{Name: "App"}
+
+	tests := []struct {
+		name     string
+		settings FSouza_ClientSettings
+		networks []string
+	}{
+		{name: "project network", networks: []string{"app_default"}},
+		{name: "extra networks", settings: FSouza_ClientSettings{Networks: []string{"shared", "", "app_default", "proxy"}}, networks: []string{"app_default", "shared", "proxy"}},
+		{name: "host network mode", settings: FSouza_ClientSettings{Host: docker.HostConfig{NetworkMode: "host"}, Networks: []string{"shared"}}, networks: []string{}},
+	}
+
+	for _, test := range tests {
+		if networks := nodeNetworkNames(project, test.settings); !reflect.DeepEqual(networks, test.networks) {
+			t.Errorf("%s: networks %q, expected %q", test.name, networks, test.networks)
+		}
+	}
+}
+
+func TestInstanceNetworkAliases(t *testing.T) {
+	tests := []struct {
+		instance string
+		aliases  []string
+	}{
+		{instance: INSTANCE_SINGLE_ID, aliases: []string{"www"}},
+		{instance: "2", aliases: []string{"www", "www_2"}},
+	}
+
+	for _, test := range tests {
+		instance := &BaseInstance{id: test.instance}
+		if aliases := instanceNetworkAliases("www", instance); !reflect.DeepEqual(aliases, test.aliases) {
+			t.Errorf("%s: aliases %q, expected %q", test.instance, aliases, test.aliases)
+		}
+	}
+}
+
+func TestNetworkCreateLabels(t *testing.T) {
+	project := &conf.Project{Name: "App", Environment: "dev"}
+
+	tests := []struct {
+		network string
+		labels  map[string]string
+	}{
+		{network: "app_default", labels: map[string]string{COACH_LABEL_PROJECT: "App", COACH_LABEL_ENVIRONMENT: "dev"}},
+		// other networks may be shared with other projects, so they don't get project labels
+		{network: "shared", labels: map[string]string{}},
+	}
+
+	for _, test := range tests {
+		if labels := networkCreateLabels(project, test.network); !reflect.DeepEqual(labels, test.labels) {
+			t.Errorf("%s: labels %v, expected %v", test.network, labels, test.labels)
+		}
+	}
+}
+
+func TestIsUserNetworkMode(t *testing.T) {
+	tests := map[string]bool{
+		"":             false,
+		"default":      false,
+		"bridge":       false,
+		"host":         false,
+		"none":         false,
+		"container:db": false,
+		"app_default":  true,
+		"shared":       true,
+	}
+
+	for mode, user := range tests {
+		if isUserNetworkMode(mode) != user {
+			t.Errorf("[%s]: user network %v, expected %v", mode, !user, user)
+		}
+	}
+}
@@ -83,6 +83,13 @@ func (node *BaseNode) Prepare(logger log.Log, nodes *Nodes) (success bool) {
 
 	node.log = logger
 
+	// required nodes that don't exist are ignored by the target sort, so a mistyped name would otherwise go unnoticed
+	for _, dependency := range node.manualDependencies {
+		if _, exists := nodes.Node(dependency); !exists {
+			logger.Warning("Node requires an unknown node, so the requirement is ignored [" + dependency + "]")
+		}
+	}
+
 	logger.Debug(log.VERBOSITY_DEBUG_WOAH, "Preparing Client", nil)
 	success = success && node.client.Prepare(logger.MakeChild("client"), nodes, node)
 
@@ -94,6 +101,8 @@ func (node *BaseNode) Prepare(logger log.Log, nodes *Nodes) (success bool) {
 
 func (node *BaseNode) Can(action string) bool {
 	switch action {
+	case "volume":
+		return false
 	case "run":
 		return false
 	default:
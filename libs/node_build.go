@@ -51,13 +51,15 @@ func (node *BuildNode) defaultInstances(logger log.Log, client Client, instances
 	node.instances = Instances(&NullInstances{})
 }
 
-// Build Nodes can only build
+// Build Nodes can only build, and push what they build
 func (node *BuildNode) Can(action string) bool {
 	switch action {
 	case "destroy":
 		fallthrough
 	case "clean":
 		fallthrough
+	case "push":
+		fallthrough
 	case "build":
 		return true
 	default:
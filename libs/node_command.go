@@ -48,6 +48,8 @@ func (node *CommandNode) defaultInstances(logger log.Log, client Client, instanc
 
 func (node *CommandNode) Can(action string) bool {
 	switch action {
+	case "volume":
+		return false
 	case "create":
 		fallthrough
 	case "start":
@@ -36,6 +36,11 @@ func (node *VolumeNode) Init(logger log.Log, name string, project *conf.Project,
 		node.defaultInstances(logger, client, instancesSettings)
 	}
 
+	// named volume nodes keep their data in a docker volume, so they have no containers
+	if client.Can("volume") {
+		node.instances = Instances(&NullInstances{})
+	}
+
 	node.instances.Init(logger, node.MachineName(), client, instancesSettings)
 
 	logger.Debug(log.VERBOSITY_DEBUG_STAAAP, "Built new node:", node.client)
@@ -49,7 +54,23 @@ func (node *VolumeNode) defaultInstances(logger log.Log, client Client, instance
 }
 
 // Volume Nodes can only build and create, they can never start
+// Named volume nodes can only create and remove their volume
 func (node *VolumeNode) Can(action string) bool {
+	if node.client.Can("volume") {
+		switch action {
+		case "volume":
+			fallthrough
+		case "create":
+			fallthrough
+		case "Up":
+			fallthrough
+		case "clean":
+			return true
+		default:
+			return false
+		}
+	}
+
 	switch action {
 	case "run":
 		fallthrough
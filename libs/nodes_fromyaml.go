@@ -162,6 +162,7 @@ type node_yaml_v2 struct {
 	SingleInstances bool                    `yaml:"Single,omitempty"`
 	TempInstances   bool                    `yaml:"Disposable,omitempty"`
 
+	Client string                `yaml:"Client,omitempty"`
 	Docker FSouza_ClientSettings `yaml:"Docker,omitempty"`
 
 	Requires []string `yaml:"Requires,omitempty"`
@@ -171,13 +172,21 @@ func (node *node_yaml_v2) Type() (string, bool) {
 	return node.NodeType, node.NodeType != ""
 }
 func (node *node_yaml_v2) GetClient(logger log.Log, clientFactories *ClientFactories) (Client, bool) {
+	requirements := FactoryMatchRequirements{Type: "docker"}
+
+	// a node can ask for a particular client, by factory name, type, ID or class
+	if node.Client != "" {
+		requirements = FactoryMatchRequirements{Name: node.Client, Type: node.Client, ID: node.Client, Class: node.Client}
+	}
 
 	// if a docker client was configured then try to take it.
 	// if !(node.Docker.Config.Image=="" && node.Docker.BuildPath=="") {
-	if factory, ok := clientFactories.MatchClientFactory(FactoryMatchRequirements{Type: "docker"}); ok {
+	if factory, ok := clientFactories.MatchClientFactory(requirements); ok {
 		if client, ok := factory.MakeClient(logger, ClientSettings(&node.Docker)); ok {
 			return client, true
 		}
+	} else if node.Client != "" {
+		logger.Warning("Node asked for a client that is not defined in the clients.yml: " + node.Client)
 	} else {
 		logger.Debug(log.VERBOSITY_DEBUG_STAAAP, "Failed to match client factory:", factory)
 	}
@@ -0,0 +1,101 @@
+package libs
+
+/**
+ * @file Machine readable node and instance reports
+ *
+ * The info, status and inspect operations can write structured JSON or YAML
+ * documents instead of text, for scripts and CI checks.  Clients fill in
+ * what they know about node images and instance containers, and operations
+ * add what comes from the targets, such as which instances are filtered.
+ */
+
+import (
+	docker "github.com/fsouza/go-dockerclient"
+
+	"github.com/james-nesbitt/coach/conf"
+)
+
+// A report of a node, and its instances
+type NodeReport struct {
+	Node string `json:"Node" yaml:"Node"`
+	Type string `json:"Type" yaml:"Type"`
+
+	HasImage bool          `json:"HasImage" yaml:"HasImage"`
+	Images   []ImageReport `json:"Images,omitempty" yaml:"Images,omitempty"`
+	Volume   *VolumeReport `json:"Volume,omitempty" yaml:"Volume,omitempty"`
+
+	Containers int              `json:"Containers" yaml:"Containers"` // how many instances have a container
+	Running    int              `json:"Running" yaml:"Running"`       // how many instances are running
+	Instances  []InstanceReport `json:"Instances" yaml:"Instances"`
+}
+
+// A report of a node image
+type ImageReport struct {
+	ID      string   `json:"ID" yaml:"ID"`
+	Tags    []string `json:"Tags" yaml:"Tags"`
+	Created string   `json:"Created,omitempty" yaml:"Created,omitempty"`
+}
+
+// A report of a node named volume
+type VolumeReport struct {
+	Name       string `json:"Name" yaml:"Name"`
+	Driver     string `json:"Driver" yaml:"Driver"`
+	Mountpoint string `json:"Mountpoint,omitempty" yaml:"Mountpoint,omitempty"`
+}
+
+// A report of a node instance, and its container
+type InstanceReport struct {
+	Instance  string `json:"Instance" yaml:"Instance"`
+	Container string `json:"Container" yaml:"Container"` // the container name
+	Default   bool   `json:"Default" yaml:"Default"`     // is the instance used by default
+	Filtered  bool   `json:"Filtered" yaml:"Filtered"`   // is the instance included by the target filters
+
+	HasContainer bool `json:"HasContainer" yaml:"HasContainer"`
+	Running      bool `json:"Running" yaml:"Running"`
+
+	ID      string   `json:"ID,omitempty" yaml:"ID,omitempty"`
+	Image   string   `json:"Image,omitempty" yaml:"Image,omitempty"`
+	State   string   `json:"State,omitempty" yaml:"State,omitempty"`
+	Status  string   `json:"Status,omitempty" yaml:"Status,omitempty"`
+	Created string   `json:"Created,omitempty" yaml:"Created,omitempty"`
+	Ports   []string `json:"Ports,omitempty" yaml:"Ports,omitempty"`
+}
+
+// The resolved container configuration for an instance, and its container inspect document if it has one
+type InstanceInspect struct {
+	Node     string `json:"Node" yaml:"Node"`
+	Instance string `json:"Instance" yaml:"Instance"`
+	Name     string `json:"Name" yaml:"Name"`
+
+	Config     docker.Config     `json:"Config" yaml:"Config"`
+	HostConfig docker.HostConfig `json:"HostConfig" yaml:"HostConfig"`
+	Networks   []string          `json:"Networks,omitempty" yaml:"Networks,omitempty"`
+	Aliases    []string          `json:"Aliases,omitempty" yaml:"Aliases,omitempty"`
+
+	Container interface{} `json:"Container,omitempty" yaml:"Container,omitempty"`
+}
+
+// Resolve the container configuration that is used to create an instance container
+func makeInstanceInspect(project *conf.Project, settings FSouza_ClientSettings, nodeId string, instance Instance, image string, labels map[string]string) InstanceInspect {
+	config := settings.Config
+	host := settings.Host
+
+	config.Image = image
+	config.Labels = labels
+
+	networks := nodeNetworkNames(project, settings)
+	if len(networks) > 0 {
+		host.NetworkMode = networks[0]
+	}
+
+	return InstanceInspect{
+		Node:     nodeId,
+		Instance: instance.Id(),
+		Name:     instance.MachineName(),
+
+		Config:     config,
+		HostConfig: host,
+		Networks:   networks,
+		Aliases:    instanceNetworkAliases(nodeId, instance),
+	}
+}
@@ -0,0 +1,124 @@
+package libs
+
+/**
+ * @file Instance container resource usage statistics
+ *
+ * Each client samples the resource usage of a running instance container
+ * into an InstanceStats, so that operations can show the usage of project
+ * nodes, without needing to know which client was used.
+ */
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+// A sample of the resource usage of an instance container
+type InstanceStats struct {
+	CPUPercent float64 // percentage of a single CPU, so it can go above 100 on multi-core hosts
+
+	MemoryUsage uint64 // bytes, not counting reclaimable page cache
+	MemoryLimit uint64 // bytes
+
+	NetworkRx uint64 // bytes received over all container networks
+	NetworkTx uint64 // bytes sent over all container networks
+
+	BlockRead  uint64 // bytes read from block devices
+	BlockWrite uint64 // bytes written to block devices
+
+	PIDs uint64 // number of processes/threads
+}
+
+// The memory usage as a percentage of the memory limit
+func (stats InstanceStats) MemoryPercent() float64 {
+	if stats.MemoryLimit == 0 {
+		return 0
+	}
+	return float64(stats.MemoryUsage) / float64(stats.MemoryLimit) * 100
+}
+
+// Convert a docker remote API stats sample, using the same calculations as the docker cli
+func statsFromDocker(sample *docker.Stats) InstanceStats {
+	stats := InstanceStats{
+		MemoryLimit: sample.MemoryStats.Limit,
+		PIDs:        sample.PidsStats.Current,
+	}
+
+	cpuDelta := float64(sample.CPUStats.CPUUsage.TotalUsage) - float64(sample.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(sample.CPUStats.SystemCPUUsage) - float64(sample.PreCPUStats.SystemCPUUsage)
+	cpus := float64(sample.CPUStats.OnlineCPUs)
+	if cpus == 0 {
+		cpus = float64(len(sample.CPUStats.CPUUsage.PercpuUsage))
+	}
+	if cpuDelta > 0 && systemDelta > 0 {
+		stats.CPUPercent = cpuDelta / systemDelta * cpus * 100
+	}
+
+	// page cache can be reclaimed, so it isn't counted (cgroup v1 reports total_inactive_file, v2 inactive_file)
+	cache := sample.MemoryStats.Stats.TotalInactiveFile
+	if cache == 0 {
+		cache = sample.MemoryStats.Stats.InactiveFile
+	}
+	if cache < sample.MemoryStats.Usage {
+		stats.MemoryUsage = sample.MemoryStats.Usage - cache
+	}
+
+	for _, network := range sample.Networks {
+		stats.NetworkRx += network.RxBytes
+		stats.NetworkTx += network.TxBytes
+	}
+	for _, entry := range sample.BlkioStats.IOServiceBytesRecursive {
+		switch strings.ToLower(entry.Op) {
+		case "read":
+			stats.BlockRead += entry.Value
+		case "write":
+			stats.BlockWrite += entry.Value
+		}
+	}
+
+	return stats
+}
+
+// Parse a size written by the docker cli, such as "1.5MiB" or "12kB"
+func parseDockerSize(size string) (uint64, error) {
+	size = strings.TrimSpace(size)
+	units := []struct {
+		suffix     string
+		multiplier float64
+	}{
+		// longest suffixes first, so that "MiB" isn't read as "B"
+		{"KiB", 1 << 10}, {"MiB", 1 << 20}, {"GiB", 1 << 30}, {"TiB", 1 << 40},
+		{"kB", 1e3}, {"KB", 1e3}, {"MB", 1e6}, {"GB", 1e9}, {"TB", 1e12},
+		{"B", 1},
+	}
+	for _, unit := range units {
+		if strings.HasSuffix(size, unit.suffix) {
+			value, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(size, unit.suffix)), 64)
+			if err != nil {
+				return 0, err
+			}
+			return uint64(value * unit.multiplier), nil
+		}
+	}
+	if size == "" || size == "--" {
+		return 0, nil
+	}
+	return 0, errors.New("unknown size: " + size)
+}
+
+// Parse a pair of sizes written by the docker cli, such as "1.5MiB / 1.9GiB"
+func parseDockerSizePair(pair string) (uint64, uint64, error) {
+	sizes := strings.SplitN(pair, "/", 2)
+	if len(sizes) != 2 {
+		return 0, 0, errors.New("unknown size pair: " + pair)
+	}
+	first, err := parseDockerSize(sizes[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	second, err := parseDockerSize(sizes[1])
+	return first, second, err
+}
@@ -0,0 +1,62 @@
+package libs
+
+import (
+	"testing"
+
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+func TestParseDockerSize(t *testing.T) {
+	tests := []struct {
+		size  string
+		bytes uint64
+		fail  bool
+	}{
+		{size: "0B", bytes: 0},
+		{size: "512B", bytes: 512},
+		{size: "1.5MiB", bytes: 3 << 19},
+		{size: "12kB", bytes: 12000},
+		{size: " 2GiB ", bytes: 2 << 30},
+		{size: "--", bytes: 0},
+		{size: "12 parsecs", fail: true},
+		{size: "lotsMB", fail: true},
+	}
+
+	for _, test := range tests {
+		bytes, err := parseDockerSize(test.size)
+		if (err != nil) != test.fail || bytes != test.bytes {
+			t.Errorf("%q: parsed %d %v, expected %d (fail %v)", test.size, bytes, err, test.bytes, test.fail)
+		}
+	}
+
+	if usage, limit, err := parseDockerSizePair("1KiB / 2KiB"); err != nil || usage != 1024 || limit != 2048 {
+		t.Errorf("Size pair parsed as %d / %d %v", usage, limit, err)
+	}
+	if _, _, err := parseDockerSizePair("1KiB"); err == nil {
+		t.Error("A single size was parsed as a pair")
+	}
+}
+
+func TestStatsFromDocker(t *testing.T) {
+	sample := &docker.Stats{}
+	sample.CPUStats.CPUUsage.TotalUsage = 300
+	sample.PreCPUStats.CPUUsage.TotalUsage = 100
+	sample.CPUStats.SystemCPUUsage = 2000
+	sample.PreCPUStats.SystemCPUUsage = 1000
+	sample.CPUStats.OnlineCPUs = 2
+	sample.MemoryStats.Usage = 1000
+	sample.MemoryStats.Limit = 4000
+	sample.MemoryStats.Stats.InactiveFile = 200
+	sample.Networks = map[string]docker.NetworkStats{"eth0": {RxBytes: 10, TxBytes: 20}, "eth1": {RxBytes: 1, TxBytes: 2}}
+	sample.BlkioStats.IOServiceBytesRecursive = []docker.BlkioStatsEntry{{Op: "Read", Value: 5}, {Op: "Write", Value: 7}, {Op: "Total", Value: 12}}
+	sample.PidsStats.Current = 4
+
+	// the cpu delta is a fifth of the system delta, on two cpus, and page cache is not counted as memory usage
+	expected := InstanceStats{CPUPercent: 40, MemoryUsage: 800, MemoryLimit: 4000, NetworkRx: 11, NetworkTx: 22, BlockRead: 5, BlockWrite: 7, PIDs: 4}
+	if stats := statsFromDocker(sample); stats != expected {
+		t.Errorf("Converted stats %+v, expected %+v", stats, expected)
+	}
+	if percent := expected.MemoryPercent(); percent != 20 {
+		t.Errorf("Memory percent %v, expected 20", percent)
+	}
+}
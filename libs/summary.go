@@ -0,0 +1,78 @@
+package libs
+
+/**
+ * @file Operation summaries
+ *
+ * Operations record each node and instance action that they attempt, and
+ * whether it succeeded, was skipped, failed, or was not run at all, so that
+ * a summary can be written when all of the operations are finished.
+ * Failures are also logged where they happen, but are easy to miss in a
+ * long run.
+ */
+
+import (
+	"sync"
+)
+
+// The result of an attempted action
+type ActionResult string
+
+const (
+	RESULT_SUCCEEDED ActionResult = "succeeded"
+	RESULT_SKIPPED   ActionResult = "skipped" // nothing needed to be done, e.g. the instance already had a container
+	RESULT_FAILED    ActionResult = "failed"
+	RESULT_NOT_RUN   ActionResult = "not run" // the action was never attempted, because an earlier target failed
+)
+
+// A single attempted action on a node, or a node instance
+type ActionRecord struct {
+	Node     string
+	Instance string // empty for node actions, such as build or pull
+	Action   string
+	Result   ActionResult
+	Reason   string // why the action was skipped or not run, or the error if it failed
+}
+
+// The actions attempted while processing targets
+type Summary struct {
+	lock    sync.Mutex
+	records []ActionRecord
+}
+
+// Record the result of an action, using its error to decide if it succeeded, was skipped or failed.
+// The error is returned, so that it can still be collected by the caller.
+func (summary *Summary) Record(node string, instance string, action string, err error) error {
+	record := ActionRecord{Node: node, Instance: instance, Action: action, Result: RESULT_SUCCEEDED}
+	if IsSkipped(err) {
+		record.Result = RESULT_SKIPPED
+		record.Reason = string(ErrorKind(err))
+	} else if err != nil {
+		record.Result = RESULT_FAILED
+		record.Reason = err.Error()
+	}
+	summary.add(record)
+	return err
+}
+
+// Record an action that was skipped without asking the client
+func (summary *Summary) Skip(node string, instance string, action string, reason string) {
+	summary.add(ActionRecord{Node: node, Instance: instance, Action: action, Result: RESULT_SKIPPED, Reason: reason})
+}
+
+// Record an action that was not run, because processing stopped before it was reached
+func (summary *Summary) NotRun(node string, instance string, action string, reason string) {
+	summary.add(ActionRecord{Node: node, Instance: instance, Action: action, Result: RESULT_NOT_RUN, Reason: reason})
+}
+
+func (summary *Summary) add(record ActionRecord) {
+	summary.lock.Lock()
+	defer summary.lock.Unlock()
+	summary.records = append(summary.records, record)
+}
+
+// All of the recorded actions, in the order that they were recorded
+func (summary *Summary) Records() []ActionRecord {
+	summary.lock.Lock()
+	defer summary.lock.Unlock()
+	return append([]ActionRecord{}, summary.records...)
+}
@@ -0,0 +1,44 @@
+package libs
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSummaryRecord(t *testing.T) {
+	exists := NewClientError(ERROR_CONTAINER_EXISTS, "create", "www", nil)
+	failed := NewClientError(ERROR_FAILED, "start", "www", errors.New("exit 1"))
+
+	tests := []struct {
+		name   string
+		err    error
+		record ActionRecord
+	}{
+		{
+			name:   "succeeded",
+			err:    nil,
+			record: ActionRecord{Node: "www", Instance: "1", Action: "create", Result: RESULT_SUCCEEDED},
+		},
+		{
+			name:   "skipped",
+			err:    exists,
+			record: ActionRecord{Node: "www", Instance: "1", Action: "create", Result: RESULT_SKIPPED, Reason: "container-exists"},
+		},
+		{
+			name:   "failed",
+			err:    failed,
+			record: ActionRecord{Node: "www", Instance: "1", Action: "create", Result: RESULT_FAILED, Reason: failed.Error()},
+		},
+	}
+
+	for _, test := range tests {
+		summary := &Summary{}
+		if err := summary.Record("www", "1", "create", test.err); err != test.err {
+			t.Errorf("%s: Record returned %v, expected the recorded error", test.name, err)
+		}
+		if records := summary.Records(); !reflect.DeepEqual(records, []ActionRecord{test.record}) {
+			t.Errorf("%s: recorded %+v, expected %+v", test.name, records, test.record)
+		}
+	}
+}
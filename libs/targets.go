@@ -1,7 +1,9 @@
 package libs
 
 import (
+	"errors"
 	"strings"
+	"sync"
 
 	"github.com/twmb/algoimpl/go/graph"
 
@@ -9,18 +11,32 @@ import (
 )
 
 // Build a targets object for a nodes list, from a list of string identifiers
-func (nodes *Nodes) Targets(logger log.Log, identifiers []string) *Targets {
-	targets := &Targets{log: logger, targetMap: map[string]*Target{}, targetOrder: []string{}}
+func (nodes *Nodes) Targets(logger log.Log, identifiers []string, options TargetsOptions) *Targets {
+	targets := &Targets{log: logger, nodes: nodes, targetMap: map[string]*Target{}, targetOrder: []string{}, summary: &Summary{}}
 	targets.fromNodes(identifiers, *nodes)
+	targets.addRelatedNodes(*nodes, options)
+	// a dependency cycle is kept, to be checked with Cycle(), and stops the targets from being processed
 	targets.Sort()
 	return targets
 }
 
+// Options for which nodes are added to a targets list, beyond the target identifiers
+type TargetsOptions struct {
+	WithDependencies bool // add the nodes that the targets depend on, transitively
+	WithDependents   bool // add the nodes that depend on the targets, transitively
+}
+
 // A set of node targets
 type Targets struct {
 	log         log.Log
+	nodes       *Nodes // all of the project nodes, including those that are not targets
 	targetMap   map[string]*Target
 	targetOrder []string
+
+	parallel int      // how many targets can be processed at the same time
+	cycle    []string // a dependency cycle found when sorting, which makes the target order unusable
+
+	summary *Summary // the actions attempted while processing the targets
 }
 
 func (targets *Targets) Target(id string) (target *Target, ok bool) {
@@ -31,6 +47,14 @@ func (targets *Targets) TargetOrder() []string {
 	return targets.targetOrder
 }
 
+// The actions that operations have attempted on the targets
+func (targets *Targets) Summary() *Summary {
+	if targets.summary == nil {
+		targets.summary = &Summary{}
+	}
+	return targets.summary
+}
+
 // Build up a targets list by interpreting string identifiers as a set of nodes targets
 func (targets *Targets) fromNodes(identifiers []string, nodes Nodes) {
 	targets.log.Debug(log.VERBOSITY_DEBUG, "Adding targets from nodes", identifiers)
@@ -76,6 +100,46 @@ func (targets *Targets) fromNodes(identifiers []string, nodes Nodes) {
 	}
 }
 
+// Add the nodes that the identified targets depend on, or that depend on them
+func (targets *Targets) addRelatedNodes(nodes Nodes, options TargetsOptions) {
+	identified := append([]string{}, targets.targetOrder...)
+
+	if options.WithDependencies {
+		targets.addTransitiveNodes(nodes, identified, "dependency", func(from Node, fromName string, to Node, toName string) bool {
+			return from.DependsOn(toName)
+		})
+	}
+	if options.WithDependents {
+		targets.addTransitiveNodes(nodes, identified, "dependent", func(from Node, fromName string, to Node, toName string) bool {
+			return to.DependsOn(fromName)
+		})
+	}
+}
+
+// Add every node that is related to the starting targets, or to a node that has already been added
+func (targets *Targets) addTransitiveNodes(nodes Nodes, start []string, relation string, related func(from Node, fromName string, to Node, toName string) bool) {
+	queue := append([]string{}, start...)
+	for len(queue) > 0 {
+		fromName := queue[0]
+		queue = queue[1:]
+
+		from, exists := nodes.Node(fromName)
+		if !exists {
+			continue
+		}
+		for _, toName := range nodes.NodesOrder {
+			if _, isTarget := targets.Target(toName); isTarget {
+				continue
+			}
+			if to, exists := nodes.Node(toName); exists && related(from, fromName, to, toName) {
+				targets.log.Info("Adding " + relation + " node target [" + toName + "] of [" + fromName + "]")
+				targets.addNodeTarget(toName, to, []string{})
+				queue = append(queue, toName)
+			}
+		}
+	}
+}
+
 // translate a single node.instance1.instance2 string a node name, and a slice of instance filters
 func (targets *Targets) targetStringSeparate(identifier string) (node string, instances []string) {
 	separated := strings.Split(identifier, ":")
@@ -91,7 +155,7 @@ func (targets *Targets) addNodeTarget(name string, node Node, instanceFilters []
 	if _, exists := targets.targetMap[name]; !exists {
 		// this is a new target node, so add all of the instances
 		instances, _ := node.Instances().FilterableInstances()
-		target := Target{name: name, node: node, instances: instances}
+		target := Target{name: name, node: node, instances: instances, summary: targets.Summary()}
 
 		targets.targetMap[name] = &target
 		targets.targetOrder = append(targets.targetOrder, name)
@@ -107,11 +171,18 @@ func (targets *Targets) addNodeTarget(name string, node Node, instanceFilters []
 	}
 }
 
-// Sort the node targets based on dependencies (using a graph-sort)
+// Sort the node targets based on dependencies (using a graph-sort), failing if the dependencies form a cycle
 func (targets *Targets) Sort() bool {
 	logger := targets.log.MakeChild("sort")
 	logger.Debug(log.VERBOSITY_DEBUG, "Starting target sort:", targets, targets.TargetOrder())
 
+	// a topological sort of a graph with a cycle still gives an order, but not one that respects the dependencies
+	targets.cycle = targets.findCycle()
+	if cycle := targets.cycle; len(cycle) > 0 {
+		logger.Error("Node dependencies form a cycle, so the targets can't be sorted [" + strings.Join(cycle, " -> ") + "]")
+		return false
+	}
+
 	g := graph.New(graph.Directed)
 	graphNodes := make(map[string]graph.Node, 0)
 	targetMap := map[string]*Target{}
@@ -149,11 +220,212 @@ func (targets *Targets) Sort() bool {
 	return true
 }
 
+// The dependency cycle found when the targets were sorted, as a path that starts and ends with the same node
+func (targets *Targets) Cycle() ([]string, bool) {
+	return targets.cycle, len(targets.cycle) > 0
+}
+
+// Find a dependency cycle that a target is part of, or depends on, using a depth first search of the
+// DependsOn edges.  Dependencies that are not targets are searched too, as a cycle can pass through them.
+func (targets *Targets) findCycle() []string {
+	const (
+		unvisited = iota
+		visiting  // on the current search path
+		visited
+	)
+	state := map[string]int{}
+	path := []string{}
+
+	nodeNames := targets.TargetOrder()
+	findNode := func(name string) (Node, bool) {
+		if target, ok := targets.Target(name); ok {
+			return target.Node()
+		}
+		return nil, false
+	}
+	if targets.nodes != nil {
+		nodeNames = targets.nodes.NodeNames()
+		findNode = targets.nodes.Node
+	}
+
+	var search func(name string) []string
+	search = func(name string) []string {
+		state[name] = visiting
+		path = append(path, name)
+
+		if node, hasNode := findNode(name); hasNode {
+			for _, dependency := range nodeNames {
+				if !node.DependsOn(dependency) {
+					continue
+				}
+
+				switch state[dependency] {
+				case visiting:
+					for index, pathName := range path {
+						if pathName == dependency {
+							return append(append([]string{}, path[index:]...), dependency)
+						}
+					}
+				case unvisited:
+					if cycle := search(dependency); len(cycle) > 0 {
+						return cycle
+					}
+				}
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[name] = visited
+		return nil
+	}
+
+	for _, name := range targets.TargetOrder() {
+		if state[name] == unvisited {
+			if cycle := search(name); len(cycle) > 0 {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+/**
+ * Processing targets
+ *
+ * Targets can be processed in parallel, by processing the dependency graph
+ * one level at a time.  Targets in the same level don't depend on each
+ * other, so they can be processed at the same time.  Parallel targets get a
+ * buffered log, which is written out when the target is finished, so that
+ * the output of concurrent targets doesn't interleave.
+ *
+ * Teardown operations process the targets in reverse dependency order, so
+ * that a node is stopped or removed before the nodes that it depends on.
+ */
+
+// Set how many targets can be processed at the same time (1 processes targets in order)
+func (targets *Targets) SetParallel(parallel int) {
+	targets.parallel = parallel
+}
+
+// Group the sorted targets into dependency levels, where no target depends on another target in the same level
+func (targets *Targets) Levels() [][]string {
+	levels := [][]string{}
+	targetLevels := map[string]int{}
+
+	for _, name := range targets.TargetOrder() {
+		level := 0
+		if target, ok := targets.Target(name); ok {
+			if node, hasNode := target.Node(); hasNode {
+				for dependency, dependencyLevel := range targetLevels {
+					if node.DependsOn(dependency) && dependencyLevel >= level {
+						level = dependencyLevel + 1
+					}
+				}
+			}
+		}
+
+		targetLevels[name] = level
+		for len(levels) <= level {
+			levels = append(levels, []string{})
+		}
+		levels[level] = append(levels[level], name)
+	}
+	return levels
+}
+
+// Process each target, in dependency order, returning the errors from all of the targets.  If the
+// process function returns an error made with StopProcess, then no more targets are started.
+func (targets *Targets) Process(logger log.Log, process func(logger log.Log, target *Target) error) error {
+	return targets.processLevels(logger, targets.Levels(), process)
+}
+
+// Process each target, in reverse dependency order, so that targets are processed before the targets that they depend on
+func (targets *Targets) ProcessReverse(logger log.Log, process func(logger log.Log, target *Target) error) error {
+	levels := [][]string{}
+	for _, level := range targets.Levels() {
+		levels = append([][]string{level}, levels...)
+	}
+
+	return targets.processLevels(logger, levels, process)
+}
+
+// Process the targets one level at a time, either one target at a time, or the targets in a level in parallel
+func (targets *Targets) processLevels(logger log.Log, levels [][]string, process func(logger log.Log, target *Target) error) error {
+	logger.Debug(log.VERBOSITY_DEBUG, "Run:Targets", levels)
+
+	// the order of targets with a dependency cycle doesn't respect their dependencies
+	if cycle, hasCycle := targets.Cycle(); hasCycle {
+		logger.Error("Node dependencies form a cycle, so no targets will be processed [" + strings.Join(cycle, " -> ") + "]")
+		return NewClientError(ERROR_INVALID, "process", strings.Join(cycle, " -> "), errors.New("node dependencies form a cycle"))
+	}
+
+	errs := Errors{}
+	for levelIndex, level := range levels {
+		logger.Debug(log.VERBOSITY_DEBUG, "Run:Level", level)
+
+		var wait sync.WaitGroup
+		var lock sync.Mutex
+		workers := make(chan struct{}, targets.parallel)
+		stop := false
+
+		for _, targetID := range level {
+			target, targetExists := targets.Target(targetID)
+			if !targetExists {
+				// this is strange
+				logger.Warning("Internal target error, was told to use a target that doesn't exist")
+				continue
+			}
+
+			if targets.parallel <= 1 {
+				err := process(logger.MakeChild(targetID), target)
+				errs.Add(err)
+				stop = stop || isStopProcess(err)
+				continue
+			}
+
+			wait.Add(1)
+			workers <- struct{}{}
+			go func(targetID string, target *Target) {
+				defer wait.Done()
+				defer func() { <-workers }()
+
+				targetLogger := logger.MakeBufferedChild(targetID)
+				err := process(targetLogger, target)
+				targetLogger.Flush()
+
+				lock.Lock()
+				errs.Add(err)
+				stop = stop || isStopProcess(err)
+				lock.Unlock()
+			}(targetID, target)
+		}
+		wait.Wait()
+
+		// let the whole level finish, but don't start any dependent targets
+		if stop {
+			for _, skipped := range levels[levelIndex+1:] {
+				targets.skipStopped(skipped)
+			}
+			break
+		}
+	}
+	return errs.Err()
+}
+
+// Record targets that were not processed, because an earlier target stopped the processing
+func (targets *Targets) skipStopped(targetIDs []string) {
+	for _, targetID := range targetIDs {
+		targets.Summary().NotRun(targetID, "", "process", "an earlier target failed")
+	}
+}
+
 // A single node target
 type Target struct {
 	name      string
 	node      Node
 	instances FilterableInstances
+
+	summary *Summary
 }
 
 func (target *Target) Name() string {
@@ -165,3 +437,18 @@ func (target *Target) Node() (Node, bool) {
 func (target *Target) Instances() (FilterableInstances, bool) {
 	return target.instances, target.instances != nil
 }
+
+// Record the result of an action on the target node (use an empty instance for node actions), returning the action error
+func (target *Target) Record(instance string, action string, err error) error {
+	if target.summary == nil {
+		return err
+	}
+	return target.summary.Record(target.name, instance, action, err)
+}
+
+// Record that an action on the target node was skipped
+func (target *Target) Skip(instance string, action string, reason string) {
+	if target.summary != nil {
+		target.summary.Skip(target.name, instance, action, reason)
+	}
+}
@@ -0,0 +1,255 @@
+package libs
+
+import (
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/james-nesbitt/coach/conf"
+	"github.com/james-nesbitt/coach/log"
+)
+
+// A node for a targets test, with the names of the nodes that it requires
+type testTargetNode struct {
+	name     string
+	requires []string
+}
+
+// Build a set of fake client nodes, with dependencies between them
+func makeTestTargetNodes(t *testing.T, logger log.Log, testNodes []testTargetNode) *Nodes {
+	project := &conf.Project{Name: "targets"}
+	factory := &Fake_ClientFactory{}
+	if !factory.Init(logger, project, ClientFactorySettings(&Fake_ClientFactorySettings{})) {
+		t.Fatal("The fake client factory failed to initialize")
+	}
+
+	nodes := &Nodes{}
+	nodes.Init(logger)
+	for _, testNode := range testNodes {
+		client, ok := factory.MakeClient(logger, ClientSettings(&FSouza_ClientSettings{}))
+		if !ok {
+			t.Fatal("The fake client factory failed to make a client")
+		}
+		node := &ServiceNode{}
+		node.Init(logger, testNode.name, project, client, InstancesSettings(SingleInstancesSettings{}))
+		for _, required := range testNode.requires {
+			node.AddDependency(required)
+		}
+		nodes.SetNode(testNode.name, node, false)
+	}
+	nodes.Prepare(logger)
+	return nodes
+}
+
+// Sort the targets in each level, as the order within a level doesn't matter
+func sortedLevels(levels [][]string) [][]string {
+	for _, level := range levels {
+		sort.Strings(level)
+	}
+	return levels
+}
+
+func TestTargetsLevels(t *testing.T) {
+	logger := log.MakeCliLog("test", ioutil.Discard, log.VERBOSITY_MESSAGE)
+
+	tests := []struct {
+		name    string
+		nodes   []testTargetNode
+		targets []string
+		levels  [][]string
+	}{
+		{
+			name:    "independent",
+			nodes:   []testTargetNode{{name: "db"}, {name: "cache"}},
+			targets: []string{"$all"},
+			levels:  [][]string{{"cache", "db"}},
+		},
+		{
+			name:    "chain",
+			nodes:   []testTargetNode{{name: "www", requires: []string{"fpm"}}, {name: "fpm", requires: []string{"db"}}, {name: "db"}},
+			targets: []string{"$all"},
+			levels:  [][]string{{"db"}, {"fpm"}, {"www"}},
+		},
+		{
+			name: "diamond",
+			nodes: []testTargetNode{
+				{name: "db"},
+				{name: "cache"},
+				{name: "fpm", requires: []string{"db", "cache"}},
+				{name: "cron", requires: []string{"db"}},
+				{name: "www", requires: []string{"fpm"}},
+			},
+			targets: []string{"$all"},
+			levels:  [][]string{{"cache", "db"}, {"cron", "fpm"}, {"www"}},
+		},
+		{
+			name:    "dependency that is not a target",
+			nodes:   []testTargetNode{{name: "www", requires: []string{"fpm"}}, {name: "fpm", requires: []string{"db"}}, {name: "db"}},
+			targets: []string{"www", "db"},
+			levels:  [][]string{{"db", "www"}},
+		},
+	}
+
+	for _, test := range tests {
+		nodes := makeTestTargetNodes(t, logger, test.nodes)
+		targets := nodes.Targets(logger, test.targets, TargetsOptions{})
+		if levels := sortedLevels(targets.Levels()); !reflect.DeepEqual(levels, test.levels) {
+			t.Errorf("%s: levels %q, expected %q", test.name, levels, test.levels)
+		}
+	}
+}
+
+func TestTargetsRelatedNodes(t *testing.T) {
+	logger := log.MakeCliLog("test", ioutil.Discard, log.VERBOSITY_MESSAGE)
+	testNodes := []testTargetNode{
+		{name: "db"},
+		{name: "cache"},
+		{name: "fpm", requires: []string{"db", "cache"}},
+		{name: "www", requires: []string{"fpm"}},
+		{name: "cron", requires: []string{"db"}},
+	}
+
+	tests := []struct {
+		name    string
+		targets []string
+		options TargetsOptions
+		order   []string // sorted, as the order within a level doesn't matter
+	}{
+		{name: "no options", targets: []string{"fpm"}, order: []string{"fpm"}},
+		{name: "with deps", targets: []string{"www"}, options: TargetsOptions{WithDependencies: true}, order: []string{"cache", "db", "fpm", "www"}},
+		{name: "with dependents", targets: []string{"db"}, options: TargetsOptions{WithDependents: true}, order: []string{"cron", "db", "fpm", "www"}},
+		{name: "with both", targets: []string{"fpm"}, options: TargetsOptions{WithDependencies: true, WithDependents: true}, order: []string{"cache", "db", "fpm", "www"}},
+		{name: "already a target", targets: []string{"www", "db"}, options: TargetsOptions{WithDependencies: true}, order: []string{"cache", "db", "fpm", "www"}},
+		{name: "nothing related", targets: []string{"cron"}, options: TargetsOptions{WithDependents: true}, order: []string{"cron"}},
+	}
+
+	for _, test := range tests {
+		nodes := makeTestTargetNodes(t, logger, testNodes)
+		targets := nodes.Targets(logger, test.targets, test.options)
+
+		order := targets.TargetOrder()
+		sort.Strings(order)
+		if !reflect.DeepEqual(order, test.order) {
+			t.Errorf("%s: targets %q, expected %q", test.name, order, test.order)
+		}
+	}
+}
+
+func TestTargetsProcessStop(t *testing.T) {
+	logger := log.MakeCliLog("test", ioutil.Discard, log.VERBOSITY_MESSAGE)
+
+	// one at a time, and in parallel, targets are skipped in the same way
+	for _, parallel := range []int{1, 4} {
+		nodes := makeTestTargetNodes(t, logger, []testTargetNode{
+			{name: "db"},
+			{name: "cache"},
+			{name: "www", requires: []string{"db"}},
+		})
+
+		targets := nodes.Targets(logger, []string{"$all"}, TargetsOptions{})
+		targets.SetParallel(parallel)
+
+		// db stops processing, but the rest of its level is still processed, and www, in the next level, is not
+		processed := []string{}
+		var lock sync.Mutex
+		err := targets.Process(logger, func(logger log.Log, target *Target) error {
+			lock.Lock()
+			defer lock.Unlock()
+			processed = append(processed, target.Name())
+			if target.Name() == "db" {
+				return StopProcess(NewClientError(ERROR_FAILED, "test", "db", nil))
+			}
+			return nil
+		})
+
+		sort.Strings(processed)
+		if err == nil || !reflect.DeepEqual(processed, []string{"cache", "db"}) {
+			t.Errorf("Process (parallel %d) returned %v after processing %q, expected an error after processing the first level", parallel, err, processed)
+		}
+		if records := targets.Summary().Records(); len(records) != 1 || records[0].Node != "www" || records[0].Result != RESULT_NOT_RUN {
+			t.Errorf("Expected www to be recorded as not run (parallel %d), got %+v", parallel, records)
+		}
+	}
+}
+
+func TestTargetsCycle(t *testing.T) {
+	logger := log.MakeCliLog("test", ioutil.Discard, log.VERBOSITY_MESSAGE)
+
+	tests := []struct {
+		name    string
+		nodes   []testTargetNode
+		targets []string
+		cycle   []string
+	}{
+		{
+			name:    "no cycle",
+			nodes:   []testTargetNode{{name: "www", requires: []string{"db"}}, {name: "db"}},
+			targets: []string{"$all"},
+			cycle:   nil,
+		},
+		{
+			name:    "self",
+			nodes:   []testTargetNode{{name: "www", requires: []string{"www"}}},
+			targets: []string{"$all"},
+			cycle:   []string{"www", "www"},
+		},
+		{
+			name:    "two nodes",
+			nodes:   []testTargetNode{{name: "www", requires: []string{"fpm"}}, {name: "fpm", requires: []string{"www"}}},
+			targets: []string{"$all"},
+			cycle:   []string{"www", "fpm", "www"},
+		},
+		{
+			name: "behind a dependency",
+			nodes: []testTargetNode{
+				{name: "www", requires: []string{"fpm"}},
+				{name: "fpm", requires: []string{"db"}},
+				{name: "db", requires: []string{"cache"}},
+				{name: "cache", requires: []string{"fpm"}},
+			},
+			targets: []string{"$all"},
+			cycle:   []string{"fpm", "db", "cache", "fpm"},
+		},
+		// the cycle passes through fpm, which is not a target
+		{
+			name:    "through a dependency that is not a target",
+			nodes:   []testTargetNode{{name: "www", requires: []string{"fpm"}}, {name: "fpm", requires: []string{"www"}}, {name: "db"}},
+			targets: []string{"www"},
+			cycle:   []string{"www", "fpm", "www"},
+		},
+		// a cycle that the targets don't depend on doesn't matter
+		{
+			name:    "not reached from the targets",
+			nodes:   []testTargetNode{{name: "www", requires: []string{"db"}}, {name: "db"}, {name: "cron", requires: []string{"cron"}}},
+			targets: []string{"www"},
+			cycle:   nil,
+		},
+	}
+
+	for _, test := range tests {
+		nodes := makeTestTargetNodes(t, logger, test.nodes)
+		targets := nodes.Targets(logger, test.targets, TargetsOptions{})
+
+		cycle, hasCycle := targets.Cycle()
+		if hasCycle != (test.cycle != nil) || !reflect.DeepEqual(cycle, test.cycle) {
+			t.Errorf("%s: cycle %q, expected %q", test.name, cycle, test.cycle)
+		}
+		if sorted := targets.Sort(); sorted == hasCycle {
+			t.Errorf("%s: Sort returned %v, with cycle %q", test.name, sorted, cycle)
+		}
+
+		// targets with a cycle are not processed
+		processed := 0
+		err := targets.Process(logger, func(logger log.Log, target *Target) error {
+			processed++
+			return nil
+		})
+		if hasCycle && (processed > 0 || !IsErrorKind(err, ERROR_INVALID)) {
+			t.Errorf("%s: processed %d targets with a cycle, returning %v", test.name, processed, err)
+		} else if !hasCycle && (processed != len(targets.TargetOrder()) || err != nil) {
+			t.Errorf("%s: processed %d of %d targets, returning %v", test.name, processed, len(targets.TargetOrder()), err)
+		}
+	}
+}
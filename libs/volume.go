@@ -0,0 +1,25 @@
+package libs
+
+/**
+ * @file Coach named volumes
+ *
+ * A volume node can be backed by a docker named volume, instead of a
+ * placeholder container that other nodes use through VolumesFrom.  This
+ * happens when the node has a Volume: setting (which may be empty, to use
+ * the default volume driver.)
+ *
+ * Other nodes can use the volume by using the volume node name as the
+ * source of a bind, which coach maps to the docker volume name:
+ *
+ *   Binds:
+ *     - "data:/var/lib/mysql"
+ */
+
+import (
+	"strings"
+)
+
+// The docker volume name for a named volume node
+func NodeVolumeName(node Node) string {
+	return strings.ToLower(node.MachineName())
+}
@@ -1,10 +1,12 @@
 package log
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"strconv"
 	"strings"
+	"sync"
 	"unicode/utf8"
 )
 
@@ -31,16 +33,31 @@ func (log *CliLog) SetVerbosity(verbosity int) {
 	log.verbosity = verbosity
 }
 func (log *CliLog) MakeChild(target string) Log {
+	return log.makeChild(target, log.writer)
+}
+func (log *CliLog) MakeBufferedChild(target string) Log {
+	return log.makeChild(target, &bufferedWriter{parent: log.writer})
+}
+func (log *CliLog) makeChild(target string, writer io.Writer) Log {
+	// copy the stack, so that sibling logs don't share a backing array
+	stack := append(append([]string{}, log.stack...), target)
 	return Log(&CliLog{
 		CliLogSettings: CliLogSettings{
-			writer:    log.writer,
-			stack:     append(log.stack, target),
+			writer:    writer,
+			stack:     stack,
 			verbosity: log.verbosity,
 			hush:      log.hush,
 		},
 	})
 }
 
+// Write out any output held by a buffered log
+func (log *CliLog) Flush() {
+	if buffered, ok := log.writer.(*bufferedWriter); ok {
+		buffered.Flush()
+	}
+}
+
 func (log *CliLog) IsHushed() bool {
 	return log.hush
 }
@@ -81,7 +98,7 @@ func (log *CliLog) Info(messages ...string) {
 func (log *CliLog) Debug(verbosity int, message string, objects ...interface{}) {
 	log.writeLog(verbosity, message)
 	if +verbosity <= log.verbosity && len(objects) > 0 && objects[0] != nil {
-		fmt.Print("	")
+		fmt.Fprint(log, "	")
 		fmt.Fprintln(log, objects...)
 	}
 }
@@ -151,6 +168,42 @@ func (log *CliLog) joinStack() string {
 // Implement io.writer
 // Direct write a string of Bytes
 func (log *CliLog) Write(message []byte) (int, error) {
-	fmt.Print(string(message))
-	return len(message), nil
+	return log.writer.Write(message)
+}
+
+/**
+ * Log writers, which keep output from concurrent logs readable
+ */
+
+// A writer that can be shared by concurrent logs, so that writes don't interleave
+type syncWriter struct {
+	lock   sync.Mutex
+	writer io.Writer
+}
+
+func (writer *syncWriter) Write(message []byte) (int, error) {
+	writer.lock.Lock()
+	defer writer.lock.Unlock()
+	return writer.writer.Write(message)
+}
+
+// A writer that holds output until it is flushed to a parent writer in a single write
+type bufferedWriter struct {
+	lock   sync.Mutex
+	buffer bytes.Buffer
+	parent io.Writer
+}
+
+func (writer *bufferedWriter) Write(message []byte) (int, error) {
+	writer.lock.Lock()
+	defer writer.lock.Unlock()
+	return writer.buffer.Write(message)
+}
+func (writer *bufferedWriter) Flush() {
+	writer.lock.Lock()
+	defer writer.lock.Unlock()
+	if writer.buffer.Len() > 0 {
+		writer.parent.Write(writer.buffer.Bytes())
+		writer.buffer.Reset()
+	}
 }
@@ -26,6 +26,8 @@ type Log interface {
 	SetVerbosity(verbosity int) // set a new verbosity for the log
 
 	MakeChild(target string) Log
+	MakeBufferedChild(target string) Log // a child log that holds its output until it is flushed
+	Flush()                              // write out any held output
 
 	Hush()          // Hush a log to make warnings, and messages less verbose
 	UnHush()        // Un hush the log
@@ -49,7 +51,7 @@ type Log interface {
 func MakeCliLog(name string, writer io.Writer, verbosity int) Log {
 	return Log(&CliLog{
 		CliLogSettings: CliLogSettings{
-			writer:    writer,
+			writer:    &syncWriter{writer: writer},
 			stack:     []string{name},
 			verbosity: verbosity,
 			hush:      false,
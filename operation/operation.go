@@ -1,6 +1,9 @@
 package operation
 
 import (
+	"errors"
+	"os"
+
 	"github.com/james-nesbitt/coach/conf"
 	"github.com/james-nesbitt/coach/libs"
 	"github.com/james-nesbitt/coach/log"
@@ -22,9 +25,13 @@ func MakeOperation(logger log.Log, project *conf.Project, name string, flags []s
 		operation = Operation(&InfoOperation{log: opLogger, targets: targets})
 	case "status":
 		operation = Operation(&StatusOperation{log: opLogger, targets: targets})
+	case "inspect":
+		operation = Operation(&InspectOperation{log: opLogger, targets: targets})
 
 	case "pull":
 		operation = Operation(&PullOperation{log: opLogger, targets: targets})
+	case "push":
+		operation = Operation(&PushOperation{log: opLogger, targets: targets})
 	case "build":
 		operation = Operation(&BuildOperation{log: opLogger, targets: targets})
 	case "destroy":
@@ -50,6 +57,19 @@ func MakeOperation(logger log.Log, project *conf.Project, name string, flags []s
 	case "commit":
 		operation = Operation(&CommitOperation{log: opLogger, targets: targets})
 
+	case "attach":
+		operation = Operation(&AttachOperation{log: opLogger, targets: targets})
+	case "events":
+		operation = Operation(&EventsOperation{log: opLogger, targets: targets})
+	case "stats":
+		operation = Operation(&StatsOperation{log: opLogger, targets: targets})
+	case "cp":
+		operation = Operation(&CpOperation{log: opLogger, targets: targets})
+	case "logs":
+		operation = Operation(&LogsOperation{log: opLogger, targets: targets})
+	case "exec":
+		operation = Operation(&ExecOperation{log: opLogger, targets: targets})
+
 	case "up":
 		operation = Operation(&UpOperation{log: opLogger, targets: targets})
 	case "clean":
@@ -89,6 +109,8 @@ type Operations struct {
 	settings       OperationsSettings
 	targets        *libs.Targets
 	operationsList []Operation
+
+	errors libs.Errors // the errors from all of the operations that have been run
 }
 
 // Constructor for the operations object
@@ -104,25 +126,59 @@ func (operations *Operations) AddOperation(operation Operation) {
 	operations.operationsList = append(operations.operationsList, operation)
 }
 
-// Run all of the prepared operations
-func (operations *Operations) Run(logger log.Log) {
+// Run all of the prepared operations, write a summary of the attempted actions, and return an exit
+// code for the process, which is not 0 if anything failed
+func (operations *Operations) Run(logger log.Log) int {
+	exitCode := 0
 	if len(operations.operationsList) == 0 {
 		logger.Error("No operation created")
-	} else {
-		for _, operation := range operations.operationsList {
-			operation.Run(logger.MakeChild(operation.Id()))
+		return 1
+	}
+
+	for _, operation := range operations.operationsList {
+		operations.errors.Add(operation.Run(logger.MakeChild(operation.Id())))
+
+		if exitCodeOperation, ok := operation.(ExitCodeOperation); ok && exitCodeOperation.ExitCode() != 0 {
+			exitCode = exitCodeOperation.ExitCode()
 		}
 	}
+
+	if operations.targets != nil {
+		writeSummary(logger.MakeChild("summary"), operations.targets.Summary())
+	}
+
+	// skipped actions are not failures
+	if exitCode == 0 && len(operations.errors.Failures()) > 0 {
+		exitCode = 1
+	}
+	return exitCode
+}
+
+// The errors from all of the operations that have been run, or nil if there were none.  Client
+// errors can be checked using libs.ErrorKind, and skipped actions using libs.IsSkipped.
+func (operations *Operations) Errors() error {
+	return operations.errors.Err()
 }
 
 // Operation that can act on A target list
 type Operation interface {
 	Id() string
 	Flags(flags []string) bool
-	Run(log.Log) bool
+	Run(log.Log) error // run the operation, returning the aggregated errors from any client actions
 	Help(topics []string)
 }
 
+// Operation that can set the process exit code, such as one that runs a command in a container
+type ExitCodeOperation interface {
+	ExitCode() int
+}
+
+// Is a file a terminal (for TTY handling, and colour output)
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && (info.Mode()&os.ModeCharDevice) != 0
+}
+
 func ListOperations() []string {
 	return []string{
 		"help",
@@ -131,6 +187,7 @@ func ListOperations() []string {
 		"init-generate",
 		"tool",
 		"pull",
+		"push",
 		"build",
 		"clean",
 		"destroy",
@@ -142,8 +199,14 @@ func ListOperations() []string {
 		"restart",
 		"start",
 		"status",
+		"inspect",
 		"stop",
 		"attach",
+		"logs",
+		"exec",
+		"cp",
+		"stats",
+		"events",
 		"pause",
 		"unpause",
 		"commit",
@@ -176,11 +239,11 @@ func (operation *UnknownOperation) Flags(flags []string) bool {
 func (operation *UnknownOperation) Help(flags []string) {
 	return
 }
-func (operation *UnknownOperation) Run(logger log.Log) bool {
+func (operation *UnknownOperation) Run(logger log.Log) error {
 	if operation.id == DEFAULT_OPERATION {
 		logger.Error("No operation specified")
-	} else {
-		logger.Error("Unknown operation: " + operation.id)
+		return errors.New("no operation specified")
 	}
-	return false
+	logger.Error("Unknown operation: " + operation.id)
+	return errors.New("unknown operation: " + operation.id)
 }
@@ -0,0 +1,161 @@
+package operation
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/james-nesbitt/coach/libs"
+	"github.com/james-nesbitt/coach/log"
+)
+
+type AttachOperation struct {
+	log     log.Log
+	targets *libs.Targets
+
+	options libs.AttachOptions
+}
+
+func (operation *AttachOperation) Id() string {
+	return "attach"
+}
+func (operation *AttachOperation) Flags(flags []string) bool {
+	operation.options = libs.AttachOptions{Stdin: true, Logs: true}
+
+	for index := 0; index < len(flags); index++ {
+		flag := flags[index]
+
+		switch flag {
+		case "--detach-keys":
+			if index+1 < len(flags) {
+				index++
+				operation.options.DetachKeys = flags[index]
+			}
+		case "--no-stdin":
+			operation.options.Stdin = false
+		case "--logs":
+			operation.options.Logs = true
+		case "--no-logs":
+			operation.options.Logs = false
+		default:
+			if strings.HasPrefix(flag, "--detach-keys=") {
+				operation.options.DetachKeys = strings.TrimPrefix(flag, "--detach-keys=")
+			} else {
+				operation.log.Warning("Unknown attach flag [" + flag + "]")
+			}
+		}
+	}
+	return true
+}
+func (operation *AttachOperation) Help(topics []string) {
+	operation.log.Message(`Operation: ATTACH
+
+Coach will attach the terminal to a running node instance container.
+
+SYNTAX:
+	$/> coach {targets} attach [--detach-keys {keys}] [--no-stdin] [--no-logs]
+
+	{targets} what target node instances the operation should process ($/> coach help targets)
+	--detach-keys {keys} : the key sequence used to detach from the container, e.g. "ctrl-p,ctrl-q" (docker default)
+	--no-stdin : don't attach stdin, only watch the container output
+	--no-logs : don't replay the existing container logs before attaching (--logs to replay them, the default)
+
+ACCESS:
+	- this operation processes only nodes with the "attach" access.  This excludes build and pull nodes, and named volume nodes.
+
+NOTES:
+	- Only one instance can be attached to.  If the targets match more than one running instance, then you will be asked to pick one, or you can pick one with @{node}:{instance}.
+	- Detaching leaves the container running, but stopping the container process (e.g. ctrl-c without a TTY) will stop the container.
+
+EXAMPLES:
+	$/> coach @www:2 attach
+	$/> coach @db attach --no-stdin --no-logs
+`)
+}
+func (operation *AttachOperation) Run(logger log.Log) error {
+	logger.Info("Running operation: attach")
+	logger.Debug(log.VERBOSITY_DEBUG, "Run:Targets", operation.targets.TargetOrder())
+
+	names := []string{}
+	clients := []libs.InstanceClient{}
+
+	for _, targetID := range operation.targets.TargetOrder() {
+		target, targetExists := operation.targets.Target(targetID)
+		if !targetExists {
+			// this is strange
+			logger.Warning("Internal target error, was told to use a target that doesn't exist")
+			continue
+		}
+
+		node, hasNode := target.Node()
+		instances, hasInstances := target.Instances()
+		nodeLogger := logger.MakeChild(targetID)
+
+		if !hasNode {
+			nodeLogger.Warning("No node [" + node.MachineName() + "]")
+		} else if !node.Can("attach") {
+			nodeLogger.Info("Node doesn't attach [" + node.MachineName() + ":" + node.Type() + "]")
+		} else if !hasInstances {
+			nodeLogger.Info("No valid instances specified in target list [" + node.MachineName() + "]")
+		} else {
+			if !instances.IsFiltered() {
+				instances.UseAll()
+			}
+
+			for _, id := range instances.InstancesOrder() {
+				instance, _ := instances.Instance(id)
+				if !instance.IsRunning() {
+					nodeLogger.Info("Instance is not running [" + id + "]")
+					continue
+				}
+
+				names = append(names, node.Id()+":"+id)
+				clients = append(clients, instance.Client())
+			}
+		}
+	}
+
+	if len(clients) == 0 {
+		logger.Error("No running instances were found to attach to")
+		return libs.NewClientError(libs.ERROR_NOT_RUNNING, "attach", strings.Join(operation.targets.TargetOrder(), ","), nil)
+	}
+
+	index := 0
+	if len(clients) > 1 {
+		var picked bool
+		if index, picked = operation.pick(logger, names); !picked {
+			return errors.New("no instance was picked to attach to")
+		}
+	}
+
+	return clients[index].Attach(logger.MakeChild(names[index]), operation.options)
+}
+
+// Ask the user to pick one of several matching instances
+func (operation *AttachOperation) pick(logger log.Log, names []string) (int, bool) {
+	if !isTerminal(os.Stdin) {
+		logger.Error("The targets match " + strconv.Itoa(len(names)) + " running instances [" + strings.Join(names, ", ") + "].  Pick one instance using @{node}:{instance}.")
+		return 0, false
+	}
+
+	logger.Message("The targets match more than one running instance:")
+	for index, name := range names {
+		logger.Message("  " + strconv.Itoa(index+1) + ") " + name)
+	}
+	fmt.Print("Which instance should be attached to? [1-" + strconv.Itoa(len(names)) + "]: ")
+
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		logger.Error("No instance was picked to attach to")
+		return 0, false
+	}
+	choice, err := strconv.Atoi(strings.TrimSpace(answer))
+	if err != nil || choice < 1 || choice > len(names) {
+		logger.Error("Invalid instance choice [" + strings.TrimSpace(answer) + "]")
+		return 0, false
+	}
+	return choice - 1, true
+}
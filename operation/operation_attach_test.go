@@ -0,0 +1,31 @@
+package operation
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/james-nesbitt/coach/libs"
+	"github.com/james-nesbitt/coach/log"
+)
+
+func TestAttachFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		flags   []string
+		options libs.AttachOptions
+	}{
+		{name: "defaults", flags: []string{}, options: libs.AttachOptions{Stdin: true, Logs: true}},
+		{name: "detach keys", flags: []string{"--detach-keys", "ctrl-x,x"}, options: libs.AttachOptions{DetachKeys: "ctrl-x,x", Stdin: true, Logs: true}},
+		{name: "detach keys with an equals", flags: []string{"--detach-keys=ctrl-x,x"}, options: libs.AttachOptions{DetachKeys: "ctrl-x,x", Stdin: true, Logs: true}},
+		{name: "watch only", flags: []string{"--no-stdin", "--no-logs"}, options: libs.AttachOptions{}},
+		{name: "last logs flag wins", flags: []string{"--no-logs", "--logs"}, options: libs.AttachOptions{Stdin: true, Logs: true}},
+	}
+
+	for _, test := range tests {
+		operation := &AttachOperation{log: log.MakeCliLog("test", ioutil.Discard, log.VERBOSITY_MESSAGE)}
+		operation.Flags(test.flags)
+		if operation.options != test.options {
+			t.Errorf("%s: parsed options %+v, expected %+v", test.name, operation.options, test.options)
+		}
+	}
+}
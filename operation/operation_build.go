@@ -9,7 +9,8 @@ type BuildOperation struct {
 	log     log.Log
 	targets *libs.Targets
 
-	force bool
+	force   bool
+	options libs.BuildOptions
 }
 
 func (operation *BuildOperation) Id() string {
@@ -17,6 +18,7 @@ func (operation *BuildOperation) Id() string {
 }
 func (operation *BuildOperation) Flags(flags []string) bool {
 	operation.force = false
+	operation.options = libs.BuildOptions{}
 
 	for _, flag := range flags {
 		switch flag {
@@ -24,6 +26,10 @@ func (operation *BuildOperation) Flags(flags []string) bool {
 			fallthrough
 		case "--force":
 			operation.force = true
+		case "--no-cache":
+			operation.options.NoCache = true
+		case "--pull":
+			operation.options.Pull = true
 		}
 	}
 	return true
@@ -36,32 +42,29 @@ Coach will attempt to build a new docker image, for each target node that has a
 The operation will look for a Build: setting inside the node, and try to find a matching Dockerfile at the suggested path.  The path can be relative to the project root, or absolute.
 
 SYNTAX:
-	$/> coach {targets} build
+	$/> coach {targets} build [--force] [--no-cache] [--pull]
 
 	{targets} what target nodes the operation should process ($/> coach help targets)
+	--force : build the image even if it already exists
+	--no-cache : build without using any cached image layers
+	--pull : always try to pull a newer version of the parent image
 
 ACCESS:
 	- this operation processes only nodes with the "build" access.  This includes only nodes with a Build: setting.
 
 NOTES:
 - a node that can be built should have a Build: setting, which points to a project path that contains the Dockerfile.
+- a node can also have BuildArgs:, Dockerfile:, NoCache:, Pull:, Target: and Labels: settings, which are passed to the docker build.
 - while {targets} globally can specify particular node instances, that information is ignored for this operation, as images are built for all instances of a node.
 `)
 }
-func (operation *BuildOperation) Run(logger log.Log) bool {
+func (operation *BuildOperation) Run(logger log.Log) error {
 	logger.Info("Running operation: build")
-	logger.Debug(log.VERBOSITY_DEBUG, "Run:Targets", operation.targets.TargetOrder())
 
-	for _, targetID := range operation.targets.TargetOrder() {
-		target, targetExists := operation.targets.Target(targetID)
-		if !targetExists {
-			// this is strange
-			logger.Warning("Internal target error, was told to use a target that doesn't exist")
-			continue
-		}
+	return operation.targets.Process(logger, func(nodeLogger log.Log, target *libs.Target) error {
+		errs := libs.Errors{}
 
 		node, hasNode := target.Node()
-		nodeLogger := logger.MakeChild(targetID)
 
 		if !hasNode {
 			nodeLogger.Info("No node [" + node.MachineName() + "]")
@@ -69,9 +72,9 @@ func (operation *BuildOperation) Run(logger log.Log) bool {
 			nodeLogger.Info("Node doesn't build [" + node.MachineName() + "]")
 		} else {
 			nodeLogger.Message("Building node")
-			node.Client().Build(nodeLogger, operation.force)
+			errs.Add(target.Record("", "build", node.Client().Build(nodeLogger, operation.force, operation.options)))
 		}
-	}
 
-	return true
+		return errs.Err()
+	})
 }
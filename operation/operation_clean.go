@@ -1,6 +1,8 @@
 package operation
 
 import (
+	"sync"
+
 	"github.com/james-nesbitt/coach/libs"
 	"github.com/james-nesbitt/coach/log"
 )
@@ -59,30 +61,32 @@ Syntax:
 	$/> coach {targets} clean
 
 	$/> coach {targets} clean --wipe   
-	  also wipe any built images
+	  also wipe any built images, and any named volumes
 
 	- to eliminate any timeout delays on "docker stop" calls, use this Syntax
 	$/> coach {targets} clean --quick
 
+	- any project networks are removed once none of their containers remain
+
+	- images, volumes and containers that are already gone are skipped, not failed
+
 	{targets} what target node instances the operation should process ($/> coach help targets)
 
 `)
 }
-func (operation *CleanOperation) Run(logger log.Log) bool {
+func (operation *CleanOperation) Run(logger log.Log) error {
 	logger.Info("Running operation: clean")
-	logger.Debug(log.VERBOSITY_DEBUG, "Run:Targets", operation.targets.TargetOrder())
-
-	for _, targetID := range operation.targets.TargetOrder() {
-		target, targetExists := operation.targets.Target(targetID)
-		if !targetExists {
-			// this is strange
-			logger.Warning("Internal target error, was told to use a target that doesn't exist")
-			continue
-		}
+
+	// volumes and networks are removed after all of the nodes are cleaned, as they are shared between nodes
+	cleanedNodes := []libs.Node{}
+	var cleanedLock sync.Mutex
+
+	errs := libs.Errors{}
+	errs.Add(operation.targets.ProcessReverse(logger, func(nodeLogger log.Log, target *libs.Target) error {
+		nodeErrs := libs.Errors{}
 
 		node, hasNode := target.Node()
 		instances, hasInstances := target.Instances()
-		nodeLogger := logger.MakeChild(targetID)
 
 		if !hasNode {
 			nodeLogger.Info("No node [" + node.MachineName() + "]")
@@ -91,6 +95,10 @@ func (operation *CleanOperation) Run(logger log.Log) bool {
 		} else {
 			nodeLogger.Message("Cleaning node [" + node.Id() + "]")
 
+			cleanedLock.Lock()
+			cleanedNodes = append(cleanedNodes, node)
+			cleanedLock.Unlock()
+
 			if hasInstances {
 				if !(operation.defaultOnly || instances.IsFiltered()) {
 					nodeLogger.Info("Switching to using all instances")
@@ -105,12 +113,13 @@ func (operation *CleanOperation) Run(logger log.Log) bool {
 
 						if instanceClient.HasContainer() {
 							if instanceClient.IsRunning() {
-								instanceClient.Stop(logger, operation.force, operation.timeout)
+								nodeErrs.Add(target.Record(id, "stop", libs.SkipNotFound(instanceClient.Stop(nodeLogger, operation.force, operation.timeout))))
 							}
-							instanceClient.Remove(logger, operation.force)
+							nodeErrs.Add(target.Record(id, "remove", libs.SkipNotFound(instanceClient.Remove(nodeLogger, operation.force))))
 							nodeLogger.Message("Cleaning node instance [" + id + "]")
 						} else {
 							nodeLogger.Info("Node instance has no container to clean [" + id + "]")
+							target.Skip(id, "remove", "no container")
 						}
 					}
 				} else {
@@ -120,14 +129,25 @@ func (operation *CleanOperation) Run(logger log.Log) bool {
 
 			if operation.wipe && node.Can("build") {
 				nodeClient := node.Client()
-				nodeClient.Destroy(nodeLogger, operation.force)
+				nodeErrs.Add(target.Record("", "destroy", libs.SkipNotFound(nodeClient.Destroy(nodeLogger, operation.force))))
 				nodeLogger.Message("Node build cleaned")
 			} else {
 				nodeLogger.Message("Node was not built, so will not be removed")
 			}
 
 		}
+
+		return nodeErrs.Err()
+	}))
+
+	for _, node := range cleanedNodes {
+		if operation.wipe && node.Can("volume") {
+			errs.Add(operation.targets.Summary().Record(node.Id(), "", "remove-volume", libs.SkipNotFound(node.Client().RemoveVolume(logger.MakeChild(node.Id()), operation.force))))
+		}
+	}
+	for _, node := range cleanedNodes {
+		errs.Add(operation.targets.Summary().Record(node.Id(), "", "remove-networks", node.Client().RemoveNetworks(logger.MakeChild(node.Id()))))
 	}
 
-	return true
+	return errs.Err()
 }
@@ -61,23 +61,15 @@ ACCESS:
 
 `)
 }
-func (operation *CommitOperation) Run(logger log.Log) bool {
+func (operation *CommitOperation) Run(logger log.Log) error {
 	logger.Info("Running operation: commit")
-	logger.Debug(log.VERBOSITY_DEBUG, "Run:Targets", operation.targets.TargetOrder())
-
-	for _, targetID := range operation.targets.TargetOrder() {
-		target, targetExists := operation.targets.Target(targetID)
-		if !targetExists {
-			// this is strange
-			logger.Warning("Internal target error, was told to use a target that doesn't exist")
-			continue
-		}
+
+	return operation.targets.Process(logger, func(nodeLogger log.Log, target *libs.Target) error {
+		errs := libs.Errors{}
 
 		node, hasNode := target.Node()
 		instances, hasInstances := target.Instances()
 
-		nodeLogger := logger.MakeChild(targetID)
-
 		if !hasNode {
 			nodeLogger.Warning("No node [" + node.MachineName() + "]")
 		} else if !node.Can("Commit") {
@@ -88,10 +80,10 @@ func (operation *CommitOperation) Run(logger log.Log) bool {
 			nodeLogger.Message("Committing instance container")
 			for _, id := range instances.InstancesOrder() {
 				instance, _ := instances.Instance(id)
-				instance.Client().Commit(logger, operation.tag, operation.message)
+				errs.Add(target.Record(id, "commit", instance.Client().Commit(nodeLogger, operation.tag, operation.message)))
 			}
 		}
-	}
 
-	return true
+		return errs.Err()
+	})
 }
@@ -0,0 +1,171 @@
+package operation
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/james-nesbitt/coach/libs"
+	"github.com/james-nesbitt/coach/log"
+)
+
+type CpOperation struct {
+	log     log.Log
+	targets *libs.Targets
+
+	source      string
+	destination string
+}
+
+func (operation *CpOperation) Id() string {
+	return "cp"
+}
+func (operation *CpOperation) Flags(flags []string) bool {
+	operation.source = ""
+	operation.destination = ""
+
+	paths := []string{}
+	for _, flag := range flags {
+		if strings.HasPrefix(flag, "-") && flag != "-" {
+			operation.log.Warning("Unknown cp flag [" + flag + "]")
+			continue
+		}
+		paths = append(paths, flag)
+	}
+
+	if len(paths) > 0 {
+		operation.source = paths[0]
+	}
+	if len(paths) > 1 {
+		operation.destination = paths[1]
+	}
+	if len(paths) > 2 {
+		operation.log.Warning("Too many cp paths, only the first two are used")
+	}
+	return true
+}
+func (operation *CpOperation) Help(topics []string) {
+	operation.log.Message(`Operation: CP
+
+Coach will copy files or directories between the host and a node instance container.
+
+SYNTAX:
+	$/> coach cp {source} {destination}
+
+	{source} {destination} : one host path, and one instance container path using the syntax @{node}:{instance}:{path}
+		The instance can be left out (@{node}:{path}) if the node has only one instance with a container.
+
+ACCESS:
+	- this operation processes only nodes with the "cp" access.  This excludes build and pull nodes, and named volume nodes.
+
+NOTES:
+	- The destination is the full path of the copy, so copying a directory to a path that already exists replaces it, instead of copying into it.
+	- The files are copied through the docker API, so they can be copied to and from remote docker hosts, where Binds don't work.
+	- Instance containers don't have to be running, so volume containers can be copied to and from.
+
+EXAMPLES:
+	$/> coach cp @db:/tmp/dump.sql ./dump.sql
+	$/> coach cp ./config/nginx.conf @www:2:/etc/nginx/nginx.conf
+`)
+}
+func (operation *CpOperation) Run(logger log.Log) error {
+	logger.Info("Running operation: cp")
+	logger.Debug(log.VERBOSITY_DEBUG, "Run:Targets", operation.targets.TargetOrder())
+
+	if operation.source == "" || operation.destination == "" {
+		logger.Error("cp needs a source and a destination path")
+		return errors.New("cp needs a source and a destination path")
+	}
+
+	sourceIsInstance := strings.HasPrefix(operation.source, "@")
+	destinationIsInstance := strings.HasPrefix(operation.destination, "@")
+	if sourceIsInstance == destinationIsInstance {
+		logger.Error("cp needs one host path, and one instance path using the syntax @{node}:{instance}:{path}")
+		return errors.New("cp needs one host path, and one instance path")
+	}
+
+	if sourceIsInstance {
+		name, client, containerPath, ok := operation.instancePath(logger, operation.source)
+		if !ok {
+			return errors.New("invalid instance path: " + operation.source)
+		}
+		return client.Download(logger.MakeChild(name), containerPath, operation.destination)
+	} else {
+		name, client, containerPath, ok := operation.instancePath(logger, operation.destination)
+		if !ok {
+			return errors.New("invalid instance path: " + operation.destination)
+		}
+		return client.Upload(logger.MakeChild(name), operation.source, containerPath)
+	}
+}
+
+// Find the instance client and container path for an @{node}:{instance}:{path} argument
+func (operation *CpOperation) instancePath(logger log.Log, argument string) (name string, client libs.InstanceClient, containerPath string, ok bool) {
+	separated := strings.SplitN(argument[1:], ":", 3)
+	nodeId, instanceId := separated[0], ""
+	switch len(separated) {
+	case 2:
+		containerPath = separated[1]
+	case 3:
+		if strings.HasPrefix(separated[1], "/") {
+			// no instance, but the container path has a colon in it
+			containerPath = separated[1] + ":" + separated[2]
+		} else {
+			instanceId, containerPath = separated[1], separated[2]
+		}
+	}
+	if nodeId == "" || containerPath == "" {
+		logger.Error("Invalid instance path [" + argument + "], use the syntax @{node}:{instance}:{path}")
+		return
+	}
+
+	target, targetExists := operation.targets.Target(nodeId)
+	if !targetExists {
+		logger.Error("Unknown node, or the node is not targeted [" + nodeId + "]")
+		return
+	}
+	node, hasNode := target.Node()
+	instances, hasInstances := target.Instances()
+	if !hasNode || !hasInstances {
+		logger.Error("Node has no instances [" + nodeId + "]")
+		return
+	} else if !node.Can("cp") {
+		logger.Error("Node can't copy files [" + node.MachineName() + ":" + node.Type() + "]")
+		return
+	}
+
+	if instanceId != "" {
+		instance, found := node.Instances().Instance(instanceId)
+		if !found {
+			logger.Error("Unknown node instance [" + nodeId + ":" + instanceId + "]")
+			return
+		}
+		if !instance.Client().HasContainer() {
+			logger.Error("Instance has no container [" + nodeId + ":" + instanceId + "]")
+			return
+		}
+		return nodeId + ":" + instanceId, instance.Client(), containerPath, true
+	}
+
+	// without an instance, the node must have exactly one instance with a container
+	if !instances.IsFiltered() {
+		instances.UseAll()
+	}
+	names := []string{}
+	for _, id := range instances.InstancesOrder() {
+		instance, _ := instances.Instance(id)
+		if instance.Client().HasContainer() {
+			names = append(names, nodeId+":"+id)
+			client = instance.Client()
+		}
+	}
+	switch len(names) {
+	case 0:
+		logger.Error("Node has no instance containers [" + nodeId + "]")
+	case 1:
+		return names[0], client, containerPath, true
+	default:
+		logger.Error("Node has " + strconv.Itoa(len(names)) + " instance containers [" + strings.Join(names, ", ") + "].  Pick one instance using @{node}:{instance}:{path}.")
+	}
+	return "", nil, "", false
+}
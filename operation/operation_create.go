@@ -41,36 +41,32 @@ Access:
 	- only nodes with the "create" access are processed.  This excludes build and command nodes
 `)
 }
-func (operation *CreateOperation) Run(logger log.Log) bool {
+func (operation *CreateOperation) Run(logger log.Log) error {
 	logger.Info("Running operation: create")
-	logger.Debug(log.VERBOSITY_DEBUG, "Run:Targets", operation.targets.TargetOrder())
 
-	for _, targetID := range operation.targets.TargetOrder() {
-		target, targetExists := operation.targets.Target(targetID)
-		if !targetExists {
-			// this is strange
-			logger.Warning("Internal target error, was told to use a target that doesn't exist")
-			continue
-		}
+	return operation.targets.Process(logger, func(nodeLogger log.Log, target *libs.Target) error {
+		errs := libs.Errors{}
 
 		node, hasNode := target.Node()
 		instances, hasInstances := target.Instances()
-		nodeLogger := logger.MakeChild(targetID)
 
 		if !hasNode {
 			nodeLogger.Warning("No node [" + node.MachineName() + "]")
 		} else if !node.Can("create") {
 			nodeLogger.Info("Node doesn't create [" + node.MachineName() + ":" + node.Type() + "]")
+		} else if node.Can("volume") {
+			nodeLogger.Message("Creating node volume")
+			errs.Add(target.Record("", "create-volume", node.Client().CreateVolume(nodeLogger)))
 		} else if !hasInstances {
 			nodeLogger.Info("No valid instances specified in target list [" + node.MachineName() + "]")
 		} else {
 			nodeLogger.Message("Creating instance containers")
 			for _, id := range instances.InstancesOrder() {
 				instance, _ := instances.Instance(id)
-				instance.Client().Create(logger, []string{}, operation.force)
+				errs.Add(target.Record(id, "create", instance.Client().Create(nodeLogger, []string{}, operation.force)))
 			}
 		}
-	}
 
-	return true
+		return errs.Err()
+	})
 }
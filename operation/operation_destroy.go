@@ -44,20 +44,13 @@ NOTE:
 	- Coach will try not to remove an image for a node that does not build, which is the common case for nodes with an image, but no build setting.  This prevents deleting shared images that are not build targets.
 `)
 }
-func (operation *DestroyOperation) Run(logger log.Log) bool {
+func (operation *DestroyOperation) Run(logger log.Log) error {
 	logger.Info("Running operation: destroy")
-	logger.Debug(log.VERBOSITY_DEBUG, "Run:Targets", operation.targets.TargetOrder())
 
-	for _, targetID := range operation.targets.TargetOrder() {
-		target, targetExists := operation.targets.Target(targetID)
-		if !targetExists {
-			// this is strange
-			logger.Warning("Internal target error, was told to use a target that doesn't exist")
-			continue
-		}
+	return operation.targets.ProcessReverse(logger, func(nodeLogger log.Log, target *libs.Target) error {
+		errs := libs.Errors{}
 
 		node, hasNode := target.Node()
-		nodeLogger := logger.MakeChild(targetID)
 
 		if !hasNode {
 			nodeLogger.Info("No node [" + node.MachineName() + "]")
@@ -65,9 +58,9 @@ func (operation *DestroyOperation) Run(logger log.Log) bool {
 			nodeLogger.Info("Node doesn't Destroy [" + node.MachineName() + "]")
 		} else {
 			nodeLogger.Message("Destroying node")
-			node.Client().Destroy(nodeLogger, operation.force)
+			errs.Add(target.Record("", "destroy", libs.SkipNotFound(node.Client().Destroy(nodeLogger, operation.force))))
 		}
-	}
 
-	return true
+		return errs.Err()
+	})
 }
@@ -0,0 +1,171 @@
+package operation
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/james-nesbitt/coach/libs"
+	"github.com/james-nesbitt/coach/log"
+)
+
+type EventsOperation struct {
+	log     log.Log
+	targets *libs.Targets
+
+	options libs.EventsOptions
+}
+
+func (operation *EventsOperation) Id() string {
+	return "events"
+}
+func (operation *EventsOperation) Flags(flags []string) bool {
+	operation.options = libs.EventsOptions{}
+
+	for index := 0; index < len(flags); index++ {
+		flag := flags[index]
+
+		switch flag {
+		case "--since":
+			if index+1 < len(flags) {
+				index++
+				operation.options.Since = flags[index]
+			}
+		case "--until":
+			if index+1 < len(flags) {
+				index++
+				operation.options.Until = flags[index]
+			}
+		case "--type":
+			if index+1 < len(flags) {
+				index++
+				operation.options.Types = append(operation.options.Types, flags[index])
+			}
+		case "--event":
+			if index+1 < len(flags) {
+				index++
+				operation.options.Actions = append(operation.options.Actions, flags[index])
+			}
+		}
+	}
+	return true
+}
+func (operation *EventsOperation) Help(topics []string) {
+	operation.log.Message(`Operation: EVENTS
+
+Coach will show the docker events for the containers, images and volumes of target nodes.
+
+SYNTAX:
+	$/> coach {targets} events [--since {time}] [--until {time}] [--type {type}] [--event {action}]
+
+	{targets} what target nodes the operation should process ($/> coach help targets)
+	--since {time} : show events since a unix timestamp, an RFC3339 time, or a relative duration like 10m
+	--until {time} : stop at a unix timestamp, an RFC3339 time, or a relative duration
+	--type {type} : only show events for container, image or volume resources (can be repeated)
+	--event {action} : only show events with an action, such as start, die or destroy (can be repeated)
+
+NOTES:
+	- Without --until, events are streamed until interrupted.
+	- With --until, the events are sorted by time before they are shown.
+	- Events are matched to nodes using the coach labels, so resources created by older versions of coach have no events.
+
+EXAMPLES:
+	$/> coach events --since 1h --until 0s --type container
+	$/> coach @db events --event die --event oom
+`)
+}
+func (operation *EventsOperation) Run(logger log.Log) error {
+	logger.Info("Running operation: events")
+	logger.Debug(log.VERBOSITY_DEBUG, "Run:Targets", operation.targets.TargetOrder())
+
+	events := make(chan libs.ProjectEvent, 100)
+	errs := libs.Errors{}
+
+	// nodes that share a client backend share a single project event stream, limited to those nodes
+	streams := []libs.NodeClient{}
+	streamNodes := map[interface{}][]string{}
+	for _, targetID := range operation.targets.TargetOrder() {
+		target, targetExists := operation.targets.Target(targetID)
+		if !targetExists {
+			// this is strange
+			logger.Warning("Internal target error, was told to use a target that doesn't exist")
+			continue
+		}
+
+		node, hasNode := target.Node()
+		if !hasNode {
+			logger.MakeChild(targetID).Warning("No node [" + node.MachineName() + "]")
+			continue
+		}
+		client := node.Client()
+		if client == nil {
+			logger.MakeChild(targetID).Info("Node has no client, so it has no events [" + node.MachineName() + "]")
+			continue
+		}
+
+		stream := client.EventsStream()
+		if _, exists := streamNodes[stream]; !exists {
+			streams = append(streams, client)
+		}
+		streamNodes[stream] = append(streamNodes[stream], node.Id())
+	}
+
+	var wait sync.WaitGroup
+	var lock sync.Mutex
+	for _, client := range streams {
+		options := operation.options
+		options.Nodes = streamNodes[client.EventsStream()]
+
+		wait.Add(1)
+		go func(client libs.NodeClient, options libs.EventsOptions) {
+			defer wait.Done()
+			if err := client.Events(logger, options, events); err != nil {
+				lock.Lock()
+				errs.Add(err)
+				lock.Unlock()
+			}
+		}(client, options)
+	}
+	go func() {
+		wait.Wait()
+		close(events)
+	}()
+
+	if operation.options.Until == "" {
+		for event := range events {
+			eventsPrint(event)
+		}
+	} else {
+		// the event history for each client backend arrives separately, so it is sorted before it is shown
+		history := []libs.ProjectEvent{}
+		for event := range events {
+			history = append(history, event)
+		}
+		sort.SliceStable(history, func(i, j int) bool {
+			return history[i].Time.Before(history[j].Time)
+		})
+		for _, event := range history {
+			eventsPrint(event)
+		}
+	}
+
+	return errs.Err()
+}
+
+// Output an event line, in a similar layout to docker events
+func eventsPrint(event libs.ProjectEvent) {
+	name := event.Node
+	if event.Instance != "" {
+		name += ":" + event.Instance
+	}
+	id := event.ID
+	if len(id) > 12 && event.Type != "volume" {
+		id = id[:12]
+	}
+	line := event.Time.Format(time.RFC3339Nano) + " " + event.Type + " " + event.Action + " " + name + " " + id
+	if event.Name != "" && event.Name != event.ID {
+		line += " (" + event.Name + ")"
+	}
+	fmt.Println(line)
+}
@@ -0,0 +1,194 @@
+package operation
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/james-nesbitt/coach/libs"
+	"github.com/james-nesbitt/coach/log"
+)
+
+type ExecOperation struct {
+	log     log.Log
+	targets *libs.Targets
+
+	all     bool
+	noTty   bool
+	options libs.ExecOptions
+	cmd     []string
+
+	exitCode int
+}
+
+func (operation *ExecOperation) Id() string {
+	return "exec"
+}
+func (operation *ExecOperation) Flags(flags []string) bool {
+	operation.all = false
+	operation.noTty = false
+	operation.options = libs.ExecOptions{Stdin: true}
+	operation.cmd = []string{}
+
+	// flags are read until the first argument that isn't a flag, which starts the command
+	for index := 0; index < len(flags); index++ {
+		flag := flags[index]
+
+		switch flag {
+		case "-a":
+			fallthrough
+		case "--all":
+			operation.all = true
+		case "-T":
+			fallthrough
+		case "--no-tty":
+			operation.noTty = true
+		case "--no-stdin":
+			operation.options.Stdin = false
+		case "-u":
+			fallthrough
+		case "--user":
+			if index+1 < len(flags) {
+				index++
+				operation.options.User = flags[index]
+			}
+		case "-w":
+			fallthrough
+		case "--workdir":
+			if index+1 < len(flags) {
+				index++
+				operation.options.WorkingDir = flags[index]
+			}
+		case "-e":
+			fallthrough
+		case "--env":
+			if index+1 < len(flags) {
+				index++
+				operation.options.Env = append(operation.options.Env, flags[index])
+			}
+		default:
+			if strings.HasPrefix(flag, "-") {
+				operation.log.Warning("Unknown exec flag [" + flag + "]")
+				continue
+			}
+			operation.cmd = flags[index:]
+			return true
+		}
+	}
+	return true
+}
+func (operation *ExecOperation) Help(topics []string) {
+	operation.log.Message(`Operation: EXEC
+
+Coach will run a command inside a running node instance container.
+
+SYNTAX:
+	$/> coach {targets} exec [--user {user}] [--workdir {path}] [--env {KEY=VALUE}] [--no-tty] [--no-stdin] [--all] {command}
+
+	{targets} what target node instances the operation should process ($/> coach help targets)
+	{command} the command to run, and its arguments
+	--user {user} : the user to run the command as
+	--workdir {path} : the path inside the container to run the command in
+	--env {KEY=VALUE} : an environment variable for the command (can be repeated)
+	--no-tty : don't allocate a TTY (the default when stdin is not a terminal)
+	--no-stdin : don't attach stdin to the command
+	--all : run the command in every matching running instance, one after another
+
+ACCESS:
+	- this operation processes only nodes with the "exec" access.  This excludes build and pull nodes, and named volume nodes.
+
+NOTES:
+	- Unlike run, exec uses the existing running instance containers, instead of creating a disposable container.
+	- If the targets match more than one running instance, then pick one with @{node}:{instance}, or pass --all.
+	- coach exits with the exit code of the command (the last failing command, when using --all).
+
+EXAMPLES:
+	$/> coach @www:2 exec bash
+	$/> coach @db exec --user mysql mysqladmin status
+`)
+}
+func (operation *ExecOperation) Run(logger log.Log) error {
+	logger.Info("Running operation: exec")
+	logger.Debug(log.VERBOSITY_DEBUG, "Run:Targets", operation.targets.TargetOrder())
+
+	if len(operation.cmd) == 0 {
+		logger.Error("No command was given to exec")
+		operation.exitCode = 1
+		return errors.New("no command was given to exec")
+	}
+
+	names := []string{}
+	clients := []libs.InstanceClient{}
+
+	for _, targetID := range operation.targets.TargetOrder() {
+		target, targetExists := operation.targets.Target(targetID)
+		if !targetExists {
+			// this is strange
+			logger.Warning("Internal target error, was told to use a target that doesn't exist")
+			continue
+		}
+
+		node, hasNode := target.Node()
+		instances, hasInstances := target.Instances()
+		nodeLogger := logger.MakeChild(targetID)
+
+		if !hasNode {
+			nodeLogger.Warning("No node [" + node.MachineName() + "]")
+		} else if !node.Can("exec") {
+			nodeLogger.Info("Node doesn't exec [" + node.MachineName() + ":" + node.Type() + "]")
+		} else if !hasInstances {
+			nodeLogger.Info("No valid instances specified in target list [" + node.MachineName() + "]")
+		} else {
+			if !instances.IsFiltered() {
+				instances.UseAll()
+			}
+
+			for _, id := range instances.InstancesOrder() {
+				instance, _ := instances.Instance(id)
+				if !instance.IsRunning() {
+					nodeLogger.Info("Instance is not running [" + id + "]")
+					continue
+				}
+
+				names = append(names, node.Id()+":"+id)
+				clients = append(clients, instance.Client())
+			}
+		}
+	}
+
+	if len(clients) == 0 {
+		logger.Error("No running instances were found to exec in")
+		operation.exitCode = 1
+		return libs.NewClientError(libs.ERROR_NOT_RUNNING, "exec", strings.Join(operation.targets.TargetOrder(), ","), nil)
+	} else if len(clients) > 1 && !operation.all {
+		logger.Error("The targets match " + strconv.Itoa(len(clients)) + " running instances [" + strings.Join(names, ", ") + "].  Pick one instance using @{node}:{instance}, or use --all to exec in each of them.")
+		operation.exitCode = 1
+		return errors.New("the targets match more than one running instance")
+	}
+
+	// only allocate a TTY when coach is attached to a terminal
+	options := operation.options
+	options.Tty = !operation.noTty && isTerminal(os.Stdin)
+
+	errs := libs.Errors{}
+	for index, client := range clients {
+		instanceLogger := logger.MakeChild(names[index])
+
+		exitCode, err := client.Exec(instanceLogger, operation.cmd, options)
+		if err != nil {
+			errs.Add(err)
+			operation.exitCode = 1
+		} else if exitCode != 0 {
+			instanceLogger.Warning("Command exited with code " + strconv.Itoa(exitCode))
+			errs.Add(errors.New(names[index] + ": command exited with code " + strconv.Itoa(exitCode)))
+			operation.exitCode = exitCode
+		}
+	}
+	return errs.Err()
+}
+
+// The exit code of the command, for the coach process
+func (operation *ExecOperation) ExitCode() int {
+	return operation.exitCode
+}
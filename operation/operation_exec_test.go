@@ -0,0 +1,44 @@
+package operation
+
+import (
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	"github.com/james-nesbitt/coach/libs"
+	"github.com/james-nesbitt/coach/log"
+)
+
+func TestExecFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		flags   []string
+		all     bool
+		options libs.ExecOptions
+		cmd     []string
+	}{
+		{name: "command only", flags: []string{"bash"}, options: libs.ExecOptions{Stdin: true}, cmd: []string{"bash"}},
+		{
+			name:    "options before the command",
+			flags:   []string{"--user", "mysql", "-w", "/var/lib/mysql", "-e", "A=1", "--env", "B=2", "--no-stdin", "--all", "mysqladmin", "status"},
+			all:     true,
+			options: libs.ExecOptions{User: "mysql", WorkingDir: "/var/lib/mysql", Env: []string{"A=1", "B=2"}},
+			cmd:     []string{"mysqladmin", "status"},
+		},
+		{
+			name:    "flags after the command belong to the command",
+			flags:   []string{"ls", "--all", "-u", "root"},
+			options: libs.ExecOptions{Stdin: true},
+			cmd:     []string{"ls", "--all", "-u", "root"},
+		},
+		{name: "no command", flags: []string{"--no-tty"}, options: libs.ExecOptions{Stdin: true}, cmd: []string{}},
+	}
+
+	for _, test := range tests {
+		operation := &ExecOperation{log: log.MakeCliLog("test", ioutil.Discard, log.VERBOSITY_MESSAGE)}
+		operation.Flags(test.flags)
+		if operation.all != test.all || !reflect.DeepEqual(operation.options, test.options) || !reflect.DeepEqual(operation.cmd, test.cmd) {
+			t.Errorf("%s: parsed all %v, options %+v, command %q, expected all %v, options %+v, command %q", test.name, operation.all, operation.options, operation.cmd, test.all, test.options, test.cmd)
+		}
+	}
+}
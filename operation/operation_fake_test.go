@@ -0,0 +1,608 @@
+package operation
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/james-nesbitt/coach/conf"
+	"github.com/james-nesbitt/coach/libs"
+	"github.com/james-nesbitt/coach/log"
+)
+
+// A project with a db node, and a scaled www node that requires it and joins a shared network, both on the fake client
+var fakeProjectFiles = map[string]string{
+	"conf.yml": `
+Project: fakeproject
+`,
+	"clients.yml": `
+fake:
+  Type: fake
+  Images:
+    - "library/mariadb:latest"
+    - "library/nginx:latest"
+`,
+	"nodes.yml": `
+db:
+  Type: service
+  Client: fake
+  Docker:
+    Config:
+      Image: library/mariadb
+www:
+  Type: service
+  Client: fake
+  Requires:
+    - db
+  Scale:
+    Initial: 1
+    Maximum: 3
+  Docker:
+    Networks:
+      - shared
+    Config:
+      Image: library/nginx
+`,
+}
+
+// Build fake project nodes from a map of .coach files in a temporary project folder, returning the nodes and the fake backend
+func makeFakeProject(t *testing.T, logger log.Log, files map[string]string) (*conf.Project, *libs.Nodes, *libs.Fake_Backend) {
+	root, err := ioutil.TempDir("", "coach-fake")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(root) })
+
+	coachPath := path.Join(root, conf.COACH_PROJECT_CONF_FOLDER)
+	if err := os.Mkdir(coachPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, contents := range files {
+		if err := ioutil.WriteFile(path.Join(coachPath, name), []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	project := conf.MakeCoachProject(logger, root, conf.COACH_CONF_ENVIRONMENTS_DEFAULT)
+	factories := libs.MakeClientFactories(logger, project)
+	factory, ok := factories.ClientFactory("fake")
+	if !ok {
+		t.Fatal("The fake client factory was not created")
+	}
+
+	nodes := libs.MakeNodes(logger, project, factories)
+	nodes.Prepare(logger)
+	return project, nodes, factory.(*libs.Fake_ClientFactory).Backend()
+}
+
+// Run an operation on some of the fake project nodes, returning the calls that it made
+func runFakeOperation(t *testing.T, logger log.Log, project *conf.Project, nodes *libs.Nodes, backend *libs.Fake_Backend, parallel int, identifiers []string, name string, flags ...string) []string {
+	backend.ResetCalls()
+
+	targets := nodes.Targets(logger, identifiers, libs.TargetsOptions{})
+	targets.SetParallel(parallel)
+	operations := MakeOperation(logger, project, name, flags, targets)
+	if exitCode := operations.Run(logger); exitCode != 0 {
+		t.Errorf("%s operation exited with code %d", name, exitCode)
+	}
+
+	calls := []string{}
+	for _, call := range backend.Calls() {
+		calls = append(calls, call.String())
+	}
+	return calls
+}
+
+func TestFakeUpScaleClean(t *testing.T) {
+	// db and www are in different dependency levels, so the calls are the same when processed in parallel
+	for _, parallel := range []int{1, 4} {
+		testFakeUpScaleClean(t, parallel)
+	}
+}
+func testFakeUpScaleClean(t *testing.T, parallel int) {
+	logger := log.MakeCliLog("test", ioutil.Discard, log.VERBOSITY_MESSAGE)
+	project, nodes, backend := makeFakeProject(t, logger, fakeProjectFiles)
+
+	steps := []struct {
+		operation string
+		flags     []string
+		calls     []string
+	}{
+		// up works in dependency order, and waits for db to be ready before starting www
+		{
+			operation: "up",
+			calls: []string{
+				"create:fakeproject_db",
+				"start:fakeproject_db",
+				"wait-ready:fakeproject_db",
+				"create:fakeproject_www_0",
+				"start:fakeproject_www_0",
+				"create:fakeproject_www_1",
+				"start:fakeproject_www_1",
+			},
+		},
+		// scale starts the next www instance
+		{
+			operation: "scale",
+			flags:     []string{"up"},
+			calls: []string{
+				"create:fakeproject_www_2",
+				"start:fakeproject_www_2",
+			},
+		},
+		// restart stops www before the db that it requires, then starts them in dependency order
+		{
+			operation: "restart",
+			calls: []string{
+				"stop:fakeproject_www_0",
+				"stop:fakeproject_www_1",
+				"stop:fakeproject_db",
+				"start:fakeproject_db",
+				"start:fakeproject_www_0",
+				"start:fakeproject_www_1",
+			},
+		},
+		// clean removes www before the db that it requires, and the project network last, but not the shared network
+		{
+			operation: "clean",
+			calls: []string{
+				"stop:fakeproject_www_0",
+				"remove:fakeproject_www_0",
+				"stop:fakeproject_www_1",
+				"remove:fakeproject_www_1",
+				"stop:fakeproject_www_2",
+				"remove:fakeproject_www_2",
+				"stop:fakeproject_db",
+				"remove:fakeproject_db",
+				"network-remove:fakeproject_default",
+			},
+		},
+	}
+
+	for _, step := range steps {
+		calls := runFakeOperation(t, logger, project, nodes, backend, parallel, []string{"$all"}, step.operation, step.flags...)
+		if strings.Join(calls, "\n") != strings.Join(step.calls, "\n") {
+			t.Errorf("%s operation (parallel %d) made the wrong calls:\n%s\nexpected:\n%s", step.operation, parallel, strings.Join(calls, "\n"), strings.Join(step.calls, "\n"))
+		}
+	}
+	if containers := backend.Containers(); len(containers) > 0 {
+		t.Errorf("clean operation (parallel %d) left %d containers behind", parallel, len(containers))
+	}
+
+	// the shared network doesn't belong to the project, so it was created without labels, and was left behind
+	if network, ok := backend.Network("shared"); !ok {
+		t.Errorf("The shared network was removed (parallel %d)", parallel)
+	} else if len(network.Labels) > 0 {
+		t.Errorf("The shared network was labelled (parallel %d): %v", parallel, network.Labels)
+	}
+	if _, ok := backend.Network("fakeproject_default"); ok {
+		t.Errorf("The project network was not removed (parallel %d)", parallel)
+	}
+}
+
+func TestFakeUpWaitsForRunningDependencies(t *testing.T) {
+	logger := log.MakeCliLog("test", ioutil.Discard, log.VERBOSITY_MESSAGE)
+	project, nodes, backend := makeFakeProject(t, logger, fakeProjectFiles)
+
+	steps := []struct {
+		operation string
+		target    string
+		calls     []string
+	}{
+		// db isn't a target, and isn't running, so there is nothing to wait for
+		{
+			operation: "up",
+			target:    "www",
+			calls: []string{
+				"create:fakeproject_www_0",
+				"start:fakeproject_www_0",
+				"create:fakeproject_www_1",
+				"start:fakeproject_www_1",
+			},
+		},
+		{
+			operation: "up",
+			target:    "db",
+			calls: []string{
+				"create:fakeproject_db",
+				"start:fakeproject_db",
+			},
+		},
+		{
+			operation: "stop",
+			target:    "www",
+			calls: []string{
+				"stop:fakeproject_www_0",
+				"stop:fakeproject_www_1",
+			},
+		},
+		// db isn't a target, but it is running now, so www waits for it before starting
+		{
+			operation: "up",
+			target:    "www",
+			calls: []string{
+				"wait-ready:fakeproject_db",
+				"start:fakeproject_www_0",
+				"start:fakeproject_www_1",
+			},
+		},
+	}
+
+	for _, step := range steps {
+		calls := runFakeOperation(t, logger, project, nodes, backend, 1, []string{step.target}, step.operation)
+		if strings.Join(calls, "\n") != strings.Join(step.calls, "\n") {
+			t.Errorf("%s %s made the wrong calls:\n%s\nexpected:\n%s", step.target, step.operation, strings.Join(calls, "\n"), strings.Join(step.calls, "\n"))
+		}
+	}
+}
+
+func TestFakePause(t *testing.T) {
+	logger := log.MakeCliLog("test", ioutil.Discard, log.VERBOSITY_MESSAGE)
+	project, nodes, backend := makeFakeProject(t, logger, fakeProjectFiles)
+	runFakeOperation(t, logger, project, nodes, backend, 1, []string{"$all"}, "up")
+	runFakeOperation(t, logger, project, nodes, backend, 1, []string{"www:1"}, "stop")
+
+	// only running instances are paused, and instances are not health checked to find out why they aren't running
+	targets := nodes.Targets(logger, []string{"www"}, libs.TargetsOptions{})
+	backend.ResetCalls()
+	if exitCode := MakeOperation(logger, project, "pause", []string{}, targets).Run(logger); exitCode != 0 {
+		t.Errorf("pause operation exited %d", exitCode)
+	}
+
+	calls := []string{}
+	for _, call := range backend.Calls() {
+		calls = append(calls, call.String())
+	}
+	if strings.Join(calls, ",") != "pause:fakeproject_www_0" {
+		t.Errorf("pause operation made the wrong calls: %q", calls)
+	}
+
+	results := []string{}
+	for _, record := range targets.Summary().Records() {
+		results = append(results, record.Instance+":"+string(record.Result)+":"+record.Reason)
+	}
+	expected := []string{"0:succeeded:", "1:skipped:not running", "2:skipped:no container", "3:skipped:no container"}
+	if strings.Join(results, ",") != strings.Join(expected, ",") {
+		t.Errorf("pause operation recorded %q, expected %q", results, expected)
+	}
+}
+
+func TestFakeLogs(t *testing.T) {
+	logger := log.MakeCliLog("test", ioutil.Discard, log.VERBOSITY_MESSAGE)
+	project, nodes, backend := makeFakeProject(t, logger, fakeProjectFiles)
+	runFakeOperation(t, logger, project, nodes, backend, 1, []string{"$all"}, "up")
+
+	// logs are read for every www instance that has a container
+	calls := runFakeOperation(t, logger, project, nodes, backend, 1, []string{"www"}, "logs", "--tail", "5", "--since", "10m")
+	expected := []string{
+		"logs:fakeproject_www_0:--tail=5 --since=10m --follow=false",
+		"logs:fakeproject_www_1:--tail=5 --since=10m --follow=false",
+	}
+	if strings.Join(calls, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("logs operation made the wrong calls:\n%s\nexpected:\n%s", strings.Join(calls, "\n"), strings.Join(expected, "\n"))
+	}
+}
+
+func TestFakeStartFailureSummary(t *testing.T) {
+	logger := log.MakeCliLog("test", ioutil.Discard, log.VERBOSITY_MESSAGE)
+	project, nodes, _ := makeFakeProject(t, logger, fakeProjectFiles)
+
+	// nothing has been created, so starting db fails, and the run exits non-zero
+	targets := nodes.Targets(logger, []string{"db"}, libs.TargetsOptions{})
+	operations := MakeOperation(logger, project, "start", []string{}, targets)
+	if exitCode := operations.Run(logger); exitCode == 0 {
+		t.Error("start operation succeeded, when db has no container")
+	}
+
+	failed := false
+	for _, record := range targets.Summary().Records() {
+		failed = failed || (record.Node == "db" && record.Action == "start" && record.Result == libs.RESULT_FAILED)
+	}
+	if !failed {
+		t.Errorf("The db start failure was not recorded: %+v", targets.Summary().Records())
+	}
+}
+
+func TestFakeStartStopsAfterFailure(t *testing.T) {
+	logger := log.MakeCliLog("test", ioutil.Discard, log.VERBOSITY_MESSAGE)
+	project, nodes, backend := makeFakeProject(t, logger, fakeProjectFiles)
+
+	// nothing has been created, so db fails to start, and www, which requires it, is not started
+	targets := nodes.Targets(logger, []string{"$all"}, libs.TargetsOptions{})
+	operations := MakeOperation(logger, project, "start", []string{}, targets)
+	if exitCode := operations.Run(logger); exitCode == 0 {
+		t.Error("start operation succeeded, when db has no container")
+	}
+
+	calls := []string{}
+	for _, call := range backend.Calls() {
+		calls = append(calls, call.String())
+	}
+	if strings.Join(calls, ",") != "start:fakeproject_db" {
+		t.Errorf("start operation made the wrong calls: %q", calls)
+	}
+
+	notRun := false
+	for _, record := range targets.Summary().Records() {
+		if record.Node == "www" {
+			notRun = record.Result == libs.RESULT_NOT_RUN
+		}
+	}
+	if !notRun {
+		t.Error("www was not recorded as not run")
+	}
+}
+
+func TestFakeExecExitCode(t *testing.T) {
+	logger := log.MakeCliLog("test", ioutil.Discard, log.VERBOSITY_MESSAGE)
+	project, nodes, backend := makeFakeProject(t, logger, fakeProjectFiles)
+	runFakeOperation(t, logger, project, nodes, backend, 1, []string{"$all"}, "up")
+
+	backend.UpdateContainer("fakeproject_www_1", func(container *libs.Fake_Container) { container.ExitCode = 3 })
+
+	tests := []struct {
+		target   string
+		flags    []string
+		calls    []string
+		exitCode int
+	}{
+		{target: "db", flags: []string{"mysqladmin", "status"}, calls: []string{"exec:fakeproject_db:mysqladmin status"}},
+		{target: "www:1", flags: []string{"ls"}, calls: []string{"exec:fakeproject_www_1:ls"}, exitCode: 3},
+		// www has two running instances, so one has to be picked, or all of them used
+		{target: "www", flags: []string{"ls"}, calls: []string{}, exitCode: 1},
+		{target: "www", flags: []string{"--all", "ls"}, calls: []string{"exec:fakeproject_www_0:ls", "exec:fakeproject_www_1:ls"}, exitCode: 3},
+	}
+
+	for _, test := range tests {
+		backend.ResetCalls()
+		operations := MakeOperation(logger, project, "exec", test.flags, nodes.Targets(logger, []string{test.target}, libs.TargetsOptions{}))
+		exitCode := operations.Run(logger)
+
+		calls := []string{}
+		for _, call := range backend.Calls() {
+			calls = append(calls, call.String())
+		}
+		if exitCode != test.exitCode || !reflect.DeepEqual(calls, test.calls) {
+			t.Errorf("%s exec %q: exit code %d with calls %q, expected %d with calls %q", test.target, test.flags, exitCode, calls, test.exitCode, test.calls)
+		}
+	}
+}
+
+func TestFakeAttach(t *testing.T) {
+	logger := log.MakeCliLog("test", ioutil.Discard, log.VERBOSITY_MESSAGE)
+	project, nodes, backend := makeFakeProject(t, logger, fakeProjectFiles)
+	runFakeOperation(t, logger, project, nodes, backend, 1, []string{"$all"}, "up")
+
+	calls := runFakeOperation(t, logger, project, nodes, backend, 1, []string{"www:1"}, "attach", "--detach-keys", "ctrl-x,x", "--no-stdin")
+	expected := "attach:fakeproject_www_1:--detach-keys=ctrl-x,x --stdin=false --logs=true"
+	if strings.Join(calls, "\n") != expected {
+		t.Errorf("attach operation made the wrong calls:\n%s\nexpected:\n%s", strings.Join(calls, "\n"), expected)
+	}
+}
+
+func TestFakeEventsShareAStream(t *testing.T) {
+	logger := log.MakeCliLog("test", ioutil.Discard, log.VERBOSITY_MESSAGE)
+	project, nodes, backend := makeFakeProject(t, logger, fakeProjectFiles)
+
+	// both nodes use the fake backend, so they share one project event stream
+	calls := runFakeOperation(t, logger, project, nodes, backend, 1, []string{"$all"}, "events", "--until", "0s")
+	expected := "events:fakeproject:--since= --until=0s --nodes=db,www"
+	if strings.Join(calls, "\n") != expected {
+		t.Errorf("events operation made the wrong calls:\n%s\nexpected:\n%s", strings.Join(calls, "\n"), expected)
+	}
+}
+
+// A project with command nodes, one of which has no image
+var fakeRunProjectFiles = map[string]string{
+	"conf.yml": `
+Project: runproject
+`,
+	"clients.yml": `
+fake:
+  Type: fake
+  Images:
+    - "library/nginx:latest"
+`,
+	"nodes.yml": `
+tool:
+  Type: command
+  Client: fake
+  Docker:
+    Config:
+      Image: library/nginx
+broken:
+  Type: command
+  Client: fake
+  Docker:
+    Config:
+      Image: library/missing
+`,
+}
+
+func TestFakeRunExitCode(t *testing.T) {
+	logger := log.MakeCliLog("test", ioutil.Discard, log.VERBOSITY_MESSAGE)
+	project, nodes, backend := makeFakeProject(t, logger, fakeRunProjectFiles)
+
+	tests := []struct {
+		target   string
+		calls    []string // the temporary instance name is replaced with {name}
+		exitCode int
+	}{
+		{
+			target:   "tool",
+			calls:    []string{"create:{name}:ls", "start:{name}", "attach:{name}:--detach-keys= --stdin=true --logs=true", "stop:{name}", "remove:{name}"},
+			exitCode: 0,
+		},
+		// the container can't be created, so the run fails
+		{
+			target:   "broken",
+			calls:    []string{"create:{name}:ls"},
+			exitCode: 1,
+		},
+	}
+
+	for _, test := range tests {
+		backend.ResetCalls()
+		operations := MakeOperation(logger, project, "run", []string{"ls"}, nodes.Targets(logger, []string{test.target}, libs.TargetsOptions{}))
+		exitCode := operations.Run(logger)
+
+		calls := []string{}
+		for _, call := range backend.Calls() {
+			calls = append(calls, strings.Replace(call.String(), call.Target, "{name}", 1))
+			if !strings.HasPrefix(call.Target, "runproject_"+test.target+"_") {
+				t.Errorf("%s run used an unexpected container: %s", test.target, call.Target)
+			}
+		}
+		if exitCode != test.exitCode || !reflect.DeepEqual(calls, test.calls) {
+			t.Errorf("%s run: exit code %d with calls %q, expected %d with calls %q", test.target, exitCode, calls, test.exitCode, test.calls)
+		}
+	}
+	if containers := backend.Containers(); len(containers) > 0 {
+		t.Errorf("run operation left %d containers behind", len(containers))
+	}
+}
+
+func TestFakePush(t *testing.T) {
+	logger := log.MakeCliLog("test", ioutil.Discard, log.VERBOSITY_MESSAGE)
+	project, nodes, backend := makeFakeProject(t, logger, fakeProjectFiles)
+
+	// db pulls its image, so it is only pushed when pulled images are included
+	if calls := runFakeOperation(t, logger, project, nodes, backend, 1, []string{"db"}, "push"); len(calls) > 0 {
+		t.Errorf("push operation pushed a pulled image: %q", calls)
+	}
+
+	calls := runFakeOperation(t, logger, project, nodes, backend, 1, []string{"db"}, "push", "--include-pulled", "--repo", "registry.example.com/mariadb")
+	if strings.Join(calls, ",") != "push:registry.example.com/mariadb:latest" {
+		t.Errorf("push operation made the wrong calls: %q", calls)
+	}
+	// pushing to another repository tags the image with that repository first
+	if _, ok := backend.Image("registry.example.com/mariadb:latest"); !ok {
+		t.Error("push operation did not tag the image for the repository")
+	}
+
+	// pull nodes can't push, but are pushed when pulled images are included
+	project, nodes, backend = makeFakeProject(t, logger, map[string]string{
+		"conf.yml": "Project: pullproject\n",
+		"clients.yml": `
+fake:
+  Type: fake
+  Images:
+    - "library/redis:latest"
+`,
+		"nodes.yml": `
+cache:
+  Type: pull
+  Client: fake
+  Docker:
+    Config:
+      Image: library/redis
+`,
+	})
+	if calls := runFakeOperation(t, logger, project, nodes, backend, 1, []string{"cache"}, "push"); len(calls) > 0 {
+		t.Errorf("push operation pushed a pull node: %q", calls)
+	}
+	calls = runFakeOperation(t, logger, project, nodes, backend, 1, []string{"cache"}, "push", "--include-pulled")
+	if strings.Join(calls, ",") != "push:library/redis:latest" {
+		t.Errorf("push operation made the wrong pull node calls: %q", calls)
+	}
+}
+
+// A project with a named volume node, and a db node that binds the volume
+var fakeVolumeProjectFiles = map[string]string{
+	"conf.yml": `
+Project: volumeproject
+`,
+	"clients.yml": `
+fake:
+  Type: fake
+  Images:
+    - "library/mariadb:latest"
+`,
+	"nodes.yml": `
+data:
+  Type: volume
+  Client: fake
+  Docker:
+    Volume:
+      Driver: local
+db:
+  Type: service
+  Client: fake
+  Docker:
+    Host:
+      Binds:
+        - "data:/var/lib/mysql"
+    Config:
+      Image: library/mariadb
+`,
+}
+
+func TestFakeNamedVolume(t *testing.T) {
+	logger := log.MakeCliLog("test", ioutil.Discard, log.VERBOSITY_MESSAGE)
+	project, nodes, backend := makeFakeProject(t, logger, fakeVolumeProjectFiles)
+
+	calls := runFakeOperation(t, logger, project, nodes, backend, 1, []string{"$all"}, "up")
+	expected := []string{
+		"volume-create:volumeproject_data",
+		"create:volumeproject_db",
+		"start:volumeproject_db",
+	}
+	if strings.Join(calls, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("up operation made the wrong calls:\n%s\nexpected:\n%s", strings.Join(calls, "\n"), strings.Join(expected, "\n"))
+	}
+
+	// the volume node name in the bind is mapped to the docker volume
+	if users := backend.VolumeContainers("volumeproject_data"); len(users) != 1 || users[0] != "volumeproject_db" {
+		t.Errorf("The db container does not bind the named volume: %q", users)
+	}
+	if volume, ok := backend.Volume("volumeproject_data"); !ok {
+		t.Fatal("The named volume was not created")
+	} else if volume.Labels[libs.COACH_LABEL_NODE] != "data" {
+		t.Errorf("The named volume was not labelled for the data node: %v", volume.Labels)
+	}
+
+	// clean only removes the volume when wiping
+	runFakeOperation(t, logger, project, nodes, backend, 1, []string{"$all"}, "clean")
+	if _, ok := backend.Volume("volumeproject_data"); !ok {
+		t.Error("clean operation removed the named volume")
+	}
+	runFakeOperation(t, logger, project, nodes, backend, 1, []string{"$all"}, "clean", "--wipe")
+	if _, ok := backend.Volume("volumeproject_data"); ok {
+		t.Error("clean --wipe operation did not remove the named volume")
+	}
+
+	// the volume is already gone, so wiping again skips it, rather than failing
+	runFakeOperation(t, logger, project, nodes, backend, 1, []string{"$all"}, "clean", "--wipe")
+}
+
+func TestFakeRemoveSkipsMissingContainers(t *testing.T) {
+	logger := log.MakeCliLog("test", ioutil.Discard, log.VERBOSITY_MESSAGE)
+	project, nodes, backend := makeFakeProject(t, logger, fakeProjectFiles)
+	runFakeOperation(t, logger, project, nodes, backend, 1, []string{"db"}, "up")
+	runFakeOperation(t, logger, project, nodes, backend, 1, []string{"db"}, "stop")
+
+	// none of the www instances has a container, so removing them is skipped, and the run succeeds
+	targets := nodes.Targets(logger, []string{"db", "www"}, libs.TargetsOptions{})
+	operations := MakeOperation(logger, project, "remove", []string{}, targets)
+	if exitCode := operations.Run(logger); exitCode != 0 {
+		t.Errorf("remove operation exited %d, when the only failures were missing containers", exitCode)
+	}
+
+	results := []string{}
+	for _, record := range targets.Summary().Records() {
+		results = append(results, record.Node+":"+record.Instance+":"+record.Action+":"+string(record.Result)+":"+record.Reason)
+	}
+	expected := []string{
+		"www:0:remove:skipped:container-not-found",
+		"www:1:remove:skipped:container-not-found",
+		"www:2:remove:skipped:container-not-found",
+		"www:3:remove:skipped:container-not-found",
+		"db:single:remove:succeeded:",
+	}
+	if strings.Join(results, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("remove operation recorded the wrong results:\n%s\nexpected:\n%s", strings.Join(results, "\n"), strings.Join(expected, "\n"))
+	}
+}
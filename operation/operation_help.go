@@ -1,6 +1,7 @@
 package operation
 
 import (
+	"errors"
 	"strings"
 
 	"github.com/james-nesbitt/coach/conf"
@@ -47,6 +48,7 @@ TOPICS:
 	cli: get help on how to use the cli
 
 		cli:targets (targets) : get help about how targets work
+		cli:parallel : get help about processing nodes in parallel
 
 	settings : get help about coach configuration
 
@@ -65,8 +67,10 @@ Target Independent: these operations don't pay attention to targets
 Target Dependent: these operations will only act on passed targets	
 
   info: get information about project nodes
+  inspect: write the resolved container configuration for node instances
 
 	pull: pull any node images
+	push: push any built or committed node images to a registry
 	build: build any node build images
 	destroy: destroy any built node images
 
@@ -78,6 +82,13 @@ Target Dependent: these operations will only act on passed targets
 	unpause: pause all processes inside node instances
 	remove: remove node instances (containers)
 
+	attach: attach the terminal to a running node instance
+	logs: show the container logs for node instances
+	exec: run a command inside a running node instance
+	cp: copy files between the host and a node instance container
+	stats: show the CPU, memory, network and block IO usage of running node instances
+	events: show the docker events for node containers, images and volumes
+
 	scale: start (or stop) additional individual node instances to scale the app
 
 	up: a shortcut operation for: build, pull, create, start
@@ -86,7 +97,7 @@ Target Dependent: these operations will only act on passed targets
 The first topic passed in is assumed to be a help operation.
 `)
 }
-func (operation *HelpOperation) Run(logger log.Log) bool {
+func (operation *HelpOperation) Run(logger log.Log) error {
 	logger.Info("Running operation: info")
 
 	helpTopicName := "help"
@@ -104,7 +115,7 @@ func (operation *HelpOperation) Run(logger log.Log) bool {
 	if topic, ok := Helper.Topic(helpTopicName, helpTopicFlags); ok {
 
 		operation.log.Message(topic)
-		return true
+		return nil
 
 	} else {
 
@@ -114,7 +125,7 @@ func (operation *HelpOperation) Run(logger log.Log) bool {
 					for _, helpOperation := range helpOperations.operationsList {
 						helpOperation.Help(append([]string{helpTopicName}, helpTopicFlags...))
 					}
-					return true
+					return nil
 				}
 			}
 		}
@@ -122,7 +133,7 @@ func (operation *HelpOperation) Run(logger log.Log) bool {
 	}
 
 	operation.log.Warning("Unknown help topic")
-	return false
+	return errors.New("unknown help topic: " + helpTopicName)
 }
 
 // get a Help object, loaded with the default Help sources
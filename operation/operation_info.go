@@ -1,6 +1,8 @@
 package operation
 
 import (
+	"strings"
+
 	"github.com/james-nesbitt/coach/libs"
 	"github.com/james-nesbitt/coach/log"
 )
@@ -8,27 +10,59 @@ import (
 type InfoOperation struct {
 	log     log.Log
 	targets *libs.Targets
+
+	output string
+	format string // a go template for each target instance
 }
 
 func (operation *InfoOperation) Id() string {
 	return "info"
 }
 func (operation *InfoOperation) Flags(flags []string) bool {
+	operation.format = ""
+
+	for index := 0; index < len(flags); index++ {
+		flag := flags[index]
+
+		switch {
+		case flag == "--format":
+			if index+1 < len(flags) {
+				index++
+				operation.format = flags[index]
+			}
+		case strings.HasPrefix(flag, "--format="):
+			operation.format = strings.TrimPrefix(flag, "--format=")
+		}
+	}
 	return true
 }
+func (operation *InfoOperation) SetOutput(format string) {
+	operation.output = format
+}
 func (operation *InfoOperation) Help(flags []string) {
 	operation.log.Message(`Operation: INFO
 
 Coach will attempt to provide project information by investigating target images and containers.
 
 SYNTAX:
-	$/> coach {targets} info
+	$/> coach {targets} info [--format {template}]
 
 	{targets} what target nodes the operation should process ($/> coach help targets)
+	--format {template} : write a line for each target instance using a go template ($/> coach help cli:format)
+
+NOTES:
+	- Use the global --output json or --output yaml flag to write a structured document, with the node type, images, and each instance container, its state, ports and default/filtered flags.
 
 `)
 }
-func (operation *InfoOperation) Run(logger log.Log) bool {
+func (operation *InfoOperation) Run(logger log.Log) error {
+	if operation.format != "" {
+		return writeTargetsFormat(logger, operation.targets, operation.format)
+	}
+	if operation.output != "" && operation.output != OUTPUT_TEXT {
+		return operation.runOutput(logger)
+	}
+
 	logger.Message("RUNNING INFO OPERATION")
 
 	logger.Debug(log.VERBOSITY_DEBUG, "Run:Targets", operation.targets.TargetOrder())
@@ -58,5 +92,21 @@ func (operation *InfoOperation) Run(logger log.Log) bool {
 		}
 	}
 
-	return true
+	return nil
+}
+
+// Write the info as a structured document
+func (operation *InfoOperation) runOutput(logger log.Log) error {
+	reports := []libs.NodeReport{}
+	for _, targetID := range operation.targets.TargetOrder() {
+		if target, targetExists := operation.targets.Target(targetID); targetExists {
+			reports = append(reports, targetReport(targetID, target))
+		}
+	}
+
+	if err := writeOutput(operation.output, reports); err != nil {
+		logger.Error("Failed to write the info output => " + err.Error())
+		return err
+	}
+	return nil
 }
@@ -1,6 +1,7 @@
 package operation
 
 import (
+	"errors"
 	"strings"
 
 	"os"
@@ -156,7 +157,7 @@ Then you can create a new project using :
 `)
 }
 
-func (operation *InitOperation) Run(logger log.Log) bool {
+func (operation *InitOperation) Run(logger log.Log) error {
 	logger.Info("running init operation")
 
 	var err error
@@ -170,7 +171,7 @@ func (operation *InitOperation) Run(logger log.Log) bool {
 			targetPath, err = os.Getwd()
 			if err != nil {
 				logger.Error("No path suggested for new project init")
-				return false
+				return err
 			}
 		}
 	}
@@ -178,7 +179,7 @@ func (operation *InitOperation) Run(logger log.Log) bool {
 	_, err = os.Stat(targetPath)
 	if err != nil {
 		logger.Error("Invalid path suggested for new project init : [" + targetPath + "] => " + err.Error())
-		return false
+		return err
 	}
 
 	coachPath, _ = operation.conf.Paths.Path("coach-root")
@@ -188,7 +189,7 @@ func (operation *InitOperation) Run(logger log.Log) bool {
 	_, err = os.Stat(coachPath)
 	if !operation.force && err == nil {
 		logger.Error("cannot create new project folder, as one already exists")
-		return false
+		return errors.New("cannot create new project folder, as one already exists")
 	}
 
 	logger = logger.MakeChild(strings.ToUpper(operation.handler))
@@ -217,10 +218,10 @@ func (operation *InitOperation) Run(logger log.Log) bool {
 	if ok {
 		logger.Info("Running init tasks")
 		tasks.RunTasks(logger)
-		return true
+		return nil
 	} else {
 		logger.Warning("No init tasks were defined.")
-		return false
+		return errors.New("no init tasks were defined")
 	}
 
 }
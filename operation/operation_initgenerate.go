@@ -59,7 +59,7 @@ func (operation *InitGenerateOperation) Flags(flags []string) bool {
 func (operation *InitGenerateOperation) Help(topics []string) {
 	operation.log.Message(`Operation: InitGenerate`)
 }
-func (operation *InitGenerateOperation) Run(logger log.Log) bool {
+func (operation *InitGenerateOperation) Run(logger log.Log) error {
 	logger.Info("running init operation:" + operation.output)
 
 	var writer io.Writer
@@ -84,5 +84,5 @@ func (operation *InitGenerateOperation) Run(logger log.Log) bool {
 
 	initialize.Init_Generate(logger.MakeChild("init-generate"), operation.handler, operation.root, operation.skip, operation.sizeLimit, writer)
 
-	return true
+	return nil
 }
@@ -0,0 +1,91 @@
+package operation
+
+import (
+	"github.com/james-nesbitt/coach/libs"
+	"github.com/james-nesbitt/coach/log"
+)
+
+type InspectOperation struct {
+	log     log.Log
+	targets *libs.Targets
+
+	output string
+}
+
+func (operation *InspectOperation) Id() string {
+	return "inspect"
+}
+func (operation *InspectOperation) Flags(flags []string) bool {
+	return true
+}
+func (operation *InspectOperation) SetOutput(format string) {
+	operation.output = format
+}
+func (operation *InspectOperation) Help(topics []string) {
+	operation.log.Message(`Operation: INSPECT
+
+Coach will write the full resolved container configuration for target node instances.
+
+SYNTAX:
+	$/> coach {targets} inspect
+
+	{targets} what target node instances the operation should process ($/> coach help targets)
+
+ACCESS:
+	- this operation processes only nodes with the "inspect" access.  This excludes build and pull nodes, and named volume nodes.
+
+NOTES:
+	- The configuration is the one that coach uses to create the instance container, after tokens, labels and networks are applied, so it can be checked before the container is created.
+	- If the instance has a container, then the docker inspect document for the container is included.
+	- The output is JSON, unless the global --output yaml flag is used.
+`)
+}
+func (operation *InspectOperation) Run(logger log.Log) error {
+	logger.Debug(log.VERBOSITY_DEBUG, "Run:Targets", operation.targets.TargetOrder())
+
+	documents := []libs.InstanceInspect{}
+	errs := libs.Errors{}
+
+	for _, targetID := range operation.targets.TargetOrder() {
+		target, targetExists := operation.targets.Target(targetID)
+		if !targetExists {
+			// this is strange
+			logger.Warning("Internal target error, was told to use a target that doesn't exist")
+			continue
+		}
+
+		node, hasNode := target.Node()
+		instances, hasInstances := target.Instances()
+		nodeLogger := logger.MakeChild(targetID)
+
+		if !hasNode {
+			nodeLogger.Warning("No node [" + node.MachineName() + "]")
+		} else if !node.Can("inspect") {
+			nodeLogger.Info("Node doesn't have containers to inspect [" + node.MachineName() + ":" + node.Type() + "]")
+		} else if !hasInstances {
+			nodeLogger.Info("No valid instances specified in target list [" + node.MachineName() + "]")
+		} else {
+			if !instances.IsFiltered() {
+				instances.UseAll()
+			}
+
+			for _, id := range instances.InstancesOrder() {
+				instance, _ := instances.Instance(id)
+
+				document, err := instance.Client().Inspect(nodeLogger)
+				errs.Add(err)
+				documents = append(documents, document)
+			}
+		}
+	}
+
+	format := OUTPUT_JSON
+	if operation.output == OUTPUT_YAML {
+		format = OUTPUT_YAML
+	}
+	if err := writeOutput(format, documents); err != nil {
+		logger.Error("Failed to write the inspect output => " + err.Error())
+		errs.Add(err)
+	}
+	return errs.Err()
+}
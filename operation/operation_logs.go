@@ -0,0 +1,235 @@
+package operation
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/james-nesbitt/coach/libs"
+	"github.com/james-nesbitt/coach/log"
+)
+
+// colours used for the instance prefixes, in the same order as docker-compose
+var logsPrefixColours = []string{"36", "33", "32", "35", "34", "31", "96", "93", "92", "95", "94", "91"}
+
+type LogsOperation struct {
+	log     log.Log
+	targets *libs.Targets
+
+	options libs.LogsOptions
+	noColor bool
+}
+
+func (operation *LogsOperation) Id() string {
+	return "logs"
+}
+func (operation *LogsOperation) Flags(flags []string) bool {
+	operation.options = libs.LogsOptions{}
+	operation.noColor = false
+
+	for index := 0; index < len(flags); index++ {
+		flag := flags[index]
+
+		switch flag {
+		case "-f":
+			fallthrough
+		case "--follow":
+			operation.options.Follow = true
+		case "-t":
+			fallthrough
+		case "--timestamps":
+			operation.options.Timestamps = true
+		case "--tail":
+			if index+1 < len(flags) {
+				index++
+				operation.options.Tail = flags[index]
+			}
+		case "--since":
+			if index+1 < len(flags) {
+				index++
+				operation.options.Since = flags[index]
+			}
+		case "--no-color":
+			operation.noColor = true
+		}
+	}
+	return true
+}
+func (operation *LogsOperation) Help(topics []string) {
+	operation.log.Message(`Operation: LOGS
+
+Coach will output the container logs for target node instances.
+
+SYNTAX:
+	$/> coach {targets} logs [--follow] [--tail {lines}] [--since {time}] [--timestamps] [--no-color]
+
+	{targets} what target node instances the operation should process ($/> coach help targets)
+	--follow : keep streaming new log output, until interrupted
+	--tail {lines} : only show this many lines from the end of each instance log (default: all)
+	--since {time} : only show logs since a unix timestamp, an RFC3339 time, or a relative duration like 10m
+	--timestamps : show a timestamp on each log line
+	--no-color : don't colour the instance prefixes
+
+ACCESS:
+	- this operation processes only nodes with the "logs" access.  This excludes build and pull nodes, and named volume nodes.
+
+NOTES:
+	- If the targets cover more than one instance, then each line is prefixed with the node:instance that it came from.
+	- When following logs, the logs for all of the instances are streamed at the same time.
+`)
+}
+func (operation *LogsOperation) Run(logger log.Log) error {
+	logger.Info("Running operation: logs")
+	logger.Debug(log.VERBOSITY_DEBUG, "Run:Targets", operation.targets.TargetOrder())
+
+	names := []string{}
+	clients := []libs.InstanceClient{}
+
+	for _, targetID := range operation.targets.TargetOrder() {
+		target, targetExists := operation.targets.Target(targetID)
+		if !targetExists {
+			// this is strange
+			logger.Warning("Internal target error, was told to use a target that doesn't exist")
+			continue
+		}
+
+		node, hasNode := target.Node()
+		instances, hasInstances := target.Instances()
+		nodeLogger := logger.MakeChild(targetID)
+
+		if !hasNode {
+			nodeLogger.Warning("No node [" + node.MachineName() + "]")
+		} else if !node.Can("logs") {
+			nodeLogger.Info("Node doesn't have logs [" + node.MachineName() + ":" + node.Type() + "]")
+		} else if !hasInstances {
+			nodeLogger.Info("No valid instances specified in target list [" + node.MachineName() + "]")
+		} else {
+			if !instances.IsFiltered() {
+				nodeLogger.Info("Switching to using all instances")
+				instances.UseAll()
+			}
+
+			for _, id := range instances.InstancesOrder() {
+				instance, _ := instances.Instance(id)
+				instanceClient := instance.Client()
+
+				if !instanceClient.HasContainer() {
+					nodeLogger.Info("Instance has no container, so it has no logs [" + id + "]")
+					continue
+				}
+
+				name := node.Id()
+				if id != libs.INSTANCE_SINGLE_ID {
+					name += ":" + id
+				}
+				names = append(names, name)
+				clients = append(clients, instanceClient)
+			}
+		}
+	}
+
+	if len(clients) == 0 {
+		logger.Warning("No instance containers found to show logs for")
+		return libs.NewClientError(libs.ERROR_CONTAINER_NOT_FOUND, "logs", strings.Join(operation.targets.TargetOrder(), ","), nil)
+	}
+
+	// with more than one instance, each line is prefixed with its instance name
+	output := &logsOutput{writer: os.Stdout}
+	writers := []io.Writer{}
+	if len(clients) == 1 {
+		writers = append(writers, output)
+	} else {
+		width := 0
+		for _, name := range names {
+			if len(name) > width {
+				width = len(name)
+			}
+		}
+		colour := !operation.noColor && isTerminal(os.Stdout)
+
+		for index, name := range names {
+			prefix := name + strings.Repeat(" ", width-len(name)) + " | "
+			if colour {
+				prefix = "\033[" + logsPrefixColours[index%len(logsPrefixColours)] + "m" + prefix + "\033[0m"
+			}
+			writers = append(writers, &logsPrefixWriter{prefix: prefix, output: output})
+		}
+	}
+
+	errs := libs.Errors{}
+	if operation.options.Follow {
+		// followed logs never end, so all of the instances are streamed at the same time
+		var wait sync.WaitGroup
+		var lock sync.Mutex
+		for index, client := range clients {
+			wait.Add(1)
+			go func(client libs.InstanceClient, writer io.Writer, instanceLogger log.Log) {
+				defer wait.Done()
+				if err := client.Logs(instanceLogger, writer, operation.options); err != nil {
+					lock.Lock()
+					errs.Add(err)
+					lock.Unlock()
+				}
+				logsFlush(writer)
+			}(client, writers[index], logger.MakeChild(names[index]))
+		}
+		wait.Wait()
+	} else {
+		for index, client := range clients {
+			errs.Add(client.Logs(logger.MakeChild(names[index]), writers[index], operation.options))
+			logsFlush(writers[index])
+		}
+	}
+
+	return errs.Err()
+}
+
+// Output any partial line held by a prefix writer
+func logsFlush(writer io.Writer) {
+	if prefixWriter, ok := writer.(*logsPrefixWriter); ok {
+		prefixWriter.Flush()
+	}
+}
+
+// An output that can be shared by concurrent log streams, which writes whole lines at a time
+type logsOutput struct {
+	lock   sync.Mutex
+	writer io.Writer
+}
+
+func (output *logsOutput) Write(message []byte) (int, error) {
+	output.lock.Lock()
+	defer output.lock.Unlock()
+	return output.writer.Write(message)
+}
+
+// A writer that prefixes each line written to it, and holds partial lines until they are completed
+type logsPrefixWriter struct {
+	prefix  string
+	output  io.Writer
+	partial []byte
+}
+
+func (writer *logsPrefixWriter) Write(message []byte) (int, error) {
+	writer.partial = append(writer.partial, message...)
+	for {
+		end := bytes.IndexByte(writer.partial, '\n')
+		if end < 0 {
+			break
+		}
+		line := append([]byte(writer.prefix), writer.partial[:end+1]...)
+		if _, err := writer.output.Write(line); err != nil {
+			return 0, err
+		}
+		writer.partial = writer.partial[end+1:]
+	}
+	return len(message), nil
+}
+func (writer *logsPrefixWriter) Flush() {
+	if len(writer.partial) > 0 {
+		writer.output.Write(append([]byte(writer.prefix), append(writer.partial, '\n')...))
+		writer.partial = nil
+	}
+}
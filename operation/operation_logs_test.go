@@ -0,0 +1,34 @@
+package operation
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestLogsPrefixWriter(t *testing.T) {
+	tests := []struct {
+		name   string
+		writes []string
+		output string
+	}{
+		{name: "whole lines", writes: []string{"one\ntwo\n"}, output: "www | one\nwww | two\n"},
+		{name: "split line", writes: []string{"o", "ne\ntw", "o\n"}, output: "www | one\nwww | two\n"},
+		{name: "unfinished line is flushed", writes: []string{"one\ntwo"}, output: "www | one\nwww | two\n"},
+		{name: "empty line", writes: []string{"\n"}, output: "www | \n"},
+	}
+
+	for _, test := range tests {
+		output := &bytes.Buffer{}
+		writer := &logsPrefixWriter{prefix: "www | ", output: output}
+		for _, write := range test.writes {
+			if written, err := writer.Write([]byte(write)); err != nil || written != len(write) {
+				t.Errorf("%s: write returned %d, %v", test.name, written, err)
+			}
+		}
+		logsFlush(writer)
+
+		if output.String() != test.output {
+			t.Errorf("%s: output %q, expected %q", test.name, output.String(), test.output)
+		}
+	}
+}
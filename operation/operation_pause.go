@@ -32,23 +32,15 @@ ACCESS:
 
 `)
 }
-func (operation *PauseOperation) Run(logger log.Log) bool {
+func (operation *PauseOperation) Run(logger log.Log) error {
 	logger.Info("Running operation: pause")
-	logger.Debug(log.VERBOSITY_DEBUG, "Run:Targets", operation.targets.TargetOrder())
-
-	for _, targetID := range operation.targets.TargetOrder() {
-		target, targetExists := operation.targets.Target(targetID)
-		if !targetExists {
-			// this is strange
-			logger.Warning("Internal target error, was told to use a target that doesn't exist")
-			continue
-		}
+
+	return operation.targets.Process(logger, func(nodeLogger log.Log, target *libs.Target) error {
+		errs := libs.Errors{}
 
 		node, hasNode := target.Node()
 		instances, hasInstances := target.Instances()
 
-		nodeLogger := logger.MakeChild(targetID)
-
 		if !hasNode {
 			nodeLogger.Warning("No node [" + node.MachineName() + "]")
 		} else if !node.Can("pause") {
@@ -66,18 +58,18 @@ func (operation *PauseOperation) Run(logger log.Log) bool {
 			for _, id := range instances.InstancesOrder() {
 				instance, _ := instances.Instance(id)
 
-				if !instance.IsRunning() {
-					if !instance.IsReady() {
-						nodeLogger.Info("Instance will not be paused as it is not ready :" + id)
-					} else {
-						nodeLogger.Info("Instance will not be paused as it is not running :" + id)
-					}
+				if !instance.Client().HasContainer() {
+					nodeLogger.Info("Instance will not be paused as it has no container :" + id)
+					target.Skip(id, "pause", "no container")
+				} else if !instance.IsRunning() {
+					nodeLogger.Info("Instance will not be paused as it is not running :" + id)
+					target.Skip(id, "pause", "not running")
 				} else {
-					instance.Client().Pause(logger)
+					errs.Add(target.Record(id, "pause", instance.Client().Pause(nodeLogger)))
 				}
 			}
 		}
-	}
 
-	return true
+		return errs.Err()
+	})
 }
@@ -48,20 +48,13 @@ NOTES:
 	- Nodes that have build settings will not attempt to pull any images, as it is expected that those images will be created using the build operation.
 `)
 }
-func (operation *PullOperation) Run(logger log.Log) bool {
+func (operation *PullOperation) Run(logger log.Log) error {
 	logger.Info("Running operation: pull")
 
-	logger.Debug(log.VERBOSITY_DEBUG, "Run:Targets", operation.targets.TargetOrder())
-	for _, targetID := range operation.targets.TargetOrder() {
-		target, targetExists := operation.targets.Target(targetID)
-		if !targetExists {
-			// this is strange
-			logger.Warning("Internal target error, was told to use a target that doesn't exist")
-			continue
-		}
+	return operation.targets.Process(logger, func(nodeLogger log.Log, target *libs.Target) error {
+		errs := libs.Errors{}
 
 		node, hasNode := target.Node()
-		nodeLogger := logger.MakeChild(targetID)
 
 		if !hasNode {
 			nodeLogger.Info("No node [" + node.MachineName() + "]")
@@ -69,9 +62,9 @@ func (operation *PullOperation) Run(logger log.Log) bool {
 			nodeLogger.Info("Node doesn't pull [" + node.MachineName() + "]")
 		} else {
 			nodeLogger.Message("Pulling node")
-			node.Client().Pull(nodeLogger, operation.force)
+			errs.Add(target.Record("", "pull", node.Client().Pull(nodeLogger, operation.force)))
 		}
-	}
 
-	return true
+		return errs.Err()
+	})
 }
@@ -0,0 +1,96 @@
+package operation
+
+import (
+	"strings"
+
+	"github.com/james-nesbitt/coach/libs"
+	"github.com/james-nesbitt/coach/log"
+)
+
+type PushOperation struct {
+	log     log.Log
+	targets *libs.Targets
+
+	includePulled bool
+
+	tag        string
+	repository string
+}
+
+func (operation *PushOperation) Id() string {
+	return "push"
+}
+func (operation *PushOperation) Flags(flags []string) bool {
+	operation.includePulled = false
+
+	for index := 0; index < len(flags); index++ {
+		flag := flags[index]
+
+		switch flag {
+		case "-t":
+			fallthrough
+		case "--tag":
+			if index+1 < len(flags) && !strings.HasPrefix(flags[index+1], "-") {
+				index++
+				operation.tag = flags[index]
+			}
+		case "-r":
+			fallthrough
+		case "--repo":
+			if index+1 < len(flags) && !strings.HasPrefix(flags[index+1], "-") {
+				index++
+				operation.repository = flags[index]
+			}
+		case "-a":
+			fallthrough
+		case "--include-pulled":
+			operation.includePulled = true
+		}
+	}
+	return true
+}
+func (operation *PushOperation) Help(topics []string) {
+	operation.log.Message(`Operation: PUSH
+
+Coach will attempt to push node images to a registry, for nodes that build or commit their images.
+
+SYNTAX:
+	$/> coach {targets} push [--tag {tag}] [--repo {repo}] [--include-pulled]
+
+	{targets} what target nodes the operation should process ($/> coach help targets)
+	--tag "{tag}" : what image tag to push (default: the node image tag)
+	--repo "{repo}" : what repository to push to (default: the node Repo: setting, or the node image name)
+	--include-pulled : also push images for nodes that pull their image
+
+ACCESS:
+	- this operation processes only nodes with the "push" access, which are build nodes.  Pull nodes are also processed if --include-pulled is used.
+
+NOTES:
+	- If a repository is given that doesn't match the node image, then the image is tagged with the repository before it is pushed.
+	- Registry credentials are taken from the node Auth: settings, or from the docker config file (~/.docker/config.json)
+	- Nodes that pull their image are skipped by default, as it is expected that those images are published elsewhere.
+`)
+}
+func (operation *PushOperation) Run(logger log.Log) error {
+	logger.Info("Running operation: push")
+
+	return operation.targets.Process(logger, func(nodeLogger log.Log, target *libs.Target) error {
+		errs := libs.Errors{}
+
+		node, hasNode := target.Node()
+
+		if !hasNode {
+			nodeLogger.Info("No node [" + node.MachineName() + "]")
+		} else if node.Can("pull") && !operation.includePulled {
+			nodeLogger.Info("Node pulls its image, so it will not be pushed [" + node.MachineName() + "].  You can include pulled images if you want to push this image.")
+			target.Skip("", "push", "the node pulls its image")
+		} else if !node.Can("push") && !node.Can("pull") {
+			nodeLogger.Info("Node doesn't push [" + node.MachineName() + "]")
+		} else {
+			nodeLogger.Message("Pushing node")
+			errs.Add(target.Record("", "push", node.Client().Push(nodeLogger, operation.repository, operation.tag)))
+		}
+
+		return errs.Err()
+	})
+}
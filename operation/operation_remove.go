@@ -39,25 +39,20 @@ SYNTAX:
 
 ACCESS:
 	- only nodes with the "create" access are processed.  This excludes build and command nodes
+
+NOTES:
+	- instances that have no container are skipped, not failed
 `)
 }
-func (operation *RemoveOperation) Run(logger log.Log) bool {
+func (operation *RemoveOperation) Run(logger log.Log) error {
 	logger.Info("Running operation: remove")
-	logger.Debug(log.VERBOSITY_DEBUG, "Run:Targets", operation.targets.TargetOrder())
-
-	for _, targetID := range operation.targets.TargetOrder() {
-		target, targetExists := operation.targets.Target(targetID)
-		if !targetExists {
-			// this is strange
-			logger.Warning("Internal target error, was told to use a target that doesn't exist")
-			continue
-		}
+
+	return operation.targets.ProcessReverse(logger, func(nodeLogger log.Log, target *libs.Target) error {
+		errs := libs.Errors{}
 
 		node, hasNode := target.Node()
 		instances, hasInstances := target.Instances()
 
-		nodeLogger := logger.MakeChild(targetID)
-
 		if !hasNode {
 			nodeLogger.Warning("No node [" + node.MachineName() + "]")
 		} else if !node.Can("remove") {
@@ -74,10 +69,10 @@ func (operation *RemoveOperation) Run(logger log.Log) bool {
 
 			for _, id := range instances.InstancesOrder() {
 				instance, _ := instances.Instance(id)
-				instance.Client().Remove(logger, operation.force)
+				errs.Add(target.Record(id, "remove", libs.SkipNotFound(instance.Client().Remove(nodeLogger, operation.force))))
 			}
 		}
-	}
 
-	return true
+		return errs.Err()
+	})
 }
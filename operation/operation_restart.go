@@ -48,53 +48,74 @@ SYNTAX:
 
 ACCESS:
 	- This operation processed only nodes with the "Restart" access.  This excludes build, volume and command containers.
+
+NOTES:
+	- All of the target nodes are stopped first, in reverse dependency order, and then started in dependency order.
 `)
 }
-func (operation *RestartOperation) Run(logger log.Log) bool {
+func (operation *RestartOperation) Run(logger log.Log) error {
 	logger.Info("Running operation: Restart")
-	logger.Debug(log.VERBOSITY_DEBUG, "Run:Targets", operation.targets.TargetOrder())
-
-	for _, targetID := range operation.targets.TargetOrder() {
-		target, targetExists := operation.targets.Target(targetID)
-		if !targetExists {
-			// this is strange
-			logger.Warning("Internal target error, was told to use a target that doesn't exist")
-			continue
-		}
-
-		node, hasNode := target.Node()
-		instances, hasInstances := target.Instances()
 
-		nodeLogger := logger.MakeChild(targetID)
+	// stop in reverse dependency order, so that nodes are stopped before the nodes that they depend on
+	errs := libs.Errors{}
+	errs.Add(operation.targets.ProcessReverse(logger, func(nodeLogger log.Log, target *libs.Target) error {
+		nodeErrs := libs.Errors{}
 
-		if !hasNode {
-			nodeLogger.Warning("No node [" + node.MachineName() + "]")
-		} else if !node.Can("start") {
-			nodeLogger.Info("Node doesn't Restart [" + node.MachineName() + ":" + node.Type() + "]")
-		} else if !hasInstances {
-			nodeLogger.Info("No valid instances specified in target list [" + node.MachineName() + "]")
+		instances, reason := restartInstances(target)
+		if reason != "" {
+			nodeLogger.Info(reason)
 		} else {
-			nodeLogger.Message("Restarting instance containers")
+			nodeLogger.Message("Stopping instance containers")
 
 			for _, id := range instances.InstancesOrder() {
 				instance, _ := instances.Instance(id)
 				instanceClient := instance.Client()
 
-				// Stop
 				if instanceClient.IsRunning() {
 					nodeLogger.Info("Stopping instance: " + id)
-					instanceClient.Stop(nodeLogger, operation.force, operation.timeout)
+					nodeErrs.Add(target.Record(id, "stop", instanceClient.Stop(nodeLogger, operation.force, operation.timeout)))
 				}
+			}
+		}
+
+		return nodeErrs.Err()
+	}))
+
+	// start in dependency order, so that nodes are started after the nodes that they depend on
+	errs.Add(operation.targets.Process(logger, func(nodeLogger log.Log, target *libs.Target) error {
+		nodeErrs := libs.Errors{}
+
+		if instances, reason := restartInstances(target); reason == "" {
+			nodeLogger.Message("Starting instance containers")
+
+			for _, id := range instances.InstancesOrder() {
+				instance, _ := instances.Instance(id)
+				instanceClient := instance.Client()
 
-				// Start
 				if !instanceClient.IsRunning() {
 					nodeLogger.Info("Starting instance: " + id)
-					instanceClient.Start(nodeLogger, operation.force)
+					nodeErrs.Add(target.Record(id, "start", instanceClient.Start(nodeLogger, operation.force)))
 				}
-
 			}
 		}
-	}
 
-	return true
+		return nodeErrs.Err()
+	}))
+
+	return errs.Err()
+}
+
+// The instances of a target that can be restarted, or the reason that the target can't be restarted
+func restartInstances(target *libs.Target) (libs.FilterableInstances, string) {
+	node, hasNode := target.Node()
+	instances, hasInstances := target.Instances()
+
+	if !hasNode {
+		return nil, "No node [" + target.Name() + "]"
+	} else if !node.Can("start") {
+		return nil, "Node doesn't Restart [" + node.MachineName() + ":" + node.Type() + "]"
+	} else if !hasInstances {
+		return nil, "No valid instances specified in target list [" + node.MachineName() + "]"
+	}
+	return instances, ""
 }
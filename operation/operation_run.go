@@ -1,6 +1,10 @@
 package operation
 
 import (
+	"errors"
+	"os"
+	"strconv"
+
 	"github.com/james-nesbitt/coach/libs"
 	"github.com/james-nesbitt/coach/log"
 )
@@ -13,6 +17,7 @@ type RunOperation struct {
 	instance string
 
 	persistant bool
+	exitCode   int
 }
 
 func (operation *RunOperation) Id() string {
@@ -40,17 +45,28 @@ SYNTAX:
 	{target} what target node instance the operation should process ($/> coach help targets)
 	{cmd} a list of flags to pass into the container.  These can be flags added passed to the container entrypoint, or full command replacement.
 
-NOTE:
--	 Containers can be persistant, but such containers are generally not usefull, as the container command cannot be changed.  In most cases, command container volatility can still work, as long as persistant file and folder binds/maps are used to keep volatile information outside of the container.
+NOTES:
+	- Coach waits for the command to finish, and exits with the command exit code, so that run can be used in scripts and CI.
+	- If stdin is not a terminal, then the container runs without a TTY, and stdin is piped into the command, e.g.: $/> coach @mysql run mysql < dump.sql
+	- Containers can be persistant, but such containers are generally not usefull, as the container command cannot be changed.  In most cases, command container volatility can still work, as long as persistant file and folder binds/maps are used to keep volatile information outside of the container.
 
 TODO:
 	- Allow overriding of a container entrypoint via a flag?
 `)
 }
-func (operation *RunOperation) Run(logger log.Log) bool {
+func (operation *RunOperation) Run(logger log.Log) error {
 	logger.Info("Running operation: run")
 	logger.Debug(log.VERBOSITY_DEBUG, "Run:Targets", operation.targets.TargetOrder())
 
+	// only allocate a TTY when coach is attached to a terminal, so that piped input and output are not mangled
+	options := libs.RunOptions{
+		Persistant: operation.persistant,
+		Tty:        isTerminal(os.Stdin),
+		Stdin:      true,
+	}
+
+	operation.exitCode = 0
+	errs := libs.Errors{}
 	for _, targetID := range operation.targets.TargetOrder() {
 		target, targetExists := operation.targets.Target(targetID)
 		if !targetExists {
@@ -76,11 +92,24 @@ func (operation *RunOperation) Run(logger log.Log) bool {
 
 			for _, id := range instanceIds {
 				if instance, ok := instances.Instance(id); ok {
-					instance.Client().Run(logger, false, operation.cmd)
+					exitCode, err := instance.Client().Run(logger, operation.cmd, options)
+					if err != nil {
+						errs.Add(err)
+						operation.exitCode = 1
+					} else if exitCode != 0 {
+						nodeLogger.Warning("Command exited with code " + strconv.Itoa(exitCode))
+						errs.Add(errors.New(targetID + ": command exited with code " + strconv.Itoa(exitCode)))
+						operation.exitCode = exitCode
+					}
 				}
 			}
 		}
 	}
 
-	return true
+	return errs.Err()
+}
+
+// The exit code of the run command, for the coach process
+func (operation *RunOperation) ExitCode() int {
+	return operation.exitCode
 }
@@ -1,6 +1,7 @@
 package operation
 
 import (
+	"errors"
 	"strconv"
 
 	"github.com/james-nesbitt/coach/libs"
@@ -50,25 +51,18 @@ Access:
 	- only scaled type nodes with the "start" access are processed.  This effectively limits it to service type nodes.
 `)
 }
-func (operation *ScaleOperation) Run(logger log.Log) bool {
+func (operation *ScaleOperation) Run(logger log.Log) error {
 	logger.Info("Running operation: scale")
-	logger.Debug(log.VERBOSITY_DEBUG, "Run:Targets", operation.targets.TargetOrder())
 
 	if operation.scale == 0 {
 		operation.log.Warning("scale operation was told to scale to 0")
-		return false
+		return errors.New("scale operation was told to scale to 0")
 	}
 
-	for _, targetID := range operation.targets.TargetOrder() {
-		target, targetExists := operation.targets.Target(targetID)
-		if !targetExists {
-			// this is strange
-			logger.Warning("Internal target error, was told to use a target that doesn't exist")
-			continue
-		}
+	return operation.targets.Process(logger, func(nodeLogger log.Log, target *libs.Target) error {
+		errs := libs.Errors{}
 
 		node, hasNode := target.Node()
-		nodeLogger := logger.MakeChild(targetID)
 
 		if !hasNode {
 			nodeLogger.Info("No node [" + node.MachineName() + "]")
@@ -78,7 +72,8 @@ func (operation *ScaleOperation) Run(logger log.Log) bool {
 			nodeLogger.Message("Scaling node " + node.Id())
 
 			if operation.scale > 0 {
-				count := operation.ScaleUpNumber(nodeLogger, node.Instances(), operation.scale)
+				count, err := operation.ScaleUpNumber(nodeLogger, node.Instances(), operation.scale)
+				errs.Add(target.Record("", "scale-up", err))
 
 				if count == 0 {
 					nodeLogger.Warning("Scale operation could not scale up any new instances of node")
@@ -89,7 +84,8 @@ func (operation *ScaleOperation) Run(logger log.Log) bool {
 				}
 
 			} else {
-				count := operation.ScaleDownNumber(nodeLogger, node.Instances(), -operation.scale)
+				count, err := operation.ScaleDownNumber(nodeLogger, node.Instances(), -operation.scale)
+				errs.Add(target.Record("", "scale-down", err))
 
 				if count == 0 {
 					nodeLogger.Warning("Scale operation could not scale down any new instances of node")
@@ -101,12 +97,14 @@ func (operation *ScaleOperation) Run(logger log.Log) bool {
 			}
 
 		}
-	}
-	return true
+
+		return errs.Err()
+	})
 }
 
 // scale a node up a certain number of instances
-func (operation *ScaleOperation) ScaleUpNumber(logger log.Log, instances libs.Instances, number int) int {
+func (operation *ScaleOperation) ScaleUpNumber(logger log.Log, instances libs.Instances, number int) (int, error) {
+	errs := libs.Errors{}
 	count := 0
 	instancesOrder := instances.InstancesOrder()
 
@@ -119,24 +117,25 @@ InstanceScaleReturn:
 				continue InstanceScaleReturn
 			} else if !client.HasContainer() {
 				// create a new container for this instance
-				client.Create(logger, []string{}, false)
+				errs.Add(client.Create(logger, []string{}, false))
 			}
 
 			logger.Info("Node Scaling up. Starting instance :" + instanceId)
-			client.Start(logger, false)
+			errs.Add(client.Start(logger, false))
 
 			count++
 			if count >= number {
-				return count
+				return count, errs.Err()
 			}
 		}
 	}
 
-	return count
+	return count, errs.Err()
 }
 
 // scale a node down a certain number of instances
-func (operation *ScaleOperation) ScaleDownNumber(logger log.Log, instances libs.Instances, number int) int {
+func (operation *ScaleOperation) ScaleDownNumber(logger log.Log, instances libs.Instances, number int) (int, error) {
+	errs := libs.Errors{}
 
 	count := 0
 	instancesOrder := []string{}
@@ -154,18 +153,18 @@ InstanceScaleReturn:
 			}
 
 			logger.Info("Node Scaling down. Stopping instance :" + instanceId)
-			client.Stop(logger, operation.force, operation.timeout)
+			errs.Add(client.Stop(logger, operation.force, operation.timeout))
 
 			if operation.removeStopped {
-				client.Remove(logger, operation.force)
+				errs.Add(client.Remove(logger, operation.force))
 			}
 
 			count++
 			if count >= number {
-				return count
+				return count, errs.Err()
 			}
 		}
 	}
 
-	return count
+	return count, errs.Err()
 }
@@ -39,39 +39,38 @@ SYNTAX:
 
 ACCESS:
 	- This operation processed only nodes with the "start" access.  This excludes build, volume and command containers.
+
+NOTES:
+	- Nodes are not started until any target nodes that they depend on pass their Healthcheck: settings.  If a dependency doesn't become ready before its health check timeout, then the operation stops.
+	- If a node instance container can't be started, then the operation stops once the nodes at the same dependency level are finished, so that nodes which depend on it are not started.
 `)
 }
-func (operation *StartOperation) Run(logger log.Log) bool {
+func (operation *StartOperation) Run(logger log.Log) error {
 	logger.Info("Running operation: start")
-	logger.Debug(log.VERBOSITY_DEBUG, "Run:Targets", operation.targets.TargetOrder())
 
-	for _, targetID := range operation.targets.TargetOrder() {
-		target, targetExists := operation.targets.Target(targetID)
-		if !targetExists {
-			// this is strange
-			logger.Warning("Internal target error, was told to use a target that doesn't exist")
-			continue
-		}
+	return operation.targets.Process(logger, func(nodeLogger log.Log, target *libs.Target) error {
+		errs := libs.Errors{}
 
 		node, hasNode := target.Node()
 		instances, hasInstances := target.Instances()
 
-		nodeLogger := logger.MakeChild(targetID)
-
 		if !hasNode {
 			nodeLogger.Warning("No node [" + node.MachineName() + "]")
 		} else if !node.Can("start") {
 			nodeLogger.Info("Node doesn't Start [" + node.MachineName() + ":" + node.Type() + "]")
 		} else if !hasInstances {
 			nodeLogger.Info("No valid instances specified in target list [" + node.MachineName() + "]")
+		} else if err := operation.targets.WaitForDependencies(nodeLogger, node); err != nil {
+			nodeLogger.Error("Stopping the start operation, as a dependency of [" + target.Name() + "] is not ready")
+			return libs.StopProcess(target.Record("", "wait-dependencies", err))
 		} else {
 			nodeLogger.Message("Starting instance containers")
 			for _, id := range instances.InstancesOrder() {
 				instance, _ := instances.Instance(id)
-				instance.Client().Start(logger, operation.force)
+				errs.Add(libs.StopProcessOnFailure(target.Record(id, "start", instance.Client().Start(nodeLogger, operation.force))))
 			}
 		}
-	}
 
-	return true
+		return errs.Err()
+	})
 }
@@ -0,0 +1,207 @@
+package operation
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/james-nesbitt/coach/libs"
+	"github.com/james-nesbitt/coach/log"
+)
+
+const STATS_DEFAULT_INTERVAL = 2 // seconds between stats samples when streaming
+
+type StatsOperation struct {
+	log     log.Log
+	targets *libs.Targets
+
+	stream   bool
+	interval int
+}
+
+func (operation *StatsOperation) Id() string {
+	return "stats"
+}
+func (operation *StatsOperation) Flags(flags []string) bool {
+	operation.stream = false
+	operation.interval = STATS_DEFAULT_INTERVAL
+
+	for index := 0; index < len(flags); index++ {
+		flag := flags[index]
+
+		switch flag {
+		case "-f":
+			fallthrough
+		case "--stream":
+			operation.stream = true
+		case "--no-stream":
+			operation.stream = false
+		case "--interval":
+			if index+1 < len(flags) {
+				index++
+				if interval, err := strconv.Atoi(flags[index]); err == nil && interval > 0 {
+					operation.interval = interval
+				} else {
+					operation.log.Warning("Invalid stats interval [" + flags[index] + "], using the default")
+				}
+			}
+		}
+	}
+	return true
+}
+func (operation *StatsOperation) Help(topics []string) {
+	operation.log.Message(`Operation: STATS
+
+Coach will show the CPU, memory, network and block IO usage of running target node instances.
+
+SYNTAX:
+	$/> coach {targets} stats [--stream] [--interval {seconds}]
+
+	{targets} what target node instances the operation should process ($/> coach help targets)
+	--stream : keep refreshing the stats, until interrupted (default: show the stats once)
+	--interval {seconds} : how often to refresh the stats when streaming (default: 2)
+
+ACCESS:
+	- this operation processes only nodes with the "stats" access.  This excludes build and pull nodes, and named volume nodes.
+
+NOTES:
+	- Only running instances are shown.
+	- CPU usage is a percentage of a single CPU, so it can be more than 100% on hosts with more than one CPU.
+	- Memory usage doesn't include page cache, which the kernel can reclaim.
+`)
+}
+func (operation *StatsOperation) Run(logger log.Log) error {
+	logger.Info("Running operation: stats")
+	logger.Debug(log.VERBOSITY_DEBUG, "Run:Targets", operation.targets.TargetOrder())
+
+	nodes := []string{}
+	instanceIds := []string{}
+	clients := []libs.InstanceClient{}
+
+	for _, targetID := range operation.targets.TargetOrder() {
+		target, targetExists := operation.targets.Target(targetID)
+		if !targetExists {
+			// this is strange
+			logger.Warning("Internal target error, was told to use a target that doesn't exist")
+			continue
+		}
+
+		node, hasNode := target.Node()
+		instances, hasInstances := target.Instances()
+		nodeLogger := logger.MakeChild(targetID)
+
+		if !hasNode {
+			nodeLogger.Warning("No node [" + node.MachineName() + "]")
+		} else if !node.Can("stats") {
+			nodeLogger.Info("Node doesn't have stats [" + node.MachineName() + ":" + node.Type() + "]")
+		} else if !hasInstances {
+			nodeLogger.Info("No valid instances specified in target list [" + node.MachineName() + "]")
+		} else {
+			if !instances.IsFiltered() {
+				instances.UseAll()
+			}
+
+			for _, id := range instances.InstancesOrder() {
+				instance, _ := instances.Instance(id)
+				if !instance.IsRunning() {
+					nodeLogger.Info("Instance is not running [" + id + "]")
+					continue
+				}
+
+				nodes = append(nodes, node.Id())
+				instanceIds = append(instanceIds, id)
+				clients = append(clients, instance.Client())
+			}
+		}
+	}
+
+	if len(clients) == 0 {
+		logger.Message("No running instances were found to show stats for")
+		return nil
+	}
+
+	clearScreen := operation.stream && isTerminal(os.Stdout)
+	for {
+		// sampling can take a second per container, so all instances are sampled at the same time
+		stats := make([]libs.InstanceStats, len(clients))
+		sampled := make([]error, len(clients))
+		var wait sync.WaitGroup
+		for index, client := range clients {
+			wait.Add(1)
+			go func(index int, client libs.InstanceClient) {
+				defer wait.Done()
+				stats[index], sampled[index] = client.Stats(logger.MakeChild(nodes[index] + ":" + instanceIds[index]))
+			}(index, client)
+		}
+		wait.Wait()
+
+		if clearScreen {
+			fmt.Print("\033[H\033[2J")
+		}
+
+		w := new(tabwriter.Writer)
+		w.Init(logger, 8, 12, 2, ' ', 0)
+
+		row := []string{
+			"|=",
+			"Node",
+			"Instance",
+			"CPU %",
+			"Mem Usage / Limit",
+			"Mem %",
+			"Net I/O",
+			"Block I/O",
+			"PIDs",
+		}
+		w.Write([]byte(strings.Join(row, "\t") + "\n"))
+
+		errs := libs.Errors{}
+		for index := range clients {
+			row := []string{
+				"|-",
+				nodes[index],
+				instanceIds[index],
+			}
+			if sampled[index] == nil {
+				sample := stats[index]
+				row = append(row,
+					strconv.FormatFloat(sample.CPUPercent, 'f', 2, 64)+"%",
+					statsBytes(sample.MemoryUsage)+" / "+statsBytes(sample.MemoryLimit),
+					strconv.FormatFloat(sample.MemoryPercent(), 'f', 2, 64)+"%",
+					statsBytes(sample.NetworkRx)+" / "+statsBytes(sample.NetworkTx),
+					statsBytes(sample.BlockRead)+" / "+statsBytes(sample.BlockWrite),
+					strconv.FormatUint(sample.PIDs, 10),
+				)
+			} else {
+				row = append(row, "--", "--", "--", "--", "--", "--")
+				errs.Add(sampled[index])
+			}
+			w.Write([]byte(strings.Join(row, "\t") + "\n"))
+		}
+		w.Flush()
+
+		if !operation.stream {
+			return errs.Err()
+		}
+		time.Sleep(time.Duration(operation.interval) * time.Second)
+	}
+}
+
+// Format a byte count using binary units, in the same way as docker stats
+func statsBytes(bytes uint64) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB"}
+	value := float64(bytes)
+	unit := 0
+	for value >= 1024 && unit < len(units)-1 {
+		value /= 1024
+		unit++
+	}
+	if unit == 0 {
+		return strconv.FormatUint(bytes, 10) + "B"
+	}
+	return strconv.FormatFloat(value, 'f', 2, 64) + units[unit]
+}
@@ -0,0 +1,25 @@
+package operation
+
+import (
+	"testing"
+)
+
+func TestStatsBytes(t *testing.T) {
+	tests := []struct {
+		bytes  uint64
+		output string
+	}{
+		{bytes: 0, output: "0B"},
+		{bytes: 1023, output: "1023B"},
+		{bytes: 1024, output: "1.00KiB"},
+		{bytes: 3 << 19, output: "1.50MiB"},
+		{bytes: 5 << 40, output: "5.00TiB"},
+		{bytes: 2048 << 40, output: "2048.00TiB"},
+	}
+
+	for _, test := range tests {
+		if output := statsBytes(test.bytes); output != test.output {
+			t.Errorf("%d: formatted as %s, expected %s", test.bytes, output, test.output)
+		}
+	}
+}
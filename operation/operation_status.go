@@ -11,27 +11,59 @@ import (
 type StatusOperation struct {
 	log     log.Log
 	targets *libs.Targets
+
+	output string
+	format string // a go template for each target instance
 }
 
 func (operation *StatusOperation) Id() string {
 	return "Status"
 }
 func (operation *StatusOperation) Flags(flags []string) bool {
+	operation.format = ""
+
+	for index := 0; index < len(flags); index++ {
+		flag := flags[index]
+
+		switch {
+		case flag == "--format":
+			if index+1 < len(flags) {
+				index++
+				operation.format = flags[index]
+			}
+		case strings.HasPrefix(flag, "--format="):
+			operation.format = strings.TrimPrefix(flag, "--format=")
+		}
+	}
 	return true
 }
+func (operation *StatusOperation) SetOutput(format string) {
+	operation.output = format
+}
 func (operation *StatusOperation) Help(flags []string) {
 	operation.log.Message(`Operation: Status
 
 Coach will attempt to provide project Status by investigating target images and containers.
 
 SYNTAX:
-	$/> coach {targets} Status
+	$/> coach {targets} Status [--format {template}]
 
 	{targets} what target nodes the operation should process ($/> coach help targets)
+	--format {template} : write a line for each target instance using a go template ($/> coach help cli:format)
+
+NOTES:
+	- Use the global --output json or --output yaml flag to write a structured document, with the node image status, the container and running counts, and the state of each instance.
 
 `)
 }
-func (operation *StatusOperation) Run(logger log.Log) bool {
+func (operation *StatusOperation) Run(logger log.Log) error {
+	if operation.format != "" {
+		return writeTargetsFormat(logger, operation.targets, operation.format)
+	}
+	if operation.output != "" && operation.output != OUTPUT_TEXT {
+		return operation.runOutput(logger)
+	}
+
 	logger.Message("RUNNING Status OPERATION")
 
 	logger.Debug(log.VERBOSITY_DEBUG, "Run:Targets", operation.targets.TargetOrder())
@@ -63,7 +95,7 @@ func (operation *StatusOperation) Run(logger log.Log) bool {
 		nodeLogger.Message("[" + strings.Join(status, "][") + "]")
 	}
 
-	return true
+	return nil
 }
 
 func (operation *StatusOperation) NodeStatus(logger log.Log, node libs.Node) []string {
@@ -102,3 +134,21 @@ func (operation *StatusOperation) InstancesStatus(logger log.Log, instances libs
 
 	return status
 }
+
+// Write the status as a structured document
+func (operation *StatusOperation) runOutput(logger log.Log) error {
+	reports := []libs.NodeReport{}
+	for _, targetID := range operation.targets.TargetOrder() {
+		if target, targetExists := operation.targets.Target(targetID); targetExists {
+			report := targetReport(targetID, target)
+			report.Images = nil // status only reports if there is an image
+			reports = append(reports, report)
+		}
+	}
+
+	if err := writeOutput(operation.output, reports); err != nil {
+		logger.Error("Failed to write the status output => " + err.Error())
+		return err
+	}
+	return nil
+}
@@ -52,23 +52,15 @@ ACCESS:
 
 `)
 }
-func (operation *StopOperation) Run(logger log.Log) bool {
+func (operation *StopOperation) Run(logger log.Log) error {
 	logger.Info("Running operation: stop")
-	logger.Debug(log.VERBOSITY_DEBUG, "Run:Targets", operation.targets.TargetOrder())
-
-	for _, targetID := range operation.targets.TargetOrder() {
-		target, targetExists := operation.targets.Target(targetID)
-		if !targetExists {
-			// this is strange
-			logger.Warning("Internal target error, was told to use a target that doesn't exist")
-			continue
-		}
+
+	return operation.targets.ProcessReverse(logger, func(nodeLogger log.Log, target *libs.Target) error {
+		errs := libs.Errors{}
 
 		node, hasNode := target.Node()
 		instances, hasInstances := target.Instances()
 
-		nodeLogger := logger.MakeChild(targetID)
-
 		if !hasNode {
 			nodeLogger.Warning("No node [" + node.MachineName() + "]")
 		} else if !node.Can("Stop") {
@@ -87,13 +79,14 @@ func (operation *StopOperation) Run(logger log.Log) bool {
 				instance, _ := instances.Instance(id)
 
 				if instance.IsRunning() {
-					instance.Client().Stop(logger, operation.force, operation.timeout)
+					errs.Add(target.Record(id, "stop", instance.Client().Stop(nodeLogger, operation.force, operation.timeout)))
 				} else {
 					nodeLogger.Info("Instance [" + id + "] is not running")
+					target.Skip(id, "stop", "not running")
 				}
 			}
 		}
-	}
 
-	return true
+		return errs.Err()
+	})
 }
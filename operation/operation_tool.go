@@ -1,6 +1,8 @@
 package operation
 
 import (
+	"errors"
+
 	"github.com/james-nesbitt/coach/conf"
 	"github.com/james-nesbitt/coach/log"
 	"github.com/james-nesbitt/coach/tool"
@@ -46,7 +48,7 @@ to worry about the path to the script.
 `)
 }
 
-func (operation *ToolOperation) Run(logger log.Log) bool {
+func (operation *ToolOperation) Run(logger log.Log) error {
 	logger.Info("running tool operation")
 
 	if operation.tools == nil {
@@ -58,11 +60,13 @@ func (operation *ToolOperation) Run(logger log.Log) bool {
 
 	if operation.tool == "" {
 		operation.log.Error("No tool specified")
+		return errors.New("no tool specified")
 	} else if tool, ok := operation.tools.Tool(operation.tool); !ok {
 		operation.log.Error("Specified tool not found: " + operation.tool)
+		return errors.New("tool not found: " + operation.tool)
 	} else {
 		tool.Run(operation.flags)
 	}
 
-	return true
+	return nil
 }
@@ -30,23 +30,15 @@ ACCESS:
 	- This operation processed only nodes with the "start" access.  This excludes build, volume and command containers.
 `)
 }
-func (operation *UnpauseOperation) Run(logger log.Log) bool {
+func (operation *UnpauseOperation) Run(logger log.Log) error {
 	logger.Info("Running operation: unpause")
-	logger.Debug(log.VERBOSITY_DEBUG, "Run:Targets", operation.targets.TargetOrder())
 
-	for _, targetID := range operation.targets.TargetOrder() {
-		target, targetExists := operation.targets.Target(targetID)
-		if !targetExists {
-			// this is strange
-			logger.Warning("Internal target error, was told to use a target that doesn't exist")
-			continue
-		}
+	return operation.targets.Process(logger, func(nodeLogger log.Log, target *libs.Target) error {
+		errs := libs.Errors{}
 
 		node, hasNode := target.Node()
 		instances, hasInstances := target.Instances()
 
-		nodeLogger := logger.MakeChild(targetID)
-
 		if !hasNode {
 			nodeLogger.Warning("No node [" + node.MachineName() + "]")
 		} else if !node.Can("npause") {
@@ -65,11 +57,13 @@ func (operation *UnpauseOperation) Run(logger log.Log) bool {
 				instance, _ := instances.Instance(id)
 
 				if instance.IsRunning() {
-					instance.Client().Unpause(logger)
+					errs.Add(target.Record(id, "unpause", instance.Client().Unpause(nodeLogger)))
+				} else {
+					target.Skip(id, "unpause", "not running")
 				}
 			}
 		}
-	}
 
-	return true
+		return errs.Err()
+	})
 }
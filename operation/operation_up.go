@@ -14,7 +14,8 @@ type UpOperation struct {
 	log     log.Log
 	targets *libs.Targets
 
-	force bool
+	force   bool
+	options libs.BuildOptions
 }
 
 func (operation *UpOperation) Id() string {
@@ -22,6 +23,7 @@ func (operation *UpOperation) Id() string {
 }
 func (operation *UpOperation) Flags(flags []string) bool {
 	operation.force = false
+	operation.options = libs.BuildOptions{}
 
 	for _, flag := range flags {
 		switch flag {
@@ -29,6 +31,10 @@ func (operation *UpOperation) Flags(flags []string) bool {
 			fallthrough
 		case "--force":
 			operation.force = true
+		case "--no-cache":
+			operation.options.NoCache = true
+		case "--pull":
+			operation.options.Pull = true
 		}
 	}
 	return true
@@ -48,9 +54,15 @@ This operation is used to allow users to take a coach project
 from beginning to fully operational, with a single command.
 
 SYNTAX:
-	$/> coach {target} up
+	$/> coach {target} up [--force] [--no-cache] [--pull]
 
 	{targets} what target node instances the operation should process ($/> coach help targets)
+	--no-cache : build images without using any cached image layers
+	--pull : always try to pull a newer version of parent images when building
+
+NOTES:
+	- Nodes are not started until any target nodes that they depend on pass their Healthcheck: settings.  If a dependency doesn't become ready before its health check timeout, then the operation stops.
+	- If a node instance container can't be created or started, then the operation stops once the nodes at the same dependency level are finished, so that nodes which depend on it are not started.
 
 TODO:
 	- building images may take a long time, so maybe it should be optional;
@@ -59,21 +71,14 @@ TODO:
 	- perhaps check if contaienrs are running before starting them.
 `)
 }
-func (operation *UpOperation) Run(logger log.Log) bool {
+func (operation *UpOperation) Run(logger log.Log) error {
 	logger.Info("Running operation: up")
-	logger.Debug(log.VERBOSITY_DEBUG, "Run:Targets", operation.targets.TargetOrder())
-
-	for _, targetID := range operation.targets.TargetOrder() {
-		target, targetExists := operation.targets.Target(targetID)
-		if !targetExists {
-			// this is strange
-			logger.Warning("Internal target error, was told to use a target that doesn't exist")
-			continue
-		}
+
+	return operation.targets.Process(logger, func(nodeLogger log.Log, target *libs.Target) error {
+		errs := libs.Errors{}
 
 		node, hasNode := target.Node()
 		instances, hasInstances := target.Instances()
-		nodeLogger := logger.MakeChild(targetID)
 
 		build := node.Can("build")
 		pull := node.Can("pull")
@@ -91,17 +96,37 @@ func (operation *UpOperation) Run(logger log.Log) bool {
 			if build {
 				if operation.force || !nodeClient.HasImage() {
 					nodeLogger.Message("Building node image")
-					nodeClient.Build(nodeLogger, operation.force)
+					errs.Add(target.Record("", "build", nodeClient.Build(nodeLogger, operation.force, operation.options)))
 				} else {
 					nodeLogger.Info("Node already has an image built")
+					target.Skip("", "build", "already has an image")
 				}
 			}
 			if pull {
 				if operation.force || !nodeClient.HasImage() {
 					nodeLogger.Message("Pulling node image")
-					nodeClient.Pull(nodeLogger, operation.force)
+					errs.Add(target.Record("", "pull", nodeClient.Pull(nodeLogger, operation.force)))
 				} else {
 					nodeLogger.Info("Node already has an image pulled")
+					target.Skip("", "pull", "already has an image")
+				}
+			}
+			if node.Can("volume") {
+				if !nodeClient.HasVolume() {
+					nodeLogger.Message("Creating node volume")
+					errs.Add(target.Record("", "create-volume", nodeClient.CreateVolume(nodeLogger)))
+				} else {
+					nodeLogger.Info("Node already has a volume")
+					target.Skip("", "create-volume", "already has a volume")
+				}
+			}
+
+			// wait for any dependencies to pass their health checks before starting
+			if hasInstances && start {
+				if err := operation.targets.WaitForDependencies(nodeLogger, node); err != nil {
+					nodeLogger.Error("Stopping the up operation, as a dependency of [" + target.Name() + "] is not ready")
+					errs.Add(libs.StopProcess(target.Record("", "wait-dependencies", err)))
+					return errs.Err()
 				}
 			}
 
@@ -113,23 +138,25 @@ func (operation *UpOperation) Run(logger log.Log) bool {
 					if create {
 						if operation.force || !instanceClient.HasContainer() {
 							nodeLogger.Message("Creating node instance container : " + id)
-							instanceClient.Create(nodeLogger, []string{}, operation.force)
+							errs.Add(libs.StopProcessOnFailure(target.Record(id, "create", instanceClient.Create(nodeLogger, []string{}, operation.force))))
 						} else {
 							nodeLogger.Info("Instance already has an container created : " + id)
+							target.Skip(id, "create", "already has a container")
 						}
 					}
 					if start {
 						if operation.force || !instanceClient.IsRunning() {
 							nodeLogger.Message("Starting node instance container : " + id)
-							instanceClient.Start(nodeLogger, operation.force)
+							errs.Add(libs.StopProcessOnFailure(target.Record(id, "start", instanceClient.Start(nodeLogger, operation.force))))
 						} else {
 							nodeLogger.Info("Instance already has an container running : " + id)
+							target.Skip(id, "start", "already running")
 						}
 					}
 				}
 			}
 		}
-	}
 
-	return true
+		return errs.Err()
+	})
 }
@@ -0,0 +1,193 @@
+package operation
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/james-nesbitt/coach/libs"
+	"github.com/james-nesbitt/coach/log"
+)
+
+const (
+	OUTPUT_TEXT = "text" // human readable text, written through the logger
+	OUTPUT_JSON = "json"
+	OUTPUT_YAML = "yaml"
+)
+
+// Operation that can write a structured document instead of text
+type OutputOperation interface {
+	SetOutput(format string)
+}
+
+// Set the output format for any operations that can write structured documents
+func (operations *Operations) SetOutput(format string) bool {
+	switch format {
+	case OUTPUT_TEXT, OUTPUT_JSON, OUTPUT_YAML:
+	default:
+		operations.log.Error("Unknown output format [" + format + "], use one of: text, json, yaml")
+		return false
+	}
+
+	for _, operation := range operations.operationsList {
+		if outputOperation, ok := operation.(OutputOperation); ok {
+			outputOperation.SetOutput(format)
+		} else if format != OUTPUT_TEXT {
+			operations.log.Warning("Operation has no " + format + " output, so it will write text [" + operation.Id() + "]")
+		}
+	}
+	return true
+}
+
+// Write a structured document to stdout
+func writeOutput(format string, document interface{}) error {
+	var output []byte
+	var err error
+
+	switch format {
+	case OUTPUT_JSON:
+		output, err = json.MarshalIndent(document, "", "  ")
+		output = append(output, '\n')
+	case OUTPUT_YAML:
+		output, err = yaml.Marshal(document)
+	default:
+		err = errors.New("unknown output format: " + format)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = os.Stdout.Write(output)
+	return err
+}
+
+// A machine readable report for a target node and its instances
+func targetReport(targetID string, target *libs.Target) libs.NodeReport {
+	node, hasNode := target.Node()
+	if !hasNode {
+		return libs.NodeReport{Node: targetID, Instances: []libs.InstanceReport{}}
+	}
+
+	report := node.Client().Report()
+	report.Node = targetID
+	report.Type = node.Type()
+	report.Instances = []libs.InstanceReport{}
+
+	// all of the node instances are reported, but only the filtered instances are counted
+	filtered, hasFiltered := target.Instances()
+	for _, id := range node.Instances().InstancesOrder() {
+		instance, _ := node.Instances().Instance(id)
+
+		instanceReport := instance.Client().Report()
+		instanceReport.Instance = id
+		instanceReport.Default = instance.IsDefault()
+		if hasFiltered {
+			_, instanceReport.Filtered = filtered.Instance(id)
+		}
+
+		if instanceReport.Filtered && instanceReport.HasContainer {
+			report.Containers++
+			if instanceReport.Running {
+				report.Running++
+			}
+		}
+		report.Instances = append(report.Instances, instanceReport)
+	}
+	return report
+}
+
+/**
+ * Go template formatting
+ */
+
+// The data that a --format template is evaluated against, once for each target node instance.
+// The instance report fields (.Instance, .Container, .ID, .State, .Status, .Running ...) are
+// promoted, so that templates can use them directly.
+type formatRow struct {
+	libs.InstanceReport
+
+	Node         string          // the node name
+	Type         string          // the node type
+	HasImage     bool            // does the node have an image
+	Containers   int             // how many of the target instances have a container
+	RunningCount int             // how many of the target instances are running
+	Report       libs.NodeReport // the full node report, for images and volumes
+}
+
+// Functions that can be used in --format templates
+var formatFunctions = template.FuncMap{
+	"join":  strings.Join,
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"json": func(value interface{}) (string, error) {
+		output, err := json.Marshal(value)
+		return string(output), err
+	},
+}
+
+// Parse a --format template
+func parseFormat(format string) (*template.Template, error) {
+	return template.New("format").Funcs(formatFunctions).Parse(format)
+}
+
+// The format rows for a node report, one for each target instance, or one for the node if it has no target instances
+func formatRows(report libs.NodeReport) []formatRow {
+	row := formatRow{
+		Node:         report.Node,
+		Type:         report.Type,
+		HasImage:     report.HasImage,
+		Containers:   report.Containers,
+		RunningCount: report.Running,
+		Report:       report,
+	}
+
+	rows := []formatRow{}
+	for _, instance := range report.Instances {
+		if instance.Filtered {
+			instanceRow := row
+			instanceRow.InstanceReport = instance
+			rows = append(rows, instanceRow)
+		}
+	}
+	if len(rows) == 0 {
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// Write each row through a --format template, one line per row
+func writeFormat(format *template.Template, rows []formatRow) error {
+	for _, row := range rows {
+		if err := format.Execute(os.Stdout, row); err != nil {
+			return err
+		}
+		os.Stdout.Write([]byte("\n"))
+	}
+	return nil
+}
+
+// Write the reports for all of the target nodes through a --format template
+func writeTargetsFormat(logger log.Log, targets *libs.Targets, format string) error {
+	parsed, err := parseFormat(format)
+	if err != nil {
+		logger.Error("Invalid --format template => " + err.Error())
+		return err
+	}
+
+	rows := []formatRow{}
+	for _, targetID := range targets.TargetOrder() {
+		if target, targetExists := targets.Target(targetID); targetExists {
+			rows = append(rows, formatRows(targetReport(targetID, target))...)
+		}
+	}
+
+	if err := writeFormat(parsed, rows); err != nil {
+		logger.Error("Failed to write the --format output => " + err.Error())
+		return err
+	}
+	return nil
+}
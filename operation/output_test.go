@@ -0,0 +1,121 @@
+package operation
+
+import (
+	"bytes"
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	"github.com/james-nesbitt/coach/libs"
+	"github.com/james-nesbitt/coach/log"
+)
+
+func TestTargetReport(t *testing.T) {
+	logger := log.MakeCliLog("test", ioutil.Discard, log.VERBOSITY_MESSAGE)
+	project, nodes, backend := makeFakeProject(t, logger, fakeProjectFiles)
+	runFakeOperation(t, logger, project, nodes, backend, 1, []string{"$all"}, "up")
+
+	// only the filtered www instance is counted, but all of the instances are reported
+	targets := nodes.Targets(logger, []string{"www:1"}, libs.TargetsOptions{})
+	target, _ := targets.Target("www")
+	report := targetReport("www", target)
+
+	if report.Node != "www" || report.Type != "service" || !report.HasImage {
+		t.Errorf("Unexpected node report: %+v", report)
+	}
+	if report.Containers != 1 || report.Running != 1 {
+		t.Errorf("Expected the filtered instance to be counted, got %d containers and %d running", report.Containers, report.Running)
+	}
+
+	expected := []struct {
+		instance, state     string
+		isDefault, filtered bool
+	}{
+		{instance: "0", state: "running", isDefault: true},
+		{instance: "1", state: "running", isDefault: true, filtered: true},
+		{instance: "2"},
+		{instance: "3"},
+	}
+	if len(report.Instances) != len(expected) {
+		t.Fatalf("Expected %d instance reports, got %+v", len(expected), report.Instances)
+	}
+	for index, instance := range report.Instances {
+		if instance.Instance != expected[index].instance || instance.State != expected[index].state || instance.Default != expected[index].isDefault || instance.Filtered != expected[index].filtered || instance.Container != "fakeproject_www_"+expected[index].instance {
+			t.Errorf("Unexpected instance report: %+v", instance)
+		}
+	}
+}
+
+func TestFormatRows(t *testing.T) {
+	tests := []struct {
+		name   string
+		report libs.NodeReport
+		rows   []string // node:instance for each row
+	}{
+		{
+			name:   "node without instances",
+			report: libs.NodeReport{Node: "source", Type: "build", Instances: []libs.InstanceReport{}},
+			rows:   []string{"source:"},
+		},
+		{
+			name: "only the filtered instances",
+			report: libs.NodeReport{Node: "www", Type: "service", Instances: []libs.InstanceReport{
+				{Instance: "0", Filtered: true},
+				{Instance: "1"},
+				{Instance: "2", Filtered: true},
+			}},
+			rows: []string{"www:0", "www:2"},
+		},
+		{
+			name:   "no filtered instances",
+			report: libs.NodeReport{Node: "db", Type: "service", Instances: []libs.InstanceReport{{Instance: "single"}}},
+			rows:   []string{"db:"},
+		},
+	}
+
+	for _, test := range tests {
+		rows := formatRows(test.report)
+		names := []string{}
+		for _, row := range rows {
+			if row.Type != test.report.Type || row.Report.Node != test.report.Node {
+				t.Errorf("%s: row is missing the node report fields: %+v", test.name, row)
+			}
+			names = append(names, row.Node+":"+row.Instance)
+		}
+		if !reflect.DeepEqual(names, test.rows) {
+			t.Errorf("%s: rows %q, expected %q", test.name, names, test.rows)
+		}
+	}
+}
+
+func TestFormatTemplate(t *testing.T) {
+	row := formatRow{
+		InstanceReport: libs.InstanceReport{Instance: "1", Container: "project_www_1", State: "running", Ports: []string{"80/tcp", "443/tcp"}},
+		Node:           "www",
+		RunningCount:   2,
+	}
+
+	tests := []struct {
+		format string
+		output string
+		fail   bool
+	}{
+		{format: "{{.Node}}:{{.Instance}} {{.State}}", output: "www:1 running"},
+		{format: "{{upper .Node}} {{join .Ports \",\"}}", output: "WWW 80/tcp,443/tcp"},
+		{format: "{{.Container}} {{.RunningCount}}", output: "project_www_1 2"},
+		{format: "{{json .Ports}}", output: `["80/tcp","443/tcp"]`},
+		{format: "{{.Node", fail: true},
+		{format: "{{.Missing}}", fail: true},
+	}
+
+	for _, test := range tests {
+		output := &bytes.Buffer{}
+		parsed, err := parseFormat(test.format)
+		if err == nil {
+			err = parsed.Execute(output, row)
+		}
+		if (err != nil) != test.fail || (!test.fail && output.String() != test.output) {
+			t.Errorf("%s: output %q %v, expected %q (fail %v)", test.format, output.String(), err, test.output, test.fail)
+		}
+	}
+}
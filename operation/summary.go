@@ -0,0 +1,90 @@
+package operation
+
+import (
+	"strings"
+	"text/tabwriter"
+
+	"github.com/james-nesbitt/coach/libs"
+	"github.com/james-nesbitt/coach/log"
+)
+
+// One summary row, for a node or a node instance
+type summaryRow struct {
+	node      string
+	instance  string
+	attempted []string
+	succeeded []string
+	skipped   []string
+	failed    []string
+	notRun    []string
+}
+
+// Write a table of the actions that the operations attempted, for each node and instance
+func writeSummary(logger log.Log, summary *libs.Summary) {
+	records := summary.Records()
+	if len(records) == 0 {
+		return
+	}
+
+	rows := []*summaryRow{}
+	rowMap := map[string]*summaryRow{}
+	failures := []libs.ActionRecord{}
+	for _, record := range records {
+		key := record.Node + ":" + record.Instance
+		row, exists := rowMap[key]
+		if !exists {
+			row = &summaryRow{node: record.Node, instance: record.Instance}
+			rowMap[key] = row
+			rows = append(rows, row)
+		}
+
+		row.attempted = append(row.attempted, record.Action)
+		switch record.Result {
+		case libs.RESULT_SUCCEEDED:
+			row.succeeded = append(row.succeeded, record.Action)
+		case libs.RESULT_SKIPPED:
+			row.skipped = append(row.skipped, record.Action+" ("+record.Reason+")")
+		case libs.RESULT_FAILED:
+			row.failed = append(row.failed, record.Action)
+			failures = append(failures, record)
+		case libs.RESULT_NOT_RUN:
+			row.notRun = append(row.notRun, record.Action+" ("+record.Reason+")")
+		}
+	}
+
+	logger.Message("SUMMARY")
+
+	w := new(tabwriter.Writer)
+	w.Init(logger, 8, 12, 2, ' ', 0)
+
+	w.Write([]byte(strings.Join([]string{"|=", "Node", "Instance", "Attempted", "Succeeded", "Skipped", "Failed", "Not run"}, "\t") + "\n"))
+	for _, row := range rows {
+		w.Write([]byte(strings.Join([]string{
+			"|-",
+			row.node,
+			summaryCell(row.instance),
+			summaryCell(row.attempted...),
+			summaryCell(row.succeeded...),
+			summaryCell(row.skipped...),
+			summaryCell(row.failed...),
+			summaryCell(row.notRun...),
+		}, "\t") + "\n"))
+	}
+	w.Flush()
+
+	for _, failure := range failures {
+		name := failure.Node
+		if failure.Instance != "" {
+			name += ":" + failure.Instance
+		}
+		logger.Error("FAILED [" + name + "] " + failure.Action + " => " + failure.Reason)
+	}
+}
+
+// A summary table cell, with a placeholder for no values
+func summaryCell(values ...string) string {
+	if len(values) == 0 || (len(values) == 1 && values[0] == "") {
+		return "--"
+	}
+	return strings.Join(values, ", ")
+}